@@ -0,0 +1,356 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/artifact"
+	"google.golang.org/adk/runner"
+	"google.golang.org/adk/session"
+	"google.golang.org/genai"
+)
+
+// --- Batch Runs ---
+//
+// With --batch, the client runs a file of prompts through the root agent
+// instead of the console, e.g. for nightly evaluations. Each line of the file
+// is a prompt, or a JSON object with the prompt and its expected outcome:
+//
+//	Roll a 6-sided die and check if it's prime.
+//	{"id": "prime-7", "prompt": "Is 7 prime?",
+//	 "expect": {"contains": ["prime"], "matches": "(?i)\\byes\\b",
+//	  "tools": ["check_prime"], "transfers": ["prime_agent"]}}
+//
+// where the final text must contain each of contains, ignoring case, and
+// match the regular expression matches, and the run must call each of tools
+// and transfer to each of transfers. Blank lines and lines starting with #
+// are skipped.
+//
+// Each prompt runs in a session of its own, up to --batch_parallel at the
+// same time, without streaming. The results are written to --batch_out, or
+// stdout, as JSON lines in the order of the prompts, so that the outputs of
+// two batches can be diffed, best with --batch_metrics=false, which leaves
+// out latency_ms and usage:
+//
+//	{"line": 2, "id": "prime-7", "prompt": "Is 7 prime?", "session": "batch-2",
+//	 "text": "Yes, 7 is prime.", "agent": "prime_agent",
+//	 "tool_calls": [{"agent": "prime_agent", "name": "check_prime",
+//	   "args": {"nums": [7]}, "result": {"result": "7 is prime"}}],
+//	 "transfers": ["prime_agent"], "latency_ms": 1834,
+//	 "usage": {"prompt_tokens": 912, "candidates_tokens": 41, "total_tokens": 953, "model_calls": 3},
+//	 "passed": true}
+//
+// text is the last answer of the run and agent the agent which gave it.
+// Usage adds up the model calls made by this client: remote agents do not
+// report theirs. A failed run has an error, and a prompt with an expected
+// outcome it missed has passed set to false and the reasons in failures; the
+// client then exits with status 1. Calls to tools requiring approval are
+// left pending.
+
+const batchUserID = "batch"
+
+// batchConfig is where the prompts of a batch are read from and the results
+// written to.
+type batchConfig struct {
+	file     string
+	out      string
+	parallel int
+	metrics  bool
+}
+
+// batchFlags registers the flags of batch runs on fs.
+func batchFlags(fs *flag.FlagSet) *batchConfig {
+	cfg := &batchConfig{}
+	fs.StringVar(&cfg.file, "batch", "", "File of prompts to run instead of the console, one per line or as JSON objects with their expected outcome.")
+	fs.StringVar(&cfg.out, "batch_out", "", "File the results of --batch are written to as JSON lines, stdout if empty.")
+	fs.IntVar(&cfg.parallel, "batch_parallel", 1, "How many prompts of --batch run at the same time, each in a session of its own.")
+	fs.BoolVar(&cfg.metrics, "batch_metrics", true, "Whether the results of --batch include the latency and token usage of each prompt, which differ from run to run.")
+	return cfg
+}
+
+// batchCase is a prompt of the batch.
+type batchCase struct {
+	ID     string       `json:"id,omitempty"`
+	Prompt string       `json:"prompt"`
+	Expect *batchExpect `json:"expect,omitempty"`
+
+	line int
+}
+
+// batchExpect is the expected outcome of a prompt.
+type batchExpect struct {
+	Contains  []string `json:"contains,omitempty"`
+	Matches   string   `json:"matches,omitempty"`
+	Tools     []string `json:"tools,omitempty"`
+	Transfers []string `json:"transfers,omitempty"`
+
+	matches *regexp.Regexp
+}
+
+// batchResult is the outcome of a prompt.
+type batchResult struct {
+	Line      int             `json:"line"`
+	ID        string          `json:"id,omitempty"`
+	Prompt    string          `json:"prompt"`
+	Session   string          `json:"session"`
+	Text      string          `json:"text"`
+	Agent     string          `json:"agent,omitempty"`
+	ToolCalls []batchToolCall `json:"tool_calls"`
+	Transfers []string        `json:"transfers"`
+	LatencyMS *int64          `json:"latency_ms,omitempty"`
+	Usage     *batchUsage     `json:"usage,omitempty"`
+	Error     string          `json:"error,omitempty"`
+	Passed    *bool           `json:"passed,omitempty"`
+	Failures  []string        `json:"failures,omitempty"`
+}
+
+type batchToolCall struct {
+	Agent  string         `json:"agent"`
+	Name   string         `json:"name"`
+	Args   map[string]any `json:"args"`
+	Result map[string]any `json:"result,omitempty"`
+}
+
+type batchUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CandidatesTokens int `json:"candidates_tokens"`
+	ThoughtsTokens   int `json:"thoughts_tokens,omitempty"`
+	TotalTokens      int `json:"total_tokens"`
+	ModelCalls       int `json:"model_calls"`
+}
+
+// loadBatch reads the prompts of the batch file.
+func loadBatch(file string) ([]*batchCase, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read batch: %w", err)
+	}
+	defer f.Close()
+	var cases []*batchCase
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 1<<20)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		c := &batchCase{Prompt: text, line: line}
+		if strings.HasPrefix(text, "{") {
+			c = &batchCase{line: line}
+			dec := json.NewDecoder(strings.NewReader(text))
+			dec.DisallowUnknownFields()
+			if err := dec.Decode(c); err != nil {
+				return nil, fmt.Errorf("%s:%d: invalid prompt: %w", file, line, err)
+			}
+			if strings.TrimSpace(c.Prompt) == "" {
+				return nil, fmt.Errorf("%s:%d: prompt is empty", file, line)
+			}
+			if c.Expect != nil && c.Expect.Matches != "" {
+				if c.Expect.matches, err = regexp.Compile(c.Expect.Matches); err != nil {
+					return nil, fmt.Errorf("%s:%d: invalid matches: %w", file, line, err)
+				}
+			}
+		}
+		cases = append(cases, c)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read batch %s: %w", file, err)
+	}
+	if len(cases) == 0 {
+		return nil, fmt.Errorf("batch %s has no prompts", file)
+	}
+	return cases, nil
+}
+
+// runBatch runs the prompts of the batch with the root agent and writes
+// their results, reporting whether they all ran and met their expectations.
+func runBatch(ctx context.Context, cfg *batchConfig, root func() agent.Agent, sessions session.Service, artifacts artifact.Service) (bool, error) {
+	if cfg.parallel < 1 {
+		return false, fmt.Errorf("invalid --batch_parallel %d: must be positive", cfg.parallel)
+	}
+	cases, err := loadBatch(cfg.file)
+	if err != nil {
+		return false, err
+	}
+	var out io.Writer = os.Stdout
+	if cfg.out != "" {
+		f, err := os.Create(cfg.out)
+		if err != nil {
+			return false, fmt.Errorf("failed to create batch output: %w", err)
+		}
+		defer f.Close()
+		out = f
+	}
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt)
+	defer stop()
+
+	// Prompts run in any order, but results are written in order.
+	results := make([]chan *batchResult, len(cases))
+	for i := range results {
+		results[i] = make(chan *batchResult, 1)
+	}
+	next := make(chan int)
+	go func() {
+		defer close(next)
+		for i := range cases {
+			next <- i
+		}
+	}()
+	for range min(cfg.parallel, len(cases)) {
+		go func() {
+			for i := range next {
+				results[i] <- runBatchCase(ctx, cases[i], root, sessions, artifacts)
+			}
+		}()
+	}
+
+	log.Printf("Running %d prompts of %s, %d at a time", len(cases), cfg.file, cfg.parallel)
+	enc := json.NewEncoder(out)
+	failed, errored := 0, 0
+	for i := range cases {
+		r := <-results[i]
+		if r.Error != "" {
+			errored++
+		} else if r.Passed != nil && !*r.Passed {
+			failed++
+		}
+		if !cfg.metrics {
+			r.LatencyMS, r.Usage = nil, nil
+		}
+		if err := enc.Encode(r); err != nil {
+			return false, fmt.Errorf("failed to write batch results: %w", err)
+		}
+	}
+	if ctx.Err() != nil {
+		return false, errors.New("batch interrupted")
+	}
+	log.Printf("Ran %d prompts: %d failed, %d missed their expected outcome", len(cases), errored, failed)
+	return failed == 0 && errored == 0, nil
+}
+
+// runBatchCase runs the prompt in a new session.
+func runBatchCase(ctx context.Context, c *batchCase, root func() agent.Agent, sessions session.Service, artifacts artifact.Service) *batchResult {
+	res := &batchResult{
+		Line:      c.line,
+		ID:        c.ID,
+		Prompt:    c.Prompt,
+		Session:   fmt.Sprintf("batch-%d", c.line),
+		ToolCalls: []batchToolCall{},
+		Transfers: []string{},
+		Usage:     &batchUsage{},
+	}
+	defer res.check(c.Expect)
+	ag := root()
+	if _, err := sessions.Create(ctx, &session.CreateRequest{AppName: ag.Name(), UserID: batchUserID, SessionID: res.Session}); err != nil {
+		res.Error = fmt.Sprintf("failed to create session: %v", err)
+		return res
+	}
+	r, err := runner.New(runner.Config{AppName: ag.Name(), Agent: ag, SessionService: sessions, ArtifactService: artifacts})
+	if err != nil {
+		res.Error = fmt.Sprintf("failed to create runner: %v", err)
+		return res
+	}
+
+	calls := make(map[string]int) // index in res.ToolCalls by call ID
+	start := time.Now()
+	for event, err := range r.Run(ctx, batchUserID, res.Session, genai.NewContentFromText(c.Prompt, genai.RoleUser), agent.RunConfig{}) {
+		if err != nil {
+			res.Error = err.Error()
+			break
+		}
+		if event.ErrorMessage != "" {
+			res.Error = event.ErrorMessage
+		}
+		if u := event.UsageMetadata; u != nil {
+			res.Usage.PromptTokens += int(u.PromptTokenCount)
+			res.Usage.CandidatesTokens += int(u.CandidatesTokenCount)
+			res.Usage.ThoughtsTokens += int(u.ThoughtsTokenCount)
+			res.Usage.TotalTokens += int(u.TotalTokenCount)
+			res.Usage.ModelCalls++
+		}
+		if to := event.Actions.TransferToAgent; to != "" {
+			res.Transfers = append(res.Transfers, to)
+		}
+		if event.Content == nil {
+			continue
+		}
+		var text strings.Builder
+		for _, p := range event.Content.Parts {
+			switch {
+			case p.FunctionCall != nil:
+				calls[p.FunctionCall.ID] = len(res.ToolCalls)
+				res.ToolCalls = append(res.ToolCalls, batchToolCall{Agent: event.Author, Name: p.FunctionCall.Name, Args: p.FunctionCall.Args})
+			case p.FunctionResponse != nil:
+				if i, ok := calls[p.FunctionResponse.ID]; ok {
+					res.ToolCalls[i].Result = p.FunctionResponse.Response
+				}
+			case p.Text != "" && !p.Thought:
+				text.WriteString(p.Text)
+			}
+		}
+		if text.Len() > 0 && event.Author != "user" {
+			res.Text = text.String()
+			res.Agent = event.Author
+		}
+	}
+	latency := time.Since(start).Milliseconds()
+	res.LatencyMS = &latency
+	return res
+}
+
+// check sets whether the result meets the expected outcome, if any.
+func (r *batchResult) check(e *batchExpect) {
+	if e == nil {
+		return
+	}
+	if r.Error != "" {
+		r.Failures = append(r.Failures, "run failed")
+	}
+	for _, s := range e.Contains {
+		if !strings.Contains(strings.ToLower(r.Text), strings.ToLower(s)) {
+			r.Failures = append(r.Failures, fmt.Sprintf("text lacks %q", s))
+		}
+	}
+	if e.matches != nil && !e.matches.MatchString(r.Text) {
+		r.Failures = append(r.Failures, fmt.Sprintf("text does not match %q", e.Matches))
+	}
+	for _, name := range e.Tools {
+		if !slices.ContainsFunc(r.ToolCalls, func(c batchToolCall) bool { return c.Name == name }) {
+			r.Failures = append(r.Failures, fmt.Sprintf("tool %s not called", name))
+		}
+	}
+	for _, to := range e.Transfers {
+		if !slices.Contains(r.Transfers, to) {
+			r.Failures = append(r.Failures, fmt.Sprintf("no transfer to %s", to))
+		}
+	}
+	passed := len(r.Failures) == 0
+	r.Passed = &passed
+}
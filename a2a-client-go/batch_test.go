@@ -0,0 +1,238 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoadBatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []*batchCase
+		wantErr string
+	}{
+		{
+			name:    "plain lines",
+			content: "Roll a die.\n  Is 7 prime?  \n",
+			want:    []*batchCase{{Prompt: "Roll a die.", line: 1}, {Prompt: "Is 7 prime?", line: 2}},
+		},
+		{
+			name:    "blank lines and comments",
+			content: "# nightly\n\nRoll a die.\n   # skipped too\n",
+			want:    []*batchCase{{Prompt: "Roll a die.", line: 3}},
+		},
+		{
+			name:    "JSON",
+			content: `{"id": "prime-7", "prompt": "Is 7 prime?", "expect": {"contains": ["prime"], "tools": ["check_prime"], "transfers": ["prime_agent"]}}`,
+			want: []*batchCase{{ID: "prime-7", Prompt: "Is 7 prime?", line: 1, Expect: &batchExpect{
+				Contains: []string{"prime"}, Tools: []string{"check_prime"}, Transfers: []string{"prime_agent"},
+			}}},
+		},
+		{
+			name:    "JSON and plain lines",
+			content: "Roll a die.\n{\"prompt\": \"Is 7 prime?\"}\n",
+			want:    []*batchCase{{Prompt: "Roll a die.", line: 1}, {Prompt: "Is 7 prime?", line: 2}},
+		},
+		{
+			name:    "invalid JSON",
+			content: "Roll a die.\n{\"prompt\": \n",
+			wantErr: ":2: invalid prompt",
+		},
+		{
+			name:    "unknown field",
+			content: `{"prompt": "Is 7 prime?", "expected": {}}`,
+			wantErr: `:1: invalid prompt: json: unknown field "expected"`,
+		},
+		{
+			name:    "empty prompt",
+			content: `{"id": "none", "prompt": "  "}`,
+			wantErr: ":1: prompt is empty",
+		},
+		{
+			name:    "bad regular expression",
+			content: `{"prompt": "Is 7 prime?", "expect": {"matches": "(yes"}}`,
+			wantErr: ":1: invalid matches: error parsing regexp",
+		},
+		{
+			name:    "no prompts",
+			content: "# nothing yet\n\n",
+			wantErr: "has no prompts",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := filepath.Join(t.TempDir(), "batch.txt")
+			if err := os.WriteFile(file, []byte(tt.content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			got, err := loadBatch(file)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("loadBatch() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("loadBatch() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("loadBatch() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := loadBatch(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("loadBatch() of a missing file succeeded")
+	}
+}
+
+func TestLoadBatchMatches(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "batch.txt")
+	if err := os.WriteFile(file, []byte(`{"prompt": "Is 7 prime?", "expect": {"matches": "(?i)\\byes\\b"}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cases, err := loadBatch(file)
+	if err != nil {
+		t.Fatalf("loadBatch() error = %v", err)
+	}
+	if m := cases[0].Expect.matches; m == nil || !m.MatchString("Yes, it is.") || m.MatchString("Yesterday") {
+		t.Errorf("matches = %v, want (?i)\\byes\\b", m)
+	}
+}
+
+func TestBatchResultCheck(t *testing.T) {
+	result := func() *batchResult {
+		return &batchResult{
+			Text:      "Yes, 7 is prime.",
+			ToolCalls: []batchToolCall{{Agent: "prime_agent", Name: "check_prime"}},
+			Transfers: []string{"prime_agent"},
+		}
+	}
+	tests := []struct {
+		name         string
+		expect       string
+		error        string
+		wantPassed   *bool
+		wantFailures []string
+	}{
+		{
+			name: "no expected outcome",
+		},
+		{
+			name:       "met",
+			expect:     `{"contains": ["PRIME", "7"], "matches": "(?i)\\byes\\b", "tools": ["check_prime"], "transfers": ["prime_agent"]}`,
+			wantPassed: ptr(true),
+		},
+		{
+			name:         "missing text",
+			expect:       `{"contains": ["prime", "even"]}`,
+			wantPassed:   ptr(false),
+			wantFailures: []string{`text lacks "even"`},
+		},
+		{
+			name:         "not matching",
+			expect:       `{"matches": "^No"}`,
+			wantPassed:   ptr(false),
+			wantFailures: []string{`text does not match "^No"`},
+		},
+		{
+			name:         "tool not called",
+			expect:       `{"tools": ["check_prime", "roll_die"]}`,
+			wantPassed:   ptr(false),
+			wantFailures: []string{"tool roll_die not called"},
+		},
+		{
+			name:         "no transfer",
+			expect:       `{"transfers": ["roll_agent"]}`,
+			wantPassed:   ptr(false),
+			wantFailures: []string{"no transfer to roll_agent"},
+		},
+		{
+			name:         "run failed",
+			expect:       `{"contains": ["prime"]}`,
+			error:        "model unavailable",
+			wantPassed:   ptr(false),
+			wantFailures: []string{"run failed"},
+		},
+		{
+			name:         "several reasons",
+			expect:       `{"contains": ["even"], "tools": ["roll_die"], "transfers": ["roll_agent"]}`,
+			wantPassed:   ptr(false),
+			wantFailures: []string{`text lacks "even"`, "tool roll_die not called", "no transfer to roll_agent"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := filepath.Join(t.TempDir(), "batch.txt")
+			line := "Is 7 prime?"
+			if tt.expect != "" {
+				line = `{"prompt": "Is 7 prime?", "expect": ` + tt.expect + `}`
+			}
+			if err := os.WriteFile(file, []byte(line), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			cases, err := loadBatch(file)
+			if err != nil {
+				t.Fatalf("loadBatch() error = %v", err)
+			}
+
+			r := result()
+			r.Error = tt.error
+			r.check(cases[0].Expect)
+			if !reflect.DeepEqual(r.Passed, tt.wantPassed) || !reflect.DeepEqual(r.Failures, tt.wantFailures) {
+				t.Errorf("check() passed = %v, failures = %q, want %v, %q", deref(r.Passed), r.Failures, deref(tt.wantPassed), tt.wantFailures)
+			}
+		})
+	}
+}
+
+func TestBatchResultMetrics(t *testing.T) {
+	latency := int64(1834)
+	r := &batchResult{Line: 1, Prompt: "Roll a die.", LatencyMS: &latency, Usage: &batchUsage{TotalTokens: 953, ModelCalls: 3}}
+	data, err := json.Marshal(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := string(data); !strings.Contains(s, `"latency_ms":1834`) || !strings.Contains(s, `"total_tokens":953`) {
+		t.Errorf("result = %s, want latency_ms and usage", s)
+	}
+
+	// Without metrics, two runs of the same batch can give the same results.
+	r.LatencyMS, r.Usage = nil, nil
+	if data, err = json.Marshal(r); err != nil {
+		t.Fatal(err)
+	}
+	if s := string(data); strings.Contains(s, "latency_ms") || strings.Contains(s, "usage") {
+		t.Errorf("result without metrics = %s", s)
+	}
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func deref(p *bool) any {
+	if p == nil {
+		return nil
+	}
+	return *p
+}
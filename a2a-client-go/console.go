@@ -0,0 +1,382 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+
+	a2acore "github.com/a2aproject/a2a-go/a2a"
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/artifact"
+	"google.golang.org/adk/runner"
+	"google.golang.org/adk/session"
+	"google.golang.org/genai"
+
+	"a2a-common-go/remote"
+	"a2a-common-go/toolkit"
+)
+
+// --- Console ---
+//
+// The client is an interactive console: each input of the user, see
+// consoleinput.go, is sent to the root agent in the current session, and the
+// answers and tool calls are printed as they come. Inputs starting with a
+// slash are commands:
+//
+//	/new       starts a new session
+//	/sessions  lists the sessions, marking the current one
+//	/state     prints the state of the current session
+//	/help      lists the commands
+//	/quit      exits, as does the end of the input
+//
+// While a run is in progress, ctrl-c cancels it, and the console takes
+// commands:
+//
+//	/cancel         cancels the tasks of remote agents in flight
+//	/cancel <task>  cancels the remote task with the given ID
+//
+// Anything else typed then is kept as the next input.
+//
+// Canceling a remote task stops its run and tools on the remote server, see
+// remote.TaskTracker; the agent which called it gets the canceled task as its
+// answer.
+//
+// With --streaming=sse, the text of the answers arrives in partial events,
+// printed as they come, then whole in a final event, which is not printed
+// again. Without streaming, each answer is printed whole.
+
+const consoleUserID = "user-123"
+
+const consoleHelp = `Commands:
+  /new       start a new session
+  /sessions  list the sessions
+  /state     print the state of the session
+  /help      list the commands
+  /quit      exit
+During a run, ctrl-c cancels it and /cancel [task] cancels remote tasks.
+End a line with \ to continue it, or write several between two lines of """.`
+
+// console runs the inputs of the user with the root agent.
+type console struct {
+	root      func() agent.Agent
+	sessions  session.Service
+	artifacts artifact.Service
+	streaming agent.StreamingMode
+	in        *consoleInput
+	w         io.Writer
+	out       *eventPrinter
+
+	session string // ID of the current session
+	created int    // sessions created, numbering them
+}
+
+func newConsole(root func() agent.Agent, sessions session.Service, artifacts artifact.Service, streaming agent.StreamingMode, in *consoleInput, w io.Writer) *console {
+	return &console{root: root, sessions: sessions, artifacts: artifacts, streaming: streaming, in: in, w: w, out: &eventPrinter{w: w}}
+}
+
+// appName is the app of the sessions: the root agent, whose name does not
+// change with the remote agents.
+func (c *console) appName() string {
+	return c.root().Name()
+}
+
+// run reads and runs inputs until the user quits or the input ends.
+func (c *console) run(ctx context.Context) error {
+	if err := c.newSession(ctx); err != nil {
+		return err
+	}
+	fmt.Fprintln(c.w, "Type a message, or /help for the commands.")
+	for {
+		input, err := c.in.readInput("User: ")
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		input = strings.TrimSpace(input)
+		switch {
+		case input == "":
+		case strings.HasPrefix(input, "/"):
+			quit, err := c.command(ctx, input)
+			if err != nil {
+				fmt.Fprintf(c.w, "%s failed: %v\n", input, err)
+			}
+			if quit {
+				return nil
+			}
+		default:
+			c.send(ctx, genai.NewContentFromText(input, genai.RoleUser))
+		}
+	}
+}
+
+// command runs the command, reporting whether the user quits.
+func (c *console) command(ctx context.Context, line string) (quit bool, err error) {
+	switch fields := strings.Fields(line); fields[0] {
+	case "/quit", "/exit":
+		return true, nil
+	case "/help":
+		fmt.Fprintln(c.w, consoleHelp)
+	case "/new":
+		if err := c.newSession(ctx); err != nil {
+			return false, err
+		}
+		fmt.Fprintf(c.w, "Started session %s.\n", c.session)
+	case "/sessions":
+		return false, c.listSessions(ctx)
+	case "/state":
+		return false, c.printState(ctx)
+	case "/cancel":
+		fmt.Fprintln(c.w, "No run in progress.")
+	default:
+		fmt.Fprintf(c.w, "Unknown command %s, see /help.\n", fields[0])
+	}
+	return false, nil
+}
+
+// newSession creates a session and makes it the current one.
+func (c *console) newSession(ctx context.Context) error {
+	c.created++
+	id := fmt.Sprintf("session-%d", c.created)
+	if _, err := c.sessions.Create(ctx, &session.CreateRequest{AppName: c.appName(), UserID: consoleUserID, SessionID: id}); err != nil {
+		return fmt.Errorf("failed to create session: %w", err)
+	}
+	c.session = id
+	return nil
+}
+
+func (c *console) listSessions(ctx context.Context) error {
+	resp, err := c.sessions.List(ctx, &session.ListRequest{AppName: c.appName(), UserID: consoleUserID})
+	if err != nil {
+		return err
+	}
+	sessions := slices.SortedFunc(slices.Values(resp.Sessions), func(a, b session.Session) int {
+		return a.LastUpdateTime().Compare(b.LastUpdateTime())
+	})
+	for _, s := range sessions {
+		// Listed sessions come without their events.
+		full, err := c.sessions.Get(ctx, &session.GetRequest{AppName: c.appName(), UserID: consoleUserID, SessionID: s.ID()})
+		if err != nil {
+			return err
+		}
+		current := " "
+		if s.ID() == c.session {
+			current = "*"
+		}
+		fmt.Fprintf(c.w, "%s %s  %d events, updated %s\n", current, s.ID(), full.Session.Events().Len(), s.LastUpdateTime().Format("15:04:05"))
+	}
+	return nil
+}
+
+func (c *console) printState(ctx context.Context) error {
+	resp, err := c.sessions.Get(ctx, &session.GetRequest{AppName: c.appName(), UserID: consoleUserID, SessionID: c.session})
+	if err != nil {
+		return err
+	}
+	state := maps.Collect(resp.Session.State().All())
+	if len(state) == 0 {
+		fmt.Fprintf(c.w, "Session %s has no state.\n", c.session)
+		return nil
+	}
+	// Maps are encoded with sorted keys.
+	b, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(c.w, string(b))
+	return nil
+}
+
+// send runs the content, then the decisions of the user on the calls
+// waiting for approval, until none is.
+func (c *console) send(ctx context.Context, content *genai.Content) {
+	for content != nil {
+		pending := c.turn(ctx, content)
+		content = nil
+		for _, call := range pending {
+			if content == nil {
+				content = &genai.Content{Role: genai.RoleUser}
+			}
+			content.Parts = append(content.Parts, askApproval(c.in, call))
+		}
+	}
+}
+
+// turn runs the content with the current root agent, which changes with the
+// remote agents, and returns the calls waiting for approval.
+func (c *console) turn(ctx context.Context, content *genai.Content) []*genai.FunctionCall {
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt)
+	defer stop()
+	stopCommands := watchCommands(c.in, c.w)
+	defer stopCommands()
+	defer c.out.end()
+
+	r, err := runner.New(runner.Config{
+		AppName:         c.appName(),
+		Agent:           c.root(),
+		SessionService:  c.sessions,
+		ArtifactService: c.artifacts,
+	})
+	if err != nil {
+		fmt.Fprintf(c.w, "Failed to create runner: %v\n", err)
+		return nil
+	}
+	calls := make(map[string]*genai.FunctionCall)
+	var pending []*genai.FunctionCall
+	for event, err := range r.Run(ctx, consoleUserID, c.session, content, agent.RunConfig{StreamingMode: c.streaming}) {
+		if err != nil {
+			c.out.end()
+			if ctx.Err() != nil {
+				break
+			}
+			fmt.Fprintf(c.w, "Agent run error: %v\n", err)
+			continue
+		}
+		c.out.print(event)
+		if event.Content == nil {
+			continue
+		}
+		for _, part := range event.Content.Parts {
+			if part.FunctionCall != nil {
+				calls[part.FunctionCall.ID] = part.FunctionCall
+			}
+			if fr := part.FunctionResponse; fr != nil && fr.Response["status"] == toolkit.PendingApprovalStatus {
+				if call, ok := calls[fr.ID]; ok {
+					pending = append(pending, call)
+				}
+			}
+		}
+	}
+	if ctx.Err() != nil {
+		c.out.end()
+		fmt.Fprintln(c.w, "Run canceled.")
+		return nil
+	}
+	return pending
+}
+
+// eventPrinter prints the events of runs on the console.
+type eventPrinter struct {
+	w         io.Writer
+	streaming bool // in the middle of a line of streamed text
+}
+
+// print prints the text, tool calls and tool results of the event.
+func (p *eventPrinter) print(event *session.Event) {
+	if event.Content == nil {
+		return
+	}
+	for _, part := range event.Content.Parts {
+		switch {
+		case part.Text != "" && event.Partial:
+			if !p.streaming {
+				fmt.Fprint(p.w, "Bot: ")
+				p.streaming = true
+			}
+			fmt.Fprint(p.w, part.Text)
+		case part.Text != "" && p.streaming:
+			// The whole text of what was just streamed.
+			p.end()
+		case part.Text != "":
+			fmt.Fprintf(p.w, "Bot: %s\n", part.Text)
+		case part.FunctionCall != nil:
+			p.end()
+			fmt.Fprintf(p.w, "Bot calls tool: %s with args: %v\n", part.FunctionCall.Name, part.FunctionCall.Args)
+		case part.FunctionResponse != nil:
+			p.end()
+			fmt.Fprintf(p.w, "Tool %s returns: %v\n", part.FunctionResponse.Name, part.FunctionResponse.Response)
+		}
+	}
+}
+
+// end ends the line of streamed text, if any.
+func (p *eventPrinter) end() {
+	if p.streaming {
+		fmt.Fprintln(p.w)
+		p.streaming = false
+	}
+}
+
+// watchCommands handles the commands typed during a run until stop is
+// called, after which the console can be read again. Other lines typed
+// during the run are kept, in order, for the next prompts.
+func watchCommands(in *consoleInput, w io.Writer) (stop func()) {
+	stopped := make(chan struct{})
+	done := make(chan struct{})
+	var typedAhead strings.Builder
+	go func() {
+		defer close(done)
+		for {
+			line, err := in.readPlainLine(stopped)
+			if err != nil {
+				return
+			}
+			if !runCommand(w, line) {
+				typedAhead.WriteString(line + "\n")
+			}
+		}
+	}()
+	return func() {
+		close(stopped)
+		<-done
+		in.unread(typedAhead.String())
+	}
+}
+
+// runCommand runs the line typed during a run if it is a command of runs,
+// reporting whether it was.
+func runCommand(w io.Writer, line string) bool {
+	fields := strings.Fields(line)
+	if len(fields) == 0 || fields[0] != "/cancel" {
+		return false
+	}
+	if len(fields) > 2 {
+		fmt.Fprintln(w, "Usage: /cancel [task]")
+		return true
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), remote.CancelTimeout)
+	defer cancel()
+	var ids []a2acore.TaskID
+	if len(fields) == 2 {
+		ids = append(ids, a2acore.TaskID(fields[1]))
+	} else {
+		for _, t := range remote.Tasks.InFlight() {
+			ids = append(ids, t.ID)
+		}
+	}
+	if len(ids) == 0 {
+		fmt.Fprintln(w, "No remote task in flight.")
+		return true
+	}
+	for _, id := range ids {
+		task, err := remote.Tasks.Cancel(ctx, id)
+		if err != nil {
+			fmt.Fprintf(w, "Failed to cancel remote task %s: %v\n", id, err)
+			continue
+		}
+		fmt.Fprintf(w, "Remote task %s is %s.\n", id, task.Status.State)
+	}
+	return true
+}
@@ -0,0 +1,41 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWatchCommands(t *testing.T) {
+	chunks := make(chan []byte)
+	in := &consoleInput{chunks: chunks, out: &strings.Builder{}, history: &history{}}
+	out := &strings.Builder{}
+
+	stop := watchCommands(in, out)
+	chunks <- []byte("first\n/cancel\nsecond\n/cancel\npar")
+	stop()
+	if got := strings.Count(out.String(), "No remote task in flight."); got != 2 {
+		t.Errorf("output = %q, want both /cancel commands run", out.String())
+	}
+
+	// The lines typed ahead are read in order, then the one cut short.
+	go func() { chunks <- []byte("tial\n") }()
+	for _, want := range []string{"first", "second", "partial"} {
+		if line, err := in.readPlainLine(nil); line != want || err != nil {
+			t.Errorf("readPlainLine() = %q, %v, want %q", line, err, want)
+		}
+	}
+}
@@ -0,0 +1,258 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// --- Console Input ---
+//
+// The console reads what the user types from stdin. On a terminal, prompts
+// are read with line editing, see lineedit.go, and the lines typed are kept
+// in a history file, recalled with the up and down keys. Elsewhere, e.g.
+// when stdin is piped, lines are read as they come.
+//
+// An input spans several lines when each but the last ends with a
+// backslash, or when it is written between two lines of """.
+//
+// Stdin is read by a single goroutine, so that lines typed during a run can
+// be read as commands, see console.go, and the rest at the next prompt.
+
+const (
+	// historyFileEnv is the default of --history_file.
+	historyFileEnv = "A2A_CLIENT_HISTORY"
+
+	// maxHistory is how many lines the history keeps.
+	maxHistory = 1000
+
+	continuationPrompt = "...   "
+	multiLineDelimiter = `"""`
+)
+
+// errStopped is returned by reads stopped before a line was read.
+var errStopped = errors.New("read stopped")
+
+// consoleInput reads the console.
+type consoleInput struct {
+	fd       int
+	terminal bool
+	chunks   <-chan []byte // read from stdin, closed at its end
+	pending  []byte        // read but not consumed
+	out      io.Writer     // where prompts and edited lines are echoed
+	history  *history
+}
+
+// newConsoleInput starts reading in. Lines typed on a terminal are kept in
+// historyFile, if not empty.
+func newConsoleInput(in *os.File, out io.Writer, historyFile string) (*consoleInput, error) {
+	h, err := loadHistory(historyFile)
+	if err != nil {
+		return nil, err
+	}
+	chunks := make(chan []byte)
+	go func() {
+		defer close(chunks)
+		for {
+			buf := make([]byte, 4096)
+			n, err := in.Read(buf)
+			if n > 0 {
+				chunks <- buf[:n]
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+	fd := int(in.Fd())
+	return &consoleInput{fd: fd, terminal: isTerminal(fd), chunks: chunks, out: out, history: h}, nil
+}
+
+// readByte reads the next byte, until stop is closed.
+func (c *consoleInput) readByte(stop <-chan struct{}) (byte, error) {
+	for len(c.pending) == 0 {
+		select {
+		case chunk, ok := <-c.chunks:
+			if !ok {
+				return 0, io.EOF
+			}
+			c.pending = chunk
+		case <-stop:
+			return 0, errStopped
+		}
+	}
+	b := c.pending[0]
+	c.pending = c.pending[1:]
+	return b, nil
+}
+
+// readPlainLine reads a line as it comes, until stop is closed. A line cut
+// short by stop is read again by the next read.
+func (c *consoleInput) readPlainLine(stop <-chan struct{}) (string, error) {
+	var line []byte
+	for {
+		b, err := c.readByte(stop)
+		switch {
+		case errors.Is(err, errStopped):
+			c.unread(string(line))
+			return "", err
+		case errors.Is(err, io.EOF) && len(line) > 0:
+			return string(line), nil
+		case err != nil:
+			return "", err
+		case b == '\n':
+			return strings.TrimSuffix(string(line), "\r"), nil
+		}
+		line = append(line, b)
+	}
+}
+
+// unread makes s the next input read.
+func (c *consoleInput) unread(s string) {
+	c.pending = append([]byte(s), c.pending...)
+}
+
+// readLine prints the prompt and reads a line, edited on a terminal.
+func (c *consoleInput) readLine(prompt string) (string, error) {
+	if c.terminal {
+		return c.editLine(prompt)
+	}
+	fmt.Fprint(c.out, prompt)
+	return c.readPlainLine(nil)
+}
+
+// readInput reads an input of the user, on one line or several, keeping the
+// lines typed in the history.
+func (c *consoleInput) readInput(prompt string) (string, error) {
+	line, err := c.readLine(prompt)
+	if err != nil {
+		return "", err
+	}
+	c.remember(line)
+	if strings.TrimSpace(line) == multiLineDelimiter {
+		var lines []string
+		for {
+			line, err := c.readLine(continuationPrompt)
+			if err != nil {
+				return "", err
+			}
+			if strings.TrimSpace(line) == multiLineDelimiter {
+				return strings.Join(lines, "\n"), nil
+			}
+			lines = append(lines, line)
+		}
+	}
+	var lines []string
+	for strings.HasSuffix(line, `\`) {
+		lines = append(lines, strings.TrimSuffix(line, `\`))
+		if line, err = c.readLine(continuationPrompt); err != nil {
+			return "", err
+		}
+		c.remember(line)
+	}
+	return strings.Join(append(lines, line), "\n"), nil
+}
+
+// remember adds the line to the history if it was typed on a terminal.
+func (c *consoleInput) remember(line string) {
+	if c.terminal {
+		c.history.add(line)
+	}
+}
+
+// history is the lines typed, oldest first, kept in a file if any.
+type history struct {
+	file  string
+	lines []string
+}
+
+// loadHistory loads the history kept in file, which need not exist yet.
+func loadHistory(file string) (*history, error) {
+	h := &history{file: file}
+	if file == "" {
+		return h, nil
+	}
+	f, err := os.Open(file)
+	if errors.Is(err, os.ErrNotExist) {
+		return h, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history: %w", err)
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		h.lines = append(h.lines, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read history %s: %w", file, err)
+	}
+	if len(h.lines) > maxHistory {
+		h.lines = h.lines[len(h.lines)-maxHistory:]
+		// Rewrite the file, so that it does not grow forever.
+		if err := os.WriteFile(file, []byte(strings.Join(h.lines, "\n")+"\n"), 0o600); err != nil {
+			return nil, fmt.Errorf("failed to trim history %s: %w", file, err)
+		}
+	}
+	return h, nil
+}
+
+// add adds the line to the history, unless it is blank or repeats the last
+// one.
+func (h *history) add(line string) {
+	if strings.TrimSpace(line) == "" || len(h.lines) > 0 && h.lines[len(h.lines)-1] == line {
+		return
+	}
+	h.lines = append(h.lines, line)
+	if len(h.lines) > maxHistory {
+		h.lines = h.lines[1:]
+	}
+	if h.file == "" {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(h.file), 0o700); err != nil {
+		log.Printf("Failed to save history: %v", err)
+		return
+	}
+	f, err := os.OpenFile(h.file, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		log.Printf("Failed to save history: %v", err)
+		return
+	}
+	defer f.Close()
+	if _, err := fmt.Fprintln(f, line); err != nil {
+		log.Printf("Failed to save history: %v", err)
+	}
+}
+
+// defaultHistoryFile returns $A2A_CLIENT_HISTORY, else a file in the home
+// directory, if any.
+func defaultHistoryFile() string {
+	if v := os.Getenv(historyFileEnv); v != "" {
+		return v
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".a2a_client_history")
+}
@@ -0,0 +1,167 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// newTestInput returns a console input reading the given chunks, with the
+// history lines. It is on a terminal if terminal is set, though not one that
+// can be put in raw mode.
+func newTestInput(terminal bool, lines []string, chunks ...string) (*consoleInput, *strings.Builder) {
+	ch := make(chan []byte, len(chunks))
+	for _, c := range chunks {
+		ch <- []byte(c)
+	}
+	close(ch)
+	out := &strings.Builder{}
+	return &consoleInput{fd: -1, terminal: terminal, chunks: ch, out: out, history: &history{lines: lines}}, out
+}
+
+func TestReadInput(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []string
+	}{
+		{name: "lines", input: "hello\nworld\n", want: []string{"hello", "world"}},
+		{name: "CRLF", input: "hello\r\n", want: []string{"hello"}},
+		{name: "no final newline", input: "hello", want: []string{"hello"}},
+		{name: "backslashes", input: "one\\\ntwo\\\nthree\nfour\n", want: []string{"one\ntwo\nthree", "four"}},
+		{name: "triple quotes", input: "\"\"\"\nfirst\n\n  indented \\\n\"\"\"\nafter\n", want: []string{"first\n\n  indented \\", "after"}},
+		{name: "empty triple quotes", input: "\"\"\"\n  \"\"\"  \n", want: []string{""}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, out := newTestInput(false, nil, tt.input)
+			var got []string
+			for {
+				input, err := c.readInput("> ")
+				if errors.Is(err, io.EOF) {
+					break
+				}
+				if err != nil {
+					t.Fatalf("readInput() error = %v", err)
+				}
+				got = append(got, input)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("inputs = %q, want %q", got, tt.want)
+			}
+			if !strings.HasPrefix(out.String(), "> ") {
+				t.Errorf("output = %q, want the prompt", out.String())
+			}
+		})
+	}
+}
+
+func TestReadInputUnfinished(t *testing.T) {
+	for _, input := range []string{"one\\\n", "\"\"\"\nfirst\n"} {
+		c, _ := newTestInput(false, nil, input)
+		if _, err := c.readInput("> "); !errors.Is(err, io.EOF) {
+			t.Errorf("readInput(%q) error = %v, want EOF", input, err)
+		}
+	}
+}
+
+func TestReadPlainLineStopped(t *testing.T) {
+	chunks := make(chan []byte)
+	c := &consoleInput{chunks: chunks, out: io.Discard, history: &history{}}
+
+	stop := make(chan struct{})
+	stopped := make(chan error)
+	go func() {
+		_, err := c.readPlainLine(stop)
+		stopped <- err
+	}()
+	chunks <- []byte("par")
+	close(stop)
+	if err := <-stopped; !errors.Is(err, errStopped) {
+		t.Fatalf("readPlainLine() error = %v, want %v", err, errStopped)
+	}
+
+	// The line cut short is read again with the rest.
+	go func() { chunks <- []byte("tial\n") }()
+	if line, err := c.readPlainLine(nil); line != "partial" || err != nil {
+		t.Errorf("readPlainLine() = %q, %v, want partial", line, err)
+	}
+}
+
+func TestHistory(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "dir", "history")
+	h, err := loadHistory(file)
+	if err != nil {
+		t.Fatalf("loadHistory() of a missing file error = %v", err)
+	}
+	for _, line := range []string{"first", "  ", "second", "second", "first"} {
+		h.add(line)
+	}
+	want := []string{"first", "second", "first"}
+	if !reflect.DeepEqual(h.lines, want) {
+		t.Errorf("history = %q, want %q", h.lines, want)
+	}
+	loaded, err := loadHistory(file)
+	if err != nil {
+		t.Fatalf("loadHistory() error = %v", err)
+	}
+	if !reflect.DeepEqual(loaded.lines, want) {
+		t.Errorf("loaded history = %q, want %q", loaded.lines, want)
+	}
+
+	// A history grown too long is trimmed to its latest lines.
+	var lines []string
+	for i := range maxHistory + 5 {
+		lines = append(lines, fmt.Sprint("line ", i))
+	}
+	if err := os.WriteFile(file, []byte(strings.Join(lines, "\n")+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if h, err = loadHistory(file); err != nil {
+		t.Fatalf("loadHistory() error = %v", err)
+	}
+	if !reflect.DeepEqual(h.lines, lines[5:]) {
+		t.Errorf("history has %d lines from %q, want %d from %q", len(h.lines), h.lines[0], maxHistory, lines[5])
+	}
+	if h, err = loadHistory(file); err != nil || len(h.lines) != maxHistory {
+		t.Errorf("history file not trimmed: %d lines, %v", len(h.lines), err)
+	}
+}
+
+func TestReadInputRemembers(t *testing.T) {
+	// Only the lines typed on a terminal are kept.
+	for _, terminal := range []bool{false, true} {
+		c, _ := newTestInput(terminal, nil, "one\\\ntwo\n\"\"\"\nthree\n\"\"\"\n")
+		for range 2 {
+			if _, err := c.readInput("> "); err != nil {
+				t.Fatalf("readInput() error = %v", err)
+			}
+		}
+		var want []string
+		if terminal {
+			want = []string{`one\`, "two", `"""`}
+		}
+		if !reflect.DeepEqual(c.history.lines, want) {
+			t.Errorf("terminal %v: history = %q, want %q", terminal, c.history.lines, want)
+		}
+	}
+}
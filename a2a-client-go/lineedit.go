@@ -0,0 +1,256 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// --- Line Editing ---
+//
+// Prompts on a terminal are read in raw mode, with the usual keys:
+//
+//	left, right, ctrl-b, ctrl-f    move by a character
+//	home, end, ctrl-a, ctrl-e      move to the start or end of the line
+//	up, down, ctrl-p, ctrl-n       recall the previous or next history line
+//	backspace, delete              delete a character
+//	ctrl-w, ctrl-u, ctrl-k         delete the previous word, or up to the
+//	                               start or end of the line
+//	ctrl-c                         drop the line
+//	ctrl-d                         end the input on an empty line
+//
+// A line wider than the terminal scrolls horizontally. Characters are
+// assumed to be one column wide.
+
+// lineEdit is the state of a line being edited.
+type lineEdit struct {
+	out    io.Writer
+	prompt string
+	width  int // of the terminal
+	buf    []rune
+	pos    int // of the cursor in buf
+	offset int // of the first rune shown
+
+	history []string
+	recall  int    // index in history of the line shown, len(history) for the new one
+	draft   []rune // the new line, kept while browsing the history
+}
+
+// editLine reads a line with line editing.
+func (c *consoleInput) editLine(prompt string) (string, error) {
+	restore, err := makeRaw(c.fd)
+	if err != nil {
+		fmt.Fprint(c.out, prompt)
+		return c.readPlainLine(nil)
+	}
+	defer restore()
+	return c.edit(prompt, terminalWidth(c.fd))
+}
+
+// edit reads a line from the keys typed on a terminal of the given width,
+// which must be in raw mode.
+func (c *consoleInput) edit(prompt string, width int) (string, error) {
+	e := &lineEdit{out: c.out, prompt: prompt, width: width, history: c.history.lines, recall: len(c.history.lines)}
+	e.refresh()
+	for {
+		b, err := c.readByte(nil)
+		if err != nil {
+			fmt.Fprint(e.out, "\r\n")
+			if errors.Is(err, io.EOF) && len(e.buf) > 0 {
+				return string(e.buf), nil
+			}
+			return "", err
+		}
+		switch b {
+		case '\r', '\n':
+			e.pos = len(e.buf)
+			e.refresh()
+			fmt.Fprint(e.out, "\r\n")
+			return string(e.buf), nil
+		case 1: // ctrl-a
+			e.move(-len(e.buf))
+		case 2: // ctrl-b
+			e.move(-1)
+		case 3: // ctrl-c
+			fmt.Fprint(e.out, "^C\r\n")
+			e.set(nil)
+		case 4: // ctrl-d
+			if len(e.buf) == 0 {
+				fmt.Fprint(e.out, "\r\n")
+				return "", io.EOF
+			}
+			e.delete(e.pos, e.pos+1)
+		case 5: // ctrl-e
+			e.move(len(e.buf))
+		case 6: // ctrl-f
+			e.move(1)
+		case 8, 127: // ctrl-h, backspace
+			e.delete(e.pos-1, e.pos)
+		case 11: // ctrl-k
+			e.delete(e.pos, len(e.buf))
+		case 14: // ctrl-n
+			e.browse(1)
+		case 16: // ctrl-p
+			e.browse(-1)
+		case 21: // ctrl-u
+			e.delete(0, e.pos)
+		case 23: // ctrl-w
+			start := e.pos
+			for start > 0 && e.buf[start-1] == ' ' {
+				start--
+			}
+			for start > 0 && e.buf[start-1] != ' ' {
+				start--
+			}
+			e.delete(start, e.pos)
+		case 27: // escape sequence
+			if err := c.escape(e); err != nil {
+				return "", err
+			}
+		default:
+			if b < ' ' {
+				continue
+			}
+			r, err := c.decodeRune(b)
+			if err != nil {
+				return "", err
+			}
+			e.insert(r)
+		}
+	}
+}
+
+// escape handles the escape sequence of a key: arrows, home, end, delete.
+func (c *consoleInput) escape(e *lineEdit) error {
+	b, err := c.readByte(nil)
+	if err != nil || b != '[' && b != 'O' {
+		return err
+	}
+	var param []byte
+	for {
+		if b, err = c.readByte(nil); err != nil {
+			return err
+		}
+		if b < '0' || b > '9' && b != ';' {
+			break
+		}
+		param = append(param, b)
+	}
+	switch {
+	case b == 'A':
+		e.browse(-1)
+	case b == 'B':
+		e.browse(1)
+	case b == 'C':
+		e.move(1)
+	case b == 'D':
+		e.move(-1)
+	case b == 'H', b == '~' && (string(param) == "1" || string(param) == "7"):
+		e.move(-len(e.buf))
+	case b == 'F', b == '~' && (string(param) == "4" || string(param) == "8"):
+		e.move(len(e.buf))
+	case b == '~' && string(param) == "3":
+		e.delete(e.pos, e.pos+1)
+	}
+	return nil
+}
+
+// decodeRune reads the rest of the UTF-8 encoding starting with b.
+func (c *consoleInput) decodeRune(b byte) (rune, error) {
+	enc := []byte{b}
+	for !utf8.FullRune(enc) {
+		next, err := c.readByte(nil)
+		if err != nil {
+			return 0, err
+		}
+		enc = append(enc, next)
+	}
+	r, _ := utf8.DecodeRune(enc)
+	return r, nil
+}
+
+func (e *lineEdit) insert(r rune) {
+	e.buf = append(e.buf[:e.pos], append([]rune{r}, e.buf[e.pos:]...)...)
+	e.pos++
+	e.refresh()
+}
+
+// delete deletes the runes from start to end, as far as they exist.
+func (e *lineEdit) delete(start, end int) {
+	start, end = max(start, 0), min(end, len(e.buf))
+	if start >= end {
+		return
+	}
+	e.buf = append(e.buf[:start], e.buf[end:]...)
+	e.pos = start
+	e.refresh()
+}
+
+func (e *lineEdit) move(n int) {
+	e.pos = min(max(e.pos+n, 0), len(e.buf))
+	e.refresh()
+}
+
+// browse shows the line n lines later in the history.
+func (e *lineEdit) browse(n int) {
+	recall := e.recall + n
+	if recall < 0 || recall > len(e.history) {
+		return
+	}
+	if e.recall == len(e.history) {
+		e.draft = e.buf
+	}
+	e.recall = recall
+	if recall == len(e.history) {
+		e.set(e.draft)
+		return
+	}
+	e.set([]rune(e.history[recall]))
+}
+
+// set replaces the line, with the cursor at its end.
+func (e *lineEdit) set(line []rune) {
+	e.buf = append([]rune(nil), line...)
+	e.pos = len(e.buf)
+	e.refresh()
+}
+
+// refresh redraws the prompt and the part of the line around the cursor.
+func (e *lineEdit) refresh() {
+	prompt := []rune(e.prompt)
+	room := max(e.width-len(prompt)-1, 1)
+	if e.pos < e.offset {
+		e.offset = e.pos
+	}
+	if e.pos > e.offset+room {
+		e.offset = e.pos - room
+	}
+	e.offset = min(e.offset, max(len(e.buf)-room, 0))
+	shown := e.buf[e.offset:min(e.offset+room, len(e.buf))]
+
+	var s strings.Builder
+	s.WriteString("\r")
+	s.WriteString(e.prompt)
+	s.WriteString(string(shown))
+	s.WriteString("\x1b[K\r")
+	if col := len(prompt) + e.pos - e.offset; col > 0 {
+		fmt.Fprintf(&s, "\x1b[%dC", col)
+	}
+	fmt.Fprint(e.out, s.String())
+}
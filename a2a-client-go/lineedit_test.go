@@ -0,0 +1,130 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestEditLine(t *testing.T) {
+	tests := []struct {
+		name    string
+		keys    string
+		want    string
+		wantErr error
+	}{
+		{name: "typed", keys: "hello\r", want: "hello"},
+		{name: "newline", keys: "hello\n", want: "hello"},
+		{name: "UTF-8", keys: "héllo ☃\r", want: "héllo ☃"},
+		{name: "left and insert", keys: "helo\x1b[Dl\r", want: "hello"},
+		{name: "right", keys: "hllo\x1b[D\x1b[D\x1b[D\x1b[D\x1b[Ce\r", want: "hello"},
+		{name: "ctrl-b and ctrl-f", keys: "hllo\x02\x02\x02\x02\x06e\r", want: "hello"},
+		{name: "ctrl-a and ctrl-e", keys: "ell\x01h\x05o\r", want: "hello"},
+		{name: "home and end", keys: "ell\x1b[Hh\x1b[Fo\r", want: "hello"},
+		{name: "home and end with tilde", keys: "ell\x1b[1~h\x1b[4~o\r", want: "hello"},
+		{name: "backspace", keys: "helpp\x7f\x7flo\r", want: "hello"},
+		{name: "backspace at start", keys: "hello\x01\x7f\r", want: "hello"},
+		{name: "delete", keys: "xhello\x01\x1b[3~\r", want: "hello"},
+		{name: "ctrl-w", keys: "hello big  world\x17\x17\r", want: "hello "},
+		{name: "ctrl-u", keys: "junk\x15hello\r", want: "hello"},
+		{name: "ctrl-k", keys: "hello junk\x02\x02\x02\x02\x02\x0b\r", want: "hello"},
+		{name: "ctrl-c drops the line", keys: "junk\x03hello\r", want: "hello"},
+		{name: "ctrl-d deletes", keys: "hellox\x02\x04\r", want: "hello"},
+		{name: "ctrl-d on an empty line", keys: "\x04", wantErr: io.EOF},
+		{name: "other control keys ignored", keys: "hel\x07\x0elo\r", want: "hello"},
+		{name: "unknown escape sequence", keys: "hel\x1b[5~lo\r", want: "hello"},
+		{name: "end of input", keys: "hello", want: "hello"},
+		{name: "end of input on an empty line", keys: "", wantErr: io.EOF},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Keys may arrive split anywhere.
+			var chunks []string
+			for _, b := range []byte(tt.keys) {
+				chunks = append(chunks, string([]byte{b}))
+			}
+			c, _ := newTestInput(true, nil, chunks...)
+			got, err := c.edit("> ", 80)
+			if !errors.Is(err, tt.wantErr) || got != tt.want {
+				t.Errorf("edit() = %q, %v, want %q, %v", got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestEditLineHistory(t *testing.T) {
+	history := []string{"first", "second"}
+	tests := []struct {
+		name string
+		keys string
+		want string
+	}{
+		{name: "up", keys: "\x1b[A\r", want: "second"},
+		{name: "up twice", keys: "\x1b[A\x1b[A\r", want: "first"},
+		{name: "past the oldest", keys: "\x1b[A\x1b[A\x1b[A\r", want: "first"},
+		{name: "up and down", keys: "\x1b[A\x1b[A\x1b[B\r", want: "second"},
+		{name: "back to the draft", keys: "draft\x1b[A\x1b[A\x1b[B\x1b[B\r", want: "draft"},
+		{name: "past the draft", keys: "draft\x1b[B\r", want: "draft"},
+		{name: "ctrl-p and ctrl-n", keys: "\x10\x10\x0e\r", want: "second"},
+		{name: "edited recall", keys: "\x1bOA!\r", want: "second!"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := newTestInput(true, history, tt.keys)
+			if got, err := c.edit("> ", 80); got != tt.want || err != nil {
+				t.Errorf("edit() = %q, %v, want %q", got, err, tt.want)
+			}
+		})
+	}
+	if history[1] != "second" {
+		t.Errorf("history changed to %q", history)
+	}
+}
+
+func TestEditLineRefresh(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		pos  int
+		want string
+	}{
+		{name: "short line", line: "hello", pos: 5, want: "\r> hello\x1b[K\r\x1b[7C"},
+		{name: "cursor inside", line: "hello", pos: 1, want: "\r> hello\x1b[K\r\x1b[3C"},
+		{name: "scrolled to the cursor", line: "abcdefghijkl", pos: 12, want: "\r> fghijkl\x1b[K\r\x1b[9C"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := &strings.Builder{}
+			e := &lineEdit{out: out, prompt: "> ", width: 10, buf: []rune(tt.line), pos: tt.pos}
+			e.refresh()
+			if out.String() != tt.want {
+				t.Errorf("refresh() wrote %q, want %q", out.String(), tt.want)
+			}
+		})
+	}
+
+	// Moving back to the start scrolls back.
+	out := &strings.Builder{}
+	e := &lineEdit{out: out, prompt: "> ", width: 10, buf: []rune("abcdefghijkl"), pos: 12}
+	e.refresh()
+	out.Reset()
+	e.move(-12)
+	if want := "\r> abcdefg\x1b[K\r\x1b[2C"; out.String() != want {
+		t.Errorf("move() wrote %q, want %q", out.String(), want)
+	}
+}
@@ -16,42 +16,86 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
 	"log"
-	"math/rand"
+	"net/http"
+	"os"
+	"strings"
 
 	"google.golang.org/adk/agent"
 	"google.golang.org/adk/agent/llmagent"
-	"google.golang.org/adk/agent/remoteagent"
 	"google.golang.org/adk/artifact"
 	"google.golang.org/adk/model/gemini"
-	"google.golang.org/adk/runner"
 	"google.golang.org/adk/session"
 	"google.golang.org/adk/tool"
 	"google.golang.org/adk/tool/functiontool"
 
 	"google.golang.org/genai"
+
+	"a2a-common-go/calc"
+	"a2a-common-go/dice"
+	"a2a-common-go/flagutil"
+	"a2a-common-go/mcptools"
+	"a2a-common-go/openapi"
+	"a2a-common-go/remote"
+	"a2a-common-go/shell"
+	"a2a-common-go/toolkit"
 )
 
 // --- Local Roll Agent ---
 
-type rollDieToolArgs struct {
-	Sides int `json:"sides" jsonschema:"The number of sides on the die."`
-}
-
-func rollDieTool(tc tool.Context, args rollDieToolArgs) int {
-	return rand.Intn(args.Sides) + 1
-}
-
-func newRollAgent(ctx context.Context) (agent.Agent, error) {
-	rollTool, err := functiontool.New(functiontool.Config{
+func newRollAgent(ctx context.Context, roller *dice.Roller, limiter *toolkit.Limiter, apiTools []tool.Tool) (agent.Agent, error) {
+	rollTool, err := toolkit.NewValidatedTool(functiontool.Config{
 		Name:        "roll_die",
 		Description: "Roll a die and return the rolled result.",
-	}, rollDieTool)
+	}, roller.RollDieTool, toolkit.WithApproval(toolkit.ApprovalRequired("roll_die")))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create roll_die tool: %w", err)
 	}
 
+	diceTool, err := toolkit.NewValidatedTool(functiontool.Config{
+		Name:        "roll_dice",
+		Description: "Roll dice written in standard dice notation and return every roll, the kept and dropped dice and the total.",
+	}, roller.RollDiceTool, toolkit.WithApproval(toolkit.ApprovalRequired("roll_dice")))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create roll_dice tool: %w", err)
+	}
+
+	calcTool, err := calc.NewTool()
+	if err != nil {
+		return nil, err
+	}
+
+	calls, err := toolkit.ParallelCallsFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	// Seeded rolls must run in order to be replayable; calculations have no
+	// side effects and API tools tell whether they do.
+	tools := []tool.Tool{rollTool, diceTool, calcTool}
+	concurrent := []bool{roller.Concurrent(), roller.Concurrent(), true}
+	for _, t := range apiTools {
+		tools = append(tools, t)
+		// Tools of another kind may have side effects, so they run alone.
+		api, ok := t.(*openapi.Tool)
+		concurrent = append(concurrent, ok && api.ReadOnly())
+	}
+	for i, t := range tools {
+		if tools[i], err = limiter.Limit(t); err != nil {
+			return nil, err
+		}
+		if err := calls.Add(tools[i], concurrent[i]); err != nil {
+			return nil, err
+		}
+	}
+	// run_in_parallel is not limited: the calls it runs are.
+	parallelTool, err := calls.Tool()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create run_in_parallel tool: %w", err)
+	}
+	tools = append(tools, parallelTool)
+
 	model, err := gemini.NewModel(ctx, "gemini-2.5-flash", &genai.ClientConfig{})
 	if err != nil {
 		return nil, fmt.Errorf("failed to create model for roll agent: %w", err)
@@ -59,34 +103,26 @@ func newRollAgent(ctx context.Context) (agent.Agent, error) {
 
 	return llmagent.New(llmagent.Config{
 		Name:        "roll_agent",
-		Description: "Handles rolling dice of different sizes.",
-		Instruction: "You are responsible for rolling dice based on the user's request. When asked to roll a die, you must call the roll_die tool with the number of sides as an integer.",
-		Model:       model,
-		Tools:       []tool.Tool{rollTool},
-	})
-}
-
-// --- Remote Prime Agent ---
-
-// --8<-- [start:new-prime-agent]
-func newPrimeAgent() (agent.Agent, error) {
-	remoteAgent, err := remoteagent.New(remoteagent.A2AConfig{
-		Name:            "prime_agent",
-		Description:     "Agent that handles checking if numbers are prime.",
-		AgentCardSource: "http://localhost:8086",
+		Description: "Handles rolling dice of different sizes, including standard dice notation like 3d6+2.",
+		Instruction: `
+      You are responsible for rolling dice based on the user's request.
+      When asked to roll a single die, you must call the roll_die tool with the number of sides as an integer.
+      When the request uses dice notation or needs several dice, modifiers, dropped or kept dice, or exploding dice
+      (for example "3d6+2", "4d6 drop lowest", "2d20 keep highest" or "3d6!"), call the roll_dice tool with the expression.
+      Report the individual rolls, any dropped dice and the total.
+      Use the calculate tool for any arithmetic on the rolls.
+      To make several independent rolls or calculations at once, call run_in_parallel with all of them.
+    `,
+		Model:                model,
+		Tools:                tools,
+		BeforeModelCallbacks: []llmagent.BeforeModelCallback{toolkit.ResumeApprovedCalls},
 	})
-	if err != nil {
-		return nil, fmt.Errorf("failed to create remote prime agent: %w", err)
-	}
-	return remoteAgent, nil
 }
 
-// --8<-- [end:new-prime-agent]
-
 // --- Root Agent ---
 
 // --8<-- [start:new-root-agent]
-func newRootAgent(ctx context.Context, rollAgent, primeAgent agent.Agent) (agent.Agent, error) {
+func newRootAgent(ctx context.Context, rollAgent agent.Agent, remoteAgents []agent.Agent, health *remote.Health, router *remote.SkillRouter, tools []tool.Tool, toolsets []tool.Toolset) (agent.Agent, error) {
 	model, err := gemini.NewModel(ctx, "gemini-2.5-flash", &genai.ClientConfig{})
 	if err != nil {
 		return nil, err
@@ -94,85 +130,222 @@ func newRootAgent(ctx context.Context, rollAgent, primeAgent agent.Agent) (agent
 	return llmagent.New(llmagent.Config{
 		Name:  "root_agent",
 		Model: model,
-		Instruction: `
-      You are a helpful assistant that can roll dice and check if numbers are prime.
-      You delegate rolling dice tasks to the roll_agent and prime checking tasks to the prime_agent.
-      Follow these steps:
-      1. If the user asks to roll a die, delegate to the roll_agent.
-      2. If the user asks to check primes, delegate to the prime_agent.
-      3. If the user asks to roll a die and then check if the result is prime, call roll_agent first, then pass the result to prime_agent.
-      Always clarify the results before proceeding.
-    `,
-		SubAgents: []agent.Agent{rollAgent, primeAgent},
-		Tools:     []tool.Tool{},
+		InstructionProvider: func(agent.ReadonlyContext) (string, error) {
+			return rootInstruction + router.Delegation(), nil
+		},
+		SubAgents:            append([]agent.Agent{rollAgent}, remoteAgents...),
+		Tools:                tools,
+		Toolsets:             toolsets,
+		BeforeModelCallbacks: []llmagent.BeforeModelCallback{toolkit.ResumeApprovedCalls, health.InstructOffline, router.Route},
 	})
 }
 
+// rootInstruction is followed by the delegation instructions generated by the
+// skill router.
+const rootInstruction = `You are a helpful assistant that can roll dice, check if numbers are prime and delegate other requests to the agents below.
+Always clarify the results before proceeding.
+Use the calculate tool for arithmetic, e.g. to sum rolls before checking the total, instead of computing it yourself.
+For other requests, use your tools if one of them can help.
+`
+
+// rollAgentSkills describe roll_agent to the skill router.
+var rollAgentSkills = []remote.RouteSkill{{
+	Name:        "roll_dice",
+	Description: "Rolls dice of any size, including dice notation like 3d6+2.",
+	Tags:        []string{"roll", "dice", "die"},
+	Examples:    []string{"Roll a 6-sided die.", "Roll 3d6+2."},
+}}
+
 // --8<-- [end:new-root-agent]
 
 // --- Main Function ---
 
+// consoleStreamingVar registers --streaming on fs. Unlike the servers, the
+// console streams by default: the mode defaults to $STREAMING_MODE, else sse.
+func consoleStreamingVar(fs *flag.FlagSet) (*agent.StreamingMode, error) {
+	mode := agent.StreamingModeSSE
+	if v := os.Getenv(flagutil.StreamingModeEnv); v != "" {
+		m, err := flagutil.ParseStreamingMode(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", flagutil.StreamingModeEnv, err)
+		}
+		mode = m
+	}
+	fs.Func("streaming", "Whether answers are printed as they are generated: none or sse. Defaults to $"+flagutil.StreamingModeEnv+", else sse.", func(s string) error {
+		m, err := flagutil.ParseStreamingMode(s)
+		if err != nil {
+			return err
+		}
+		mode = m
+		return nil
+	})
+	return &mode, nil
+}
+
 func main() {
 	ctx := context.Background()
 
-	primeAgent, err := newPrimeAgent()
+	fs := flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+	remoteCfg, err := remote.NewConfig(fs)
+	if err != nil {
+		log.Fatalf("Invalid remote agents configuration: %v", err)
+	}
+	statusAddr := fs.String("status_addr", "", "Address to serve the status of the remote agents on at /status, e.g. localhost:8093. Disabled if empty.")
+	preRoute, err := remote.SkillRouterFlag(fs)
+	if err != nil {
+		log.Fatalf("Invalid skill router configuration: %v", err)
+	}
+	batch := batchFlags(fs)
+	historyFile := fs.String("history_file", defaultHistoryFile(), "File keeping the lines typed on the console, none if empty. Defaults to $"+historyFileEnv+", else ~/.a2a_client_history.")
+	streaming, err := consoleStreamingVar(fs)
 	if err != nil {
-		log.Fatalf("Failed to create prime agent: %v", err)
+		log.Fatalf("Invalid streaming configuration: %v", err)
+	}
+	if err := flagutil.Parse(fs, os.Args[1:]); err != nil {
+		log.Fatalf("Invalid arguments: %v", err)
 	}
 
-	rollAgent, err := newRollAgent(ctx)
+	remoteSpecs, err := remoteCfg.Agents()
 	if err != nil {
-		log.Fatalf("Failed to create roll agent: %v", err)
+		log.Fatalf("Invalid remote agents configuration: %v", err)
+	}
+	transport, err := remoteCfg.TLS.Transport()
+	if err != nil {
+		log.Fatalf("Invalid remote TLS configuration: %v", err)
+	}
+	remote.UseTransport(transport)
+	registry, err := remoteCfg.Registry(remoteSpecs)
+	if err != nil {
+		log.Fatalf("Invalid agent registry configuration: %v", err)
+	}
+	if remoteSpecs, err = registry.Load(ctx); err != nil {
+		log.Fatalf("Failed to load agent registry: %v", err)
+	}
+	health := remote.NewHealth(remoteSpecs, remoteCfg.Breaker)
+	go health.Watch(ctx, remoteCfg.HealthInterval)
+	if *statusAddr != "" {
+		mux := http.NewServeMux()
+		mux.Handle("/status", health)
+		go func() {
+			log.Printf("Serving remote agent status on http://%s/status", *statusAddr)
+			if err := http.ListenAndServe(*statusAddr, mux); err != nil {
+				log.Printf("Status server failed: %v", err)
+			}
+		}()
 	}
 
-	rootAgent, err := newRootAgent(ctx, rollAgent, primeAgent)
+	roller, err := dice.RollerFromEnv()
 	if err != nil {
-		log.Fatalf("Failed to create root agent: %v", err)
+		log.Fatalf("Failed to create die roller: %v", err)
 	}
+	log.Printf("Rolling dice with %s randomness", roller.Source())
 
-	sessionService := session.InMemoryService()
-	artifactService := artifact.InMemoryService()
+	limiter, err := toolkit.LimiterFromEnv()
+	if err != nil {
+		log.Fatalf("Failed to create tool limiter: %v", err)
+	}
 
-	_, err = sessionService.Create(ctx, &session.CreateRequest{
-		AppName:   rootAgent.Name(),
-		UserID:    "user-123",
-		SessionID: "session-abc",
-	})
+	openAPI, err := openapi.ConfigFromEnv()
+	if err != nil {
+		log.Fatalf("Failed to load OpenAPI config: %v", err)
+	}
+	apiTools, err := openAPI.ToolsFor("roll_agent")
 	if err != nil {
-		log.Fatalf("Failed to create session: %v", err)
+		log.Fatalf("Failed to create OpenAPI tools: %v", err)
 	}
 
-	runnerConfig := runner.Config{
-		AppName:         rootAgent.Name(),
-		Agent:           rootAgent,
-		SessionService:  sessionService,
-		ArtifactService: artifactService,
+	rollAgent, err := newRollAgent(ctx, roller, limiter, apiTools)
+	if err != nil {
+		log.Fatalf("Failed to create roll agent: %v", err)
+	}
+
+	mcp, err := mcptools.ConfigFromEnv()
+	if err != nil {
+		log.Fatalf("Failed to load MCP config: %v", err)
+	}
+	defer mcp.Close()
+	rootToolsets, err := mcp.ToolsetsFor("root_agent")
+	if err != nil {
+		log.Fatalf("Failed to configure MCP servers: %v", err)
+	}
+	for i, ts := range rootToolsets {
+		rootToolsets[i] = limiter.LimitToolset(ts)
 	}
-	runner, err := runner.New(runnerConfig)
+	calcTool, err := calc.NewTool()
 	if err != nil {
-		log.Fatalf("Failed to create runner: %v", err)
+		log.Fatalf("Failed to create calculator: %v", err)
 	}
+	rootTools := []tool.Tool{calcTool}
 
-	userInput := "Roll a 6-sided die and check if it's prime."
-	fmt.Printf("User: %s\n", userInput)
+	commands, err := shell.CommandRunnerFromEnv()
+	if err != nil {
+		log.Fatalf("Failed to configure run_command tool: %v", err)
+	}
+	if commands != nil {
+		runTool, err := commands.NewTool()
+		if err != nil {
+			log.Fatalf("Failed to create run_command tool: %v", err)
+		}
+		log.Printf("Commands run in %s", commands.Dir)
+		rootTools = append(rootTools, runTool)
+	}
+	for i, t := range rootTools {
+		if rootTools[i], err = limiter.Limit(t); err != nil {
+			log.Fatalf("Failed to limit tool: %v", err)
+		}
+	}
 
-	inputContent := genai.NewContentFromText(userInput, genai.RoleUser)
-	for event, err := range runner.Run(ctx, "user-123", "session-abc", inputContent, agent.RunConfig{
-		StreamingMode: agent.StreamingModeNone,
-	}) {
+	router := remote.NewSkillRouter([]remote.RouteTarget{{Name: rollAgent.Name(), Description: rollAgent.Description(), Skills: rollAgentSkills}}, health, *preRoute)
+	root := remote.NewLiveRoot(health, func(remoteAgents []agent.Agent) (agent.Agent, error) {
+		return newRootAgent(ctx, rollAgent, remoteAgents, health, router, rootTools, rootToolsets)
+	})
+	if err := root.Update(ctx, remoteSpecs); err != nil {
+		log.Fatalf("Failed to create root agent: %v", err)
+	}
+	go registry.Watch(ctx, remoteCfg.RegistryInterval, remoteSpecs, func(specs []remote.AgentSpec) {
+		if err := root.Update(ctx, specs); err != nil {
+			log.Printf("Failed to update remote agents, keeping the previous ones: %v", err)
+		}
+	})
+	if batch.file != "" {
+		ok, err := runBatch(ctx, batch, root.Current, session.InMemoryService(), artifact.InMemoryService())
 		if err != nil {
-			log.Printf("Agent run error: %v", err)
-			continue
+			log.Fatalf("Batch failed: %v", err)
 		}
-		if event.Content != nil {
-			for _, part := range event.Content.Parts {
-				if part.Text != "" {
-					fmt.Printf("Bot: %s\n", part.Text)
-				}
-				if part.FunctionCall != nil {
-					fmt.Printf("Bot calls tool: %s with args: %v\n", part.FunctionCall.Name, part.FunctionCall.Args)
-				}
-			}
+		if !ok {
+			mcp.Close()
+			os.Exit(1)
 		}
+		return
+	}
+
+	in, err := newConsoleInput(os.Stdin, os.Stdout, *historyFile)
+	if err != nil {
+		log.Fatalf("Failed to open console: %v", err)
+	}
+	c := newConsole(root.Current, session.InMemoryService(), artifact.InMemoryService(), *streaming, in, os.Stdout)
+	if err := c.run(ctx); err != nil {
+		log.Fatalf("Console failed: %v", err)
+	}
+}
+
+// askApproval asks the user on the console whether the call may run and
+// returns the decision as the function response expected by the agent.
+func askApproval(in *consoleInput, call *genai.FunctionCall) *genai.Part {
+	answer, err := in.readLine(fmt.Sprintf("Approve %s with args %v? [y/N] ", call.Name, call.Args))
+	if err != nil {
+		log.Printf("Failed to read approval, denying %s: %v", call.Name, err)
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	approved := answer == "y" || answer == "yes"
+
+	response := map[string]any{"approved": approved}
+	if !approved {
+		response["reason"] = "denied by the user"
 	}
+	return &genai.Part{FunctionResponse: &genai.FunctionResponse{
+		ID:       call.ID,
+		Name:     call.Name,
+		Response: response,
+	}}
 }
@@ -0,0 +1,43 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import "golang.org/x/term"
+
+// isTerminal reports whether fd is a terminal, whose lines can be edited.
+func isTerminal(fd int) bool {
+	return term.IsTerminal(fd)
+}
+
+// makeRaw puts the terminal in raw mode, in which keys are read as they are
+// typed, without echo, and returns the function restoring its mode. Output
+// is not processed in raw mode, so line editing ends its lines with "\r\n".
+func makeRaw(fd int) (restore func() error, err error) {
+	old, err := term.MakeRaw(fd)
+	if err != nil {
+		return nil, err
+	}
+	return func() error { return term.Restore(fd, old) }, nil
+}
+
+// terminalWidth returns the number of columns of the terminal, 80 if
+// unknown.
+func terminalWidth(fd int) int {
+	width, _, err := term.GetSize(fd)
+	if err != nil || width == 0 {
+		return 80
+	}
+	return width
+}
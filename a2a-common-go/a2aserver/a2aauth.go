@@ -0,0 +1,367 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package a2aserver
+
+import (
+	"bufio"
+	"bytes"
+	"crypto"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	a2acore "github.com/a2aproject/a2a-go/a2a"
+)
+
+// --- A2A Authentication ---
+//
+// By default the server accepts any caller. Callers are authenticated when
+// one of these is set:
+//
+//	--api_keys_file  A2A_API_KEYS_FILE  file of API keys, one per line
+//	--jwks_file      A2A_JWKS_FILE      JSON Web Key Set verifying bearer JWTs
+//	--jwt_issuer     A2A_JWT_ISSUER     required iss of the JWTs, any if empty
+//	--jwt_audience   A2A_JWT_AUDIENCE   required aud of the JWTs, the public URL if empty
+//
+// API keys are sent in the X-API-Key header, JWTs as bearer tokens in the
+// Authorization header, signed with RS256 or ES256 by one of the keys of the
+// set and not expired. Either one is enough. The schemes are declared in the
+// agent card, which stays public so that clients can find out how to
+// authenticate; only A2A calls need credentials.
+
+const (
+	apiKeysFileEnv = "A2A_API_KEYS_FILE"
+	jwksFileEnv    = "A2A_JWKS_FILE"
+	jwtIssuerEnv   = "A2A_JWT_ISSUER"
+	jwtAudienceEnv = "A2A_JWT_AUDIENCE"
+
+	apiKeyHeader = "X-API-Key"
+
+	apiKeyScheme a2acore.SecuritySchemeName = "apiKey"
+	bearerScheme a2acore.SecuritySchemeName = "bearer"
+
+	// jwtLeeway allows for clock skew when checking exp and nbf.
+	jwtLeeway = time.Minute
+)
+
+// AuthConfig is how callers of the A2A server are authenticated.
+type AuthConfig struct {
+	APIKeysFile string
+	JWKSFile    string
+	issuer      string
+	audience    string
+}
+
+// Authenticator checks the credentials of A2A calls.
+type Authenticator struct {
+	apiKeys  [][sha256.Size]byte
+	jwks     map[string]crypto.PublicKey // by key ID
+	issuer   string
+	audience string
+	now      func() time.Time
+}
+
+// NewAuthenticator loads the API keys and the JWKS, defaulting the audience
+// to audience. It returns nil if authentication is not configured.
+func NewAuthenticator(cfg AuthConfig, audience string) (*Authenticator, error) {
+	if cfg.APIKeysFile == "" && cfg.JWKSFile == "" {
+		if cfg.issuer != "" || cfg.audience != "" {
+			return nil, errors.New("--jwt_issuer and --jwt_audience need --jwks_file")
+		}
+		return nil, nil
+	}
+	a := &Authenticator{issuer: cfg.issuer, audience: cfg.audience, now: time.Now}
+	if a.audience == "" {
+		a.audience = audience
+	}
+	if cfg.APIKeysFile != "" {
+		keys, err := loadAPIKeys(cfg.APIKeysFile)
+		if err != nil {
+			return nil, err
+		}
+		for _, k := range keys {
+			a.apiKeys = append(a.apiKeys, sha256.Sum256([]byte(k)))
+		}
+	}
+	if cfg.JWKSFile != "" {
+		jwks, err := loadJWKS(cfg.JWKSFile)
+		if err != nil {
+			return nil, err
+		}
+		a.jwks = jwks
+	}
+	return a, nil
+}
+
+// loadAPIKeys reads a file of API keys, one per line. Empty lines and lines
+// starting with # are ignored.
+func loadAPIKeys(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read API keys: %w", err)
+	}
+	var keys []string
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		keys = append(keys, line)
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("invalid API keys file %s: no keys", path)
+	}
+	return keys, nil
+}
+
+// jsonWebKey is a public key of a JSON Web Key Set (RFC 7517).
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	// RSA
+	N string `json:"n"`
+	E string `json:"e"`
+	// EC
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// loadJWKS reads the RSA and P-256 signing keys of a JSON Web Key Set.
+func loadJWKS(path string) (map[string]crypto.PublicKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read JWKS: %w", err)
+	}
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("failed to parse JWKS %s: %w", path, err)
+	}
+	keys := make(map[string]crypto.PublicKey)
+	for i, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		key, err := k.publicKey()
+		if err != nil {
+			return nil, fmt.Errorf("invalid JWKS %s: key %d: %w", path, i, err)
+		}
+		if _, ok := keys[k.Kid]; ok {
+			return nil, fmt.Errorf("invalid JWKS %s: duplicate kid %q", path, k.Kid)
+		}
+		keys[k.Kid] = key
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("invalid JWKS %s: no signing keys", path)
+	}
+	return keys, nil
+}
+
+func (k jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		if k.Alg != "" && k.Alg != "RS256" {
+			return nil, fmt.Errorf("unsupported alg %q for an RSA key", k.Alg)
+		}
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil || len(n) < 256 {
+			return nil, errors.New("n must be a base64url modulus of at least 2048 bits")
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil || len(e) == 0 || len(e) > 4 {
+			return nil, errors.New("invalid exponent e")
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "EC":
+		if k.Crv != "P-256" || (k.Alg != "" && k.Alg != "ES256") {
+			return nil, fmt.Errorf("unsupported curve %q or alg %q for an EC key: must be P-256 and ES256", k.Crv, k.Alg)
+		}
+		x, errX := base64.RawURLEncoding.DecodeString(k.X)
+		y, errY := base64.RawURLEncoding.DecodeString(k.Y)
+		if errX != nil || errY != nil || len(x) != 32 || len(y) != 32 {
+			return nil, errors.New("x and y must be base64url 32-byte coordinates")
+		}
+		// Reject points which are not on the curve.
+		if _, err := ecdh.P256().NewPublicKey(append(append([]byte{4}, x...), y...)); err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+	default:
+		return nil, fmt.Errorf("unsupported kty %q: must be RSA or EC", k.Kty)
+	}
+}
+
+// CardOption declares the accepted credentials in the agent card.
+func (a *Authenticator) CardOption() CardOption {
+	return func(c *a2acore.AgentCard) {
+		c.SecuritySchemes = a2acore.NamedSecuritySchemes{}
+		c.Security = nil
+		if len(a.apiKeys) > 0 {
+			c.SecuritySchemes[apiKeyScheme] = a2acore.APIKeySecurityScheme{
+				Description: "API key issued by the operator of the agent.",
+				In:          a2acore.APIKeySecuritySchemeInHeader,
+				Name:        apiKeyHeader,
+			}
+			c.Security = append(c.Security, a2acore.SecurityRequirements{apiKeyScheme: {}})
+		}
+		if len(a.jwks) > 0 {
+			c.SecuritySchemes[bearerScheme] = a2acore.HTTPAuthSecurityScheme{
+				Description:  fmt.Sprintf("JWT signed with RS256 or ES256 for the audience %s.", a.audience),
+				Scheme:       "Bearer",
+				BearerFormat: "JWT",
+			}
+			c.Security = append(c.Security, a2acore.SecurityRequirements{bearerScheme: {}})
+		}
+	}
+}
+
+// Wrap refuses the requests to next without valid credentials.
+func (a *Authenticator) Wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := a.authenticate(r); err != nil {
+			log.Printf("Refused %s %s from %s: %v", r.Method, r.RequestURI, r.RemoteAddr, err)
+			if len(a.jwks) > 0 {
+				w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+			}
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// authenticate checks the API key or bearer token of the request.
+func (a *Authenticator) authenticate(r *http.Request) error {
+	if key := r.Header.Get(apiKeyHeader); key != "" && len(a.apiKeys) > 0 {
+		sum := sha256.Sum256([]byte(key))
+		valid := 0
+		for _, k := range a.apiKeys {
+			valid |= subtle.ConstantTimeCompare(sum[:], k[:])
+		}
+		if valid == 1 {
+			return nil
+		}
+		return errors.New("unknown API key")
+	}
+	if scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " "); ok && strings.EqualFold(scheme, "bearer") && len(a.jwks) > 0 {
+		return a.verifyJWT(strings.TrimSpace(token))
+	}
+	return errors.New("no credentials")
+}
+
+// verifyJWT checks the signature and the claims of a compact JWT.
+func (a *Authenticator) verifyJWT(token string) error {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return errors.New("malformed JWT")
+	}
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeJWTPart(parts[0], &header); err != nil {
+		return fmt.Errorf("malformed JWT header: %w", err)
+	}
+	key, ok := a.jwks[header.Kid]
+	if !ok {
+		return fmt.Errorf("unknown JWT key %q", header.Kid)
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return errors.New("malformed JWT signature")
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	switch key := key.(type) {
+	case *rsa.PublicKey:
+		if header.Alg != "RS256" {
+			return fmt.Errorf("JWT alg %q does not match the RSA key %q", header.Alg, header.Kid)
+		}
+		if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], sig); err != nil {
+			return errors.New("invalid JWT signature")
+		}
+	case *ecdsa.PublicKey:
+		if header.Alg != "ES256" {
+			return fmt.Errorf("JWT alg %q does not match the EC key %q", header.Alg, header.Kid)
+		}
+		if len(sig) != 64 || !ecdsa.Verify(key, digest[:], new(big.Int).SetBytes(sig[:32]), new(big.Int).SetBytes(sig[32:])) {
+			return errors.New("invalid JWT signature")
+		}
+	}
+
+	var claims struct {
+		Iss string          `json:"iss"`
+		Aud json.RawMessage `json:"aud"`
+		Exp *float64        `json:"exp"`
+		Nbf *float64        `json:"nbf"`
+	}
+	if err := decodeJWTPart(parts[1], &claims); err != nil {
+		return fmt.Errorf("malformed JWT claims: %w", err)
+	}
+	now := a.now()
+	if claims.Exp == nil {
+		return errors.New("JWT without exp")
+	}
+	if now.Add(-jwtLeeway).After(time.Unix(int64(*claims.Exp), 0)) {
+		return errors.New("JWT expired")
+	}
+	if claims.Nbf != nil && now.Add(jwtLeeway).Before(time.Unix(int64(*claims.Nbf), 0)) {
+		return errors.New("JWT not valid yet")
+	}
+	if a.issuer != "" && claims.Iss != a.issuer {
+		return fmt.Errorf("JWT issuer %q is not %q", claims.Iss, a.issuer)
+	}
+	// aud is a string or an array of strings.
+	var audiences []string
+	if err := json.Unmarshal(claims.Aud, &audiences); err != nil {
+		var aud string
+		if err := json.Unmarshal(claims.Aud, &aud); err != nil {
+			return errors.New("JWT without aud")
+		}
+		audiences = []string{aud}
+	}
+	for _, aud := range audiences {
+		if aud == a.audience {
+			return nil
+		}
+	}
+	return fmt.Errorf("JWT audience %v does not include %q", audiences, a.audience)
+}
+
+// decodeJWTPart decodes a base64url JSON part of a JWT into v.
+func decodeJWTPart(part string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(part)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
@@ -0,0 +1,156 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package a2aserver
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	a2acore "github.com/a2aproject/a2a-go/a2a"
+
+	"a2a-common-go/internal/testutil"
+)
+
+func TestAuthenticator(t *testing.T) {
+	keys := testutil.NewKeys(t)
+	apiKeys := filepath.Join(t.TempDir(), "keys")
+	if err := os.WriteFile(apiKeys, []byte("# master\nsecret-1\n\nsecret-2\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	const audience = "http://localhost:8086"
+	a, err := NewAuthenticator(AuthConfig{APIKeysFile: apiKeys, JWKSFile: keys.JWKSFile, issuer: "https://issuer.example.com"}, audience)
+	if err != nil {
+		t.Fatalf("newAuthenticator() error = %v", err)
+	}
+	now := time.Now()
+	claims := func(changes map[string]any) map[string]any {
+		c := map[string]any{"iss": "https://issuer.example.com", "aud": audience, "exp": now.Add(time.Hour).Unix(), "sub": "master"}
+		for k, v := range changes {
+			if v == nil {
+				delete(c, k)
+			} else {
+				c[k] = v
+			}
+		}
+		return c
+	}
+	valid := keys.Sign(t, "RS256", "rsa-1", claims(nil))
+	tampered := valid[:strings.LastIndex(valid, ".")] + "." + base64.RawURLEncoding.EncodeToString(make([]byte, 256))
+
+	tests := []struct {
+		name    string
+		header  string
+		value   string
+		wantErr string
+	}{
+		{name: "API key", header: apiKeyHeader, value: "secret-2"},
+		{name: "RS256 JWT", header: "Authorization", value: "Bearer " + valid},
+		{name: "ES256 JWT", header: "Authorization", value: "Bearer " + keys.Sign(t, "ES256", "ec-1", claims(nil))},
+		{name: "audience list", header: "Authorization", value: "bearer " + keys.Sign(t, "ES256", "ec-1", claims(map[string]any{"aud": []string{"other", audience}}))},
+		{name: "no credentials", wantErr: "no credentials"},
+		{name: "wrong API key", header: apiKeyHeader, value: "secret-3", wantErr: "unknown API key"},
+		{name: "commented API key", header: apiKeyHeader, value: "# master", wantErr: "unknown API key"},
+		{name: "tampered JWT", header: "Authorization", value: "Bearer " + tampered, wantErr: "invalid JWT signature"},
+		{name: "unknown key", header: "Authorization", value: "Bearer " + keys.Sign(t, "RS256", "rsa-2", claims(nil)), wantErr: `unknown JWT key "rsa-2"`},
+		{name: "encryption key", header: "Authorization", value: "Bearer " + keys.Sign(t, "RS256", "enc-1", claims(nil)), wantErr: `unknown JWT key "enc-1"`},
+		{name: "algorithm mismatch", header: "Authorization", value: "Bearer " + keys.Sign(t, "ES256", "rsa-1", claims(nil)), wantErr: `JWT alg "ES256" does not match`},
+		{name: "expired", header: "Authorization", value: "Bearer " + keys.Sign(t, "RS256", "rsa-1", claims(map[string]any{"exp": now.Add(-time.Hour).Unix()})), wantErr: "JWT expired"},
+		{name: "no expiry", header: "Authorization", value: "Bearer " + keys.Sign(t, "RS256", "rsa-1", claims(map[string]any{"exp": nil})), wantErr: "JWT without exp"},
+		{name: "not yet valid", header: "Authorization", value: "Bearer " + keys.Sign(t, "RS256", "rsa-1", claims(map[string]any{"nbf": now.Add(time.Hour).Unix()})), wantErr: "JWT not valid yet"},
+		{name: "wrong issuer", header: "Authorization", value: "Bearer " + keys.Sign(t, "RS256", "rsa-1", claims(map[string]any{"iss": "https://evil.example.com"})), wantErr: "JWT issuer"},
+		{name: "wrong audience", header: "Authorization", value: "Bearer " + keys.Sign(t, "RS256", "rsa-1", claims(map[string]any{"aud": "http://other"})), wantErr: "JWT audience"},
+		{name: "malformed", header: "Authorization", value: "Bearer abc.def", wantErr: "malformed JWT"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, InvokePath, nil)
+			if tt.header != "" {
+				r.Header.Set(tt.header, tt.value)
+			}
+			err := a.authenticate(r)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("authenticate() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("authenticate() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+
+	// Refused requests do not reach the handler.
+	reached := false
+	h := a.Wrap(http.HandlerFunc(func(http.ResponseWriter, *http.Request) { reached = true }))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, InvokePath, nil))
+	if rec.Code != http.StatusUnauthorized || reached || rec.Header().Get("WWW-Authenticate") == "" {
+		t.Errorf("unauthenticated request: status %d, reached %v, want 401 with WWW-Authenticate", rec.Code, reached)
+	}
+
+	// Both schemes are declared in the card, as alternatives.
+	card := validTestCard()
+	a.CardOption()(card)
+	if _, ok := card.SecuritySchemes[apiKeyScheme].(a2acore.APIKeySecurityScheme); !ok || len(card.Security) != 2 {
+		t.Errorf("card security = %+v, %+v, want the API key and bearer schemes", card.SecuritySchemes, card.Security)
+	}
+	if err := validateAgentCard(card); err != nil {
+		t.Errorf("validateAgentCard() of the card with the schemes error = %v", err)
+	}
+}
+
+func TestNewAuthenticatorErrors(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	if a, err := NewAuthenticator(AuthConfig{}, "http://localhost"); a != nil || err != nil {
+		t.Errorf("newAuthenticator() without config = %v, %v, want nil", a, err)
+	}
+	tests := []struct {
+		name    string
+		cfg     AuthConfig
+		wantErr string
+	}{
+		{name: "issuer without JWKS", cfg: AuthConfig{issuer: "https://issuer"}, wantErr: "need --jwks_file"},
+		{name: "empty API keys", cfg: AuthConfig{APIKeysFile: write("empty", "# none\n")}, wantErr: "no keys"},
+		{name: "missing JWKS", cfg: AuthConfig{JWKSFile: filepath.Join(dir, "missing.json")}, wantErr: "failed to read JWKS"},
+		{name: "no signing keys", cfg: AuthConfig{JWKSFile: write("none.json", `{"keys": []}`)}, wantErr: "no signing keys"},
+		{name: "small RSA key", cfg: AuthConfig{JWKSFile: write("small.json", `{"keys": [{"kty": "RSA", "n": "AQAB", "e": "AQAB"}]}`)}, wantErr: "at least 2048 bits"},
+		{name: "point off the curve", cfg: AuthConfig{JWKSFile: write("ec.json", `{"keys": [{"kty": "EC", "crv": "P-256",
+			"x": "AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAE", "y": "AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAE"}]}`)}, wantErr: "key 0"},
+		{name: "symmetric key", cfg: AuthConfig{JWKSFile: write("oct.json", `{"keys": [{"kty": "oct", "k": "c2VjcmV0"}]}`)}, wantErr: `unsupported kty "oct"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewAuthenticator(tt.cfg, "http://localhost")
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("newAuthenticator() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,120 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package a2aserver
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+
+	a2acore "github.com/a2aproject/a2a-go/a2a"
+	"github.com/a2aproject/a2a-go/a2asrv"
+	"github.com/a2aproject/a2a-go/a2asrv/push"
+)
+
+// --- A2A Push Notifications ---
+//
+// Clients which do not want to hold a connection open during a long task send
+// their message with a push notification config: a webhook URL and a token.
+// The server then answers at once with the task, and POSTs the task to the
+// webhook, with the token in the X-A2A-Notification-Token header, each time
+// its state changes. Push notifications are enabled with:
+//
+//	--push_hosts  A2A_PUSH_HOSTS  comma separated hosts webhooks may be on,
+//	                              or * for any; disabled if empty
+//
+// Webhooks are restricted to known hosts so that callers cannot make the
+// server POST to any host of its network.
+
+const pushHostsEnv = "A2A_PUSH_HOSTS"
+
+// PushHosts are the hosts webhooks may be on, as in --push_hosts.
+type PushHosts string
+
+func (h PushHosts) enabled() bool { return strings.TrimSpace(string(h)) != "" }
+
+// allows reports whether webhooks may be on the host.
+func (h PushHosts) allows(host string) bool {
+	for _, allowed := range strings.Split(string(h), ",") {
+		allowed = strings.TrimSpace(allowed)
+		if allowed == "*" || allowed != "" && strings.EqualFold(allowed, host) {
+			return true
+		}
+	}
+	return false
+}
+
+// Options returns the handler options enabling push notifications, none if
+// they are disabled.
+func (h PushHosts) Options() []a2asrv.RequestHandlerOption {
+	if !h.enabled() {
+		return nil
+	}
+	store := &allowedPushStore{PushConfigStore: push.NewInMemoryStore(), hosts: h}
+	sender := &statusPushSender{sender: push.NewHTTPPushSender(nil), sent: make(map[pushKey]a2acore.TaskState)}
+	return []a2asrv.RequestHandlerOption{a2asrv.WithPushNotifications(store, sender)}
+}
+
+// allowedPushStore refuses the webhooks on hosts which are not allowed.
+type allowedPushStore struct {
+	a2asrv.PushConfigStore
+	hosts PushHosts
+}
+
+func (s *allowedPushStore) Save(ctx context.Context, taskID a2acore.TaskID, config *a2acore.PushConfig) (*a2acore.PushConfig, error) {
+	if config != nil {
+		u, err := url.Parse(config.URL)
+		if err != nil || u.Scheme != "http" && u.Scheme != "https" {
+			return nil, fmt.Errorf("%w: webhook %q must be an http(s) URL", a2acore.ErrInvalidParams, config.URL)
+		}
+		if !s.hosts.allows(u.Hostname()) {
+			return nil, fmt.Errorf("%w: webhooks on %s are not allowed", a2acore.ErrInvalidParams, u.Hostname())
+		}
+	}
+	return s.PushConfigStore.Save(ctx, taskID, config)
+}
+
+// pushKey identifies the webhook of a task.
+type pushKey struct {
+	task   a2acore.TaskID
+	config string
+}
+
+// statusPushSender sends a task to a webhook only when its state changes,
+// rather than at every artifact update.
+type statusPushSender struct {
+	sender a2asrv.PushSender
+
+	mu   sync.Mutex
+	sent map[pushKey]a2acore.TaskState // last state sent, until final
+}
+
+func (s *statusPushSender) SendPush(ctx context.Context, config *a2acore.PushConfig, task *a2acore.Task) error {
+	key := pushKey{task: task.ID, config: config.ID}
+	s.mu.Lock()
+	changed := s.sent[key] != task.Status.State
+	if task.Status.State.Terminal() {
+		delete(s.sent, key)
+	} else {
+		s.sent[key] = task.Status.State
+	}
+	s.mu.Unlock()
+	if !changed {
+		return nil
+	}
+	return s.sender.SendPush(ctx, config, task)
+}
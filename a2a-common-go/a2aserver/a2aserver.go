@@ -0,0 +1,425 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package a2aserver serves an agent over A2A, with its agent card,
+// authentication, TLS and push notifications.
+package a2aserver
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	a2acore "github.com/a2aproject/a2a-go/a2a"
+	"github.com/a2aproject/a2a-go/a2asrv"
+	"github.com/a2aproject/a2a-go/a2asrv/eventqueue"
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/runner"
+	"google.golang.org/adk/server/adka2a"
+	"google.golang.org/adk/session"
+
+	"a2a-common-go/flagutil"
+)
+
+// --- A2A Server ---
+//
+// The agent is served over A2A on the address given by the --bind and
+// --port flags, and advertised in its agent card under the URL given by
+// --public_url: the address clients reach the server at, which differs from
+// the one it listens on behind a proxy, in a container or on Cloud Run.
+// Each flag falls back to an environment variable, then to a default:
+//
+//	--port        PORT              (set by Cloud Run)
+//	--bind        A2A_BIND_ADDRESS  all interfaces
+//	--public_url  A2A_PUBLIC_URL    http://localhost:<port>
+//	--agent_card  A2A_AGENT_CARD    none, see agentcard.go
+//	--streaming   STREAMING_MODE    none
+//
+// With --streaming=sse the agent runs with streaming and the card tells
+// clients to use message/stream, over which the text of the answer is sent as
+// it is generated. With none, clients get whole events.
+//
+// Tasks are kept in memory: clients can get them with tasks/get, follow one
+// still running with tasks/resubscribe, e.g. once their stream broke, and
+// cancel it with tasks/cancel, which cancels the context of its run, so the
+// model call and the tools in progress stop too.
+//
+// Callers are authenticated as configured by the flags in a2aauth.go, the
+// server speaks TLS as configured by those in a2atls.go, and sends push
+// notifications as configured by those in a2apush.go.
+//
+// The public URL must be one clients can connect to, so an unspecified
+// address such as 0.0.0.0 is rejected, and on Cloud Run, where localhost is
+// always wrong, it must be set explicitly.
+
+const (
+	portEnv        = "PORT"
+	bindAddressEnv = "A2A_BIND_ADDRESS"
+	publicURLEnv   = "A2A_PUBLIC_URL"
+	// cloudRunServiceEnv is set by Cloud Run in every container.
+	cloudRunServiceEnv = "K_SERVICE"
+
+	// InvokePath is where A2A JSON-RPC requests are served, as with the
+	// ADK launcher.
+	InvokePath = "/a2a/invoke"
+)
+
+// Config is where the A2A server listens and the URL it advertises.
+type Config struct {
+	bind      string
+	port      int
+	PublicURL string
+	cardFile  string
+	card      *agentCardConfig // loaded from cardFile by validate
+	auth      AuthConfig
+	authn     *Authenticator // loaded from auth by validate, nil if open
+	tls       serverTLSConfig
+	streaming agent.StreamingMode
+	push      PushHosts
+}
+
+// NewConfig reads the server configuration from the environment and
+// registers the flags overriding it on fs. Call validate once fs is parsed.
+func NewConfig(fs *flag.FlagSet, defaultPort int) (*Config, error) {
+	cfg := &Config{
+		bind:      os.Getenv(bindAddressEnv),
+		port:      defaultPort,
+		PublicURL: os.Getenv(publicURLEnv),
+		cardFile:  os.Getenv(agentCardEnv),
+		auth: AuthConfig{
+			APIKeysFile: os.Getenv(apiKeysFileEnv),
+			JWKSFile:    os.Getenv(jwksFileEnv),
+			issuer:      os.Getenv(jwtIssuerEnv),
+			audience:    os.Getenv(jwtAudienceEnv),
+		},
+		tls: serverTLSConfig{
+			certFile:     os.Getenv(tlsCertEnv),
+			keyFile:      os.Getenv(tlsKeyEnv),
+			clientCAFile: os.Getenv(tlsClientCAEnv),
+		},
+		push: PushHosts(os.Getenv(pushHostsEnv)),
+	}
+	if v := os.Getenv(portEnv); v != "" {
+		port, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s %q: must be a port number", portEnv, v)
+		}
+		cfg.port = port
+	}
+	fs.IntVar(&cfg.port, "port", cfg.port, "Port to listen on. Defaults to $"+portEnv+".")
+	fs.StringVar(&cfg.bind, "bind", cfg.bind, "Address to listen on, all interfaces if empty. Defaults to $"+bindAddressEnv+".")
+	fs.StringVar(&cfg.PublicURL, "public_url", cfg.PublicURL, "URL clients reach the server at, advertised in the agent card. Defaults to $"+publicURLEnv+".")
+	fs.StringVar(&cfg.cardFile, "agent_card", cfg.cardFile, "JSON file customizing the agent card: version, provider, skills, etc. Defaults to $"+agentCardEnv+".")
+	fs.StringVar(&cfg.auth.APIKeysFile, "api_keys_file", cfg.auth.APIKeysFile, "File of the API keys accepted in the "+apiKeyHeader+" header, one per line. Defaults to $"+apiKeysFileEnv+".")
+	fs.StringVar(&cfg.auth.JWKSFile, "jwks_file", cfg.auth.JWKSFile, "JSON Web Key Set verifying the bearer JWTs of callers. Defaults to $"+jwksFileEnv+".")
+	fs.StringVar(&cfg.auth.issuer, "jwt_issuer", cfg.auth.issuer, "Issuer the bearer JWTs must have, any if empty. Defaults to $"+jwtIssuerEnv+".")
+	fs.StringVar(&cfg.auth.audience, "jwt_audience", cfg.auth.audience, "Audience the bearer JWTs must have, the public URL if empty. Defaults to $"+jwtAudienceEnv+".")
+	fs.StringVar(&cfg.tls.certFile, "tls_cert", cfg.tls.certFile, "PEM certificate chain to serve TLS with. Defaults to $"+tlsCertEnv+".")
+	fs.StringVar(&cfg.tls.keyFile, "tls_key", cfg.tls.keyFile, "PEM private key of --tls_cert. Defaults to $"+tlsKeyEnv+".")
+	fs.StringVar(&cfg.tls.clientCAFile, "tls_client_ca", cfg.tls.clientCAFile, "PEM CA bundle verifying the certificates clients must present. Defaults to $"+tlsClientCAEnv+".")
+	fs.StringVar((*string)(&cfg.push), "push_hosts", string(cfg.push), "Comma separated hosts the webhooks of push notifications may be on, * for any. Push notifications are disabled if empty. Defaults to $"+pushHostsEnv+".")
+	if err := flagutil.StreamingModeVar(fs, &cfg.streaming, "Whether the agent streams its answers to clients"); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// Validate checks the configuration, fills in the default public URL and
+// loads the agent card file and the credentials of callers.
+func (c *Config) Validate() error {
+	if c.port < 1 || c.port > 65535 {
+		return fmt.Errorf("invalid port %d: must be between 1 and 65535", c.port)
+	}
+	if err := c.tls.validate(); err != nil {
+		return err
+	}
+	if c.PublicURL == "" {
+		if service := os.Getenv(cloudRunServiceEnv); service != "" {
+			return fmt.Errorf("running on Cloud Run as %q, set --public_url or %s to the service URL, e.g. https://%s-<hash>.a.run.app", service, publicURLEnv, service)
+		}
+		scheme := "http"
+		if c.tls.enabled() {
+			scheme = "https"
+		}
+		c.PublicURL = scheme + "://" + net.JoinHostPort("localhost", strconv.Itoa(c.port))
+	}
+	if err := validatePublicURL(c.PublicURL); err != nil {
+		return err
+	}
+	if c.tls.enabled() && !strings.HasPrefix(c.PublicURL, "https://") {
+		return fmt.Errorf("invalid public URL %q: must be https with --tls_cert", c.PublicURL)
+	}
+	if c.cardFile != "" {
+		card, err := loadAgentCardConfig(c.cardFile)
+		if err != nil {
+			return err
+		}
+		c.card = card
+	}
+	authn, err := NewAuthenticator(c.auth, c.PublicURL)
+	if err != nil {
+		return err
+	}
+	c.authn = authn
+	return nil
+}
+
+// validatePublicURL checks that clients can connect to the URL.
+func validatePublicURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return fmt.Errorf("invalid public URL %q: %w", raw, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("invalid public URL %q: scheme must be http or https", raw)
+	}
+	if u.Hostname() == "" {
+		return fmt.Errorf("invalid public URL %q: no host", raw)
+	}
+	if ip := net.ParseIP(u.Hostname()); ip != nil && ip.IsUnspecified() {
+		return fmt.Errorf("invalid public URL %q: %s is not an address clients can connect to; set --public_url or %s to the URL clients use", raw, u.Hostname(), publicURLEnv)
+	}
+	if u.RawQuery != "" || u.Fragment != "" {
+		return fmt.Errorf("invalid public URL %q: must not have a query or fragment", raw)
+	}
+	return nil
+}
+
+// addr is the address the server listens on.
+func (c *Config) addr() string {
+	return net.JoinHostPort(c.bind, strconv.Itoa(c.port))
+}
+
+// Protect returns h behind the authentication of the agent, if any. Extra
+// handlers passed to Serve are served as they are, so those exposing more
+// than the agent card should be protected.
+func (c *Config) Protect(h http.Handler) http.Handler {
+	if c.authn == nil {
+		return h
+	}
+	return c.authn.Wrap(h)
+}
+
+// Serve serves the agent returned by current over A2A until ctx is done,
+// along with the extra handlers by path. The agent may change between
+// requests, but not its name. Its card is customized by card, then by the
+// --agent_card file.
+func Serve(ctx context.Context, cfg *Config, current func() agent.Agent, sessionService session.Service, extra map[string]http.Handler, card ...CardOption) error {
+	invokeURL, err := url.JoinPath(cfg.PublicURL, InvokePath)
+	if err != nil {
+		return err
+	}
+	executor := NewLiveExecutor(current, sessionService, cfg.streaming)
+	card = append([]CardOption{WithStreaming(cfg.streaming == agent.StreamingModeSSE), WithPushNotifications(cfg.push.enabled())}, card...)
+	card = append(card, cfg.card.options()...)
+	invoke := cfg.Protect(a2asrv.NewJSONRPCHandler(a2asrv.NewHandler(executor, cfg.push.Options()...)))
+	if cfg.authn != nil {
+		card = append(card, cfg.authn.CardOption())
+	}
+	cardFor := func(ctx context.Context) (*a2acore.AgentCard, error) {
+		return NewAgentCard(current(), invokeURL, card...)
+	}
+	// Refuse to start with a card clients would reject.
+	if _, err := cardFor(ctx); err != nil {
+		return err
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(a2asrv.WellKnownAgentCardPath, a2asrv.NewAgentCardHandler(a2asrv.AgentCardProducerFn(cardFor)))
+	mux.Handle(InvokePath, invoke)
+	for path, h := range extra {
+		mux.Handle(path, h)
+	}
+
+	srv := &http.Server{
+		Addr:              cfg.addr(),
+		Handler:           logRequests(mux),
+		ReadHeaderTimeout: 15 * time.Second,
+		IdleTimeout:       60 * time.Second,
+	}
+	if cfg.tls.enabled() {
+		if srv.TLSConfig, err = cfg.tls.config(); err != nil {
+			return err
+		}
+	}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		srv.Shutdown(shutdownCtx)
+	}()
+
+	log.Printf("Serving agent %q over A2A on %s, advertised as %s", current().Name(), srv.Addr, invokeURL)
+	if cfg.tls.enabled() {
+		err = srv.ListenAndServeTLS("", "")
+	} else {
+		err = srv.ListenAndServe()
+	}
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("server failed: %w", err)
+	}
+	return nil
+}
+
+// ErrTaskCanceled is the cause of the cancellation of a run by tasks/cancel.
+var ErrTaskCanceled = errors.New("task canceled by the client")
+
+// LiveExecutor runs each request with the current agent.
+type LiveExecutor struct {
+	current        func() agent.Agent
+	sessionService session.Service
+	streaming      agent.StreamingMode
+
+	mu      sync.Mutex
+	running map[a2acore.TaskID]context.CancelCauseFunc
+}
+
+// NewLiveExecutor returns the executor running each request with the agent
+// returned by current, with answers streamed as the mode says.
+func NewLiveExecutor(current func() agent.Agent, sessionService session.Service, streaming agent.StreamingMode) *LiveExecutor {
+	return &LiveExecutor{current: current, sessionService: sessionService, streaming: streaming}
+}
+
+func (e *LiveExecutor) executor() *adka2a.Executor {
+	ag := e.current()
+	return adka2a.NewExecutor(adka2a.ExecutorConfig{
+		RunnerConfig: runner.Config{
+			AppName:        ag.Name(),
+			Agent:          ag,
+			SessionService: e.sessionService,
+		},
+		RunConfig: agent.RunConfig{StreamingMode: e.streaming},
+	})
+}
+
+func (e *LiveExecutor) Execute(ctx context.Context, reqCtx *a2asrv.RequestContext, queue eventqueue.Queue) error {
+	// a2asrv runs tasks detached from the request, so it is up to Cancel to
+	// stop them.
+	ctx, cancel := context.WithCancelCause(ctx)
+	e.mu.Lock()
+	if e.running == nil {
+		e.running = make(map[a2acore.TaskID]context.CancelCauseFunc)
+	}
+	e.running[reqCtx.TaskID] = cancel
+	e.mu.Unlock()
+	defer func() {
+		e.mu.Lock()
+		delete(e.running, reqCtx.TaskID)
+		e.mu.Unlock()
+		cancel(nil)
+	}()
+
+	err := e.executor().Execute(ctx, reqCtx, e.queue(queue))
+	if errors.Is(context.Cause(ctx), ErrTaskCanceled) {
+		// The task is already canceled, whatever the run failed with.
+		return nil
+	}
+	return err
+}
+
+func (e *LiveExecutor) Cancel(ctx context.Context, reqCtx *a2asrv.RequestContext, queue eventqueue.Queue) error {
+	// Unlike adka2a, end the task with the event, so that a2asrv settles it
+	// as canceled rather than with whatever the run ends with.
+	event := a2acore.NewStatusUpdateEvent(reqCtx, a2acore.TaskStateCanceled, nil)
+	event.Final = true
+	if err := queue.Write(ctx, event); err != nil {
+		return err
+	}
+	e.mu.Lock()
+	cancel, ok := e.running[reqCtx.TaskID]
+	e.mu.Unlock()
+	if ok {
+		cancel(ErrTaskCanceled)
+		log.Printf("Canceled task %s", reqCtx.TaskID)
+	}
+	return nil
+}
+
+// queue returns the queue adka2a writes the events of a run to. Only
+// streamed runs send their text twice; without streaming, an update with the
+// same text as the previous one is a legitimate second update.
+func (e *LiveExecutor) queue(queue eventqueue.Queue) eventqueue.Queue {
+	if e.streaming != agent.StreamingModeSSE {
+		return queue
+	}
+	return &streamedQueue{Queue: queue}
+}
+
+// streamedQueue drops the text streamed twice. With streaming, a model sends
+// its text in partial events, then whole in a final one for the session, as
+// do remote agents answering over message/stream. adka2a appends each event
+// to the response artifact, so without this the artifact, and the clients
+// reading it, would get the text twice.
+type streamedQueue struct {
+	eventqueue.Queue
+	author  string
+	text    strings.Builder // streamed since the last non-text update
+	thought strings.Builder
+}
+
+func (q *streamedQueue) Write(ctx context.Context, event a2acore.Event) error {
+	update, ok := event.(*a2acore.TaskArtifactUpdateEvent)
+	if !ok || len(update.Artifact.Parts) == 0 {
+		return q.Queue.Write(ctx, event)
+	}
+	if author, _ := update.Metadata[adka2a.ToA2AMetaKey("author")].(string); author != q.author {
+		q.author = author
+		q.reset()
+	}
+	var text, thought strings.Builder
+	for _, p := range update.Artifact.Parts {
+		tp, ok := p.(a2acore.TextPart)
+		if !ok {
+			q.reset()
+			return q.Queue.Write(ctx, event)
+		}
+		if isThought, _ := tp.Metadata[adka2a.ToA2AMetaKey("thought")].(bool); isThought {
+			thought.WriteString(tp.Text)
+		} else {
+			text.WriteString(tp.Text)
+		}
+	}
+	// The whole text is all the text streamed since the last reset.
+	if q.text.Len()+q.thought.Len() > 0 && text.String() == q.text.String() && thought.String() == q.thought.String() {
+		q.reset()
+		return nil
+	}
+	q.text.WriteString(text.String())
+	q.thought.WriteString(thought.String())
+	return q.Queue.Write(ctx, event)
+}
+
+func (q *streamedQueue) reset() {
+	q.text.Reset()
+	q.thought.Reset()
+}
+
+// logRequests logs the method, URI and duration of each request.
+func logRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		next.ServeHTTP(w, r)
+		log.Printf("%s %s %s", r.Method, r.RequestURI, time.Since(start))
+	})
+}
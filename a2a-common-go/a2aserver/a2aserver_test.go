@@ -0,0 +1,251 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package a2aserver
+
+import (
+	"context"
+	"flag"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	a2acore "github.com/a2aproject/a2a-go/a2a"
+	"github.com/a2aproject/a2a-go/a2asrv/eventqueue"
+	"google.golang.org/adk/agent"
+
+	"a2a-common-go/flagutil"
+)
+
+func TestServerConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		args    []string
+		want    Config
+		wantErr string
+	}{
+		{
+			name: "defaults",
+			want: Config{port: 8092, PublicURL: "http://localhost:8092", streaming: agent.StreamingModeNone},
+		},
+		{
+			name: "environment",
+			env:  map[string]string{"PORT": "9000", "A2A_BIND_ADDRESS": "127.0.0.1", "A2A_PUBLIC_URL": "https://agents.example.com/master"},
+			want: Config{bind: "127.0.0.1", port: 9000, PublicURL: "https://agents.example.com/master", streaming: agent.StreamingModeNone},
+		},
+		{
+			name: "flags override the environment",
+			env:  map[string]string{"PORT": "9000", "A2A_PUBLIC_URL": "https://old.example.com"},
+			args: []string{"--port", "9100", "--bind", "::1", "--public_url", "http://[::1]:9100"},
+			want: Config{bind: "::1", port: 9100, PublicURL: "http://[::1]:9100", streaming: agent.StreamingModeNone},
+		},
+		{
+			name: "Cloud Run",
+			env:  map[string]string{"PORT": "8080", "K_SERVICE": "master", "A2A_PUBLIC_URL": "https://master-abc.a.run.app"},
+			want: Config{port: 8080, PublicURL: "https://master-abc.a.run.app", streaming: agent.StreamingModeNone},
+		},
+		{
+			name: "streaming",
+			env:  map[string]string{"STREAMING_MODE": "SSE"},
+			want: Config{port: 8092, PublicURL: "http://localhost:8092", streaming: agent.StreamingModeSSE},
+		},
+		{
+			name: "streaming flag overrides the environment",
+			env:  map[string]string{"STREAMING_MODE": "sse"},
+			args: []string{"--streaming", "none"},
+			want: Config{port: 8092, PublicURL: "http://localhost:8092", streaming: agent.StreamingModeNone},
+		},
+		{
+			name:    "Cloud Run without public URL",
+			env:     map[string]string{"PORT": "8080", "K_SERVICE": "master"},
+			wantErr: `running on Cloud Run as "master"`,
+		},
+		{
+			name:    "unspecified IPv4 address",
+			args:    []string{"--public_url", "http://0.0.0.0:8092"},
+			wantErr: "0.0.0.0 is not an address clients can connect to",
+		},
+		{
+			name:    "unspecified IPv6 address",
+			args:    []string{"--public_url", "http://[::]:8092"},
+			wantErr: ":: is not an address clients can connect to",
+		},
+		{
+			name:    "no scheme",
+			args:    []string{"--public_url", "agents.example.com"},
+			wantErr: "scheme must be http or https",
+		},
+		{
+			name:    "invalid PORT",
+			env:     map[string]string{"PORT": "http"},
+			wantErr: `invalid PORT "http"`,
+		},
+		{
+			name:    "port out of range",
+			args:    []string{"--port", "70000"},
+			wantErr: "invalid port 70000",
+		},
+		{
+			name: "push notifications",
+			env:  map[string]string{"A2A_PUSH_HOSTS": "master.internal, 127.0.0.1"},
+			want: Config{port: 8092, PublicURL: "http://localhost:8092", streaming: agent.StreamingModeNone, push: "master.internal, 127.0.0.1"},
+		},
+		{
+			name:    "invalid STREAMING_MODE",
+			env:     map[string]string{"STREAMING_MODE": "bidi"},
+			wantErr: `invalid STREAMING_MODE: invalid streaming mode "bidi"`,
+		},
+		{
+			name:    "invalid streaming flag",
+			args:    []string{"--streaming", "websocket"},
+			wantErr: "must be none or sse",
+		},
+		{
+			name:    "missing agent card file",
+			env:     map[string]string{"A2A_AGENT_CARD": "/nonexistent/card.json"},
+			wantErr: "failed to read agent card config",
+		},
+		{
+			name:    "unexpected arguments",
+			args:    []string{"web", "api"},
+			wantErr: "unexpected arguments: web api",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, k := range []string{"PORT", "A2A_BIND_ADDRESS", "A2A_PUBLIC_URL", "A2A_AGENT_CARD", "K_SERVICE", "STREAMING_MODE", "A2A_PUSH_HOSTS"} {
+				t.Setenv(k, tt.env[k])
+			}
+			fs := flag.NewFlagSet("test", flag.ContinueOnError)
+			got, err := NewConfig(fs, 8092)
+			if err == nil {
+				err = flagutil.Parse(fs, tt.args)
+			}
+			if err == nil {
+				err = got.Validate()
+			}
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("serverConfig error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("serverConfig error = %v", err)
+			}
+			if *got != tt.want {
+				t.Errorf("serverConfig = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+// recordingQueue records the events written to it.
+type recordingQueue struct {
+	eventqueue.Queue
+	events []a2acore.Event
+}
+
+func (q *recordingQueue) Write(ctx context.Context, event a2acore.Event) error {
+	q.events = append(q.events, event)
+	return nil
+}
+
+func TestExecutorQueue(t *testing.T) {
+	task := &a2acore.Task{ID: "task-1", ContextID: "context-1"}
+	update := func(text string) a2acore.Event {
+		return a2acore.NewArtifactEvent(task, a2acore.TextPart{Text: text})
+	}
+
+	tests := []struct {
+		name      string
+		streaming agent.StreamingMode
+		texts     []string
+		want      int
+	}{
+		{
+			name:      "streamed text sent whole again",
+			streaming: agent.StreamingModeSSE,
+			texts:     []string{"Hel", "lo", "Hello"},
+			want:      2,
+		},
+		{
+			name:      "streamed text followed by new text",
+			streaming: agent.StreamingModeSSE,
+			texts:     []string{"Hel", "lo", "Bye"},
+			want:      3,
+		},
+		{
+			name:      "same text twice without streaming",
+			streaming: agent.StreamingModeNone,
+			texts:     []string{"Hello", "Hello"},
+			want:      2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := &recordingQueue{}
+			queue := NewLiveExecutor(nil, nil, tt.streaming).queue(rec)
+			for _, text := range tt.texts {
+				if err := queue.Write(t.Context(), update(text)); err != nil {
+					t.Fatalf("Write() error = %v", err)
+				}
+			}
+			if len(rec.events) != tt.want {
+				t.Errorf("events written = %d, want %d", len(rec.events), tt.want)
+			}
+		})
+	}
+}
+
+func TestProtect(t *testing.T) {
+	apiKeys := filepath.Join(t.TempDir(), "keys")
+	if err := os.WriteFile(apiKeys, []byte("secret-1\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	authn, err := NewAuthenticator(AuthConfig{APIKeysFile: apiKeys}, "http://localhost:8092")
+	if err != nil {
+		t.Fatal(err)
+	}
+	status := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) })
+
+	tests := []struct {
+		name   string
+		cfg    *Config
+		apiKey string
+		want   int
+	}{
+		{name: "no auth", cfg: &Config{}, want: http.StatusOK},
+		{name: "no credentials", cfg: &Config{authn: authn}, want: http.StatusUnauthorized},
+		{name: "wrong API key", cfg: &Config{authn: authn}, apiKey: "secret-2", want: http.StatusUnauthorized},
+		{name: "API key", cfg: &Config{authn: authn}, apiKey: "secret-1", want: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/status", nil)
+			if tt.apiKey != "" {
+				r.Header.Set(apiKeyHeader, tt.apiKey)
+			}
+			rec := httptest.NewRecorder()
+			tt.cfg.Protect(status).ServeHTTP(rec, r)
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d", rec.Code, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,103 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package a2aserver
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+
+	"a2a-common-go/tlsreload"
+)
+
+// --- A2A Server TLS ---
+//
+// The server speaks plain HTTP unless given a certificate:
+//
+//	--tls_cert       A2A_TLS_CERT       PEM certificate chain of the server
+//	--tls_key        A2A_TLS_KEY        PEM private key of the server
+//	--tls_client_ca  A2A_TLS_CLIENT_CA  PEM CA bundle; if set, clients must
+//	                                    present a certificate it signed (mTLS)
+//
+// With mTLS every request needs a client certificate, including those for the
+// agent card. The files are reloaded when they change, see package tlsreload.
+
+const (
+	tlsCertEnv     = "A2A_TLS_CERT"
+	tlsKeyEnv      = "A2A_TLS_KEY"
+	tlsClientCAEnv = "A2A_TLS_CLIENT_CA"
+)
+
+// serverTLSConfig is where the TLS files of the server are.
+type serverTLSConfig struct {
+	certFile     string
+	keyFile      string
+	clientCAFile string
+}
+
+func (c serverTLSConfig) enabled() bool { return c.certFile != "" }
+
+func (c serverTLSConfig) validate() error {
+	if (c.certFile == "") != (c.keyFile == "") {
+		return errors.New("--tls_cert and --tls_key must be set together")
+	}
+	if c.clientCAFile != "" && c.certFile == "" {
+		return errors.New("--tls_client_ca needs --tls_cert and --tls_key")
+	}
+	return nil
+}
+
+// config returns the TLS configuration of the server, following changes to
+// the files.
+func (c serverTLSConfig) config() (*tls.Config, error) {
+	paths := []string{c.certFile, c.keyFile}
+	if c.clientCAFile != "" {
+		paths = append(paths, c.clientCAFile)
+	}
+	current, err := tlsreload.New(c.load, paths...)
+	if err != nil {
+		return nil, err
+	}
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			return &current.Get().Certificates[0], nil
+		},
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			return current.Get(), nil
+		},
+	}, nil
+}
+
+// load reads the files into the configuration of one handshake.
+func (c serverTLSConfig) load() (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(c.certFile, c.keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load TLS certificate: %w", err)
+	}
+	cfg := &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		Certificates: []tls.Certificate{cert},
+	}
+	if c.clientCAFile != "" {
+		pool, err := tlsreload.LoadCertPool(c.clientCAFile)
+		if err != nil {
+			return nil, err
+		}
+		cfg.ClientCAs = pool
+		cfg.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return cfg, nil
+}
@@ -0,0 +1,239 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package a2aserver
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"a2a-common-go/remote"
+	"a2a-common-go/tlsreload"
+)
+
+// testCA issues certificates for tests.
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	file string // PEM bundle of the CA
+}
+
+var testSerial int64
+
+func newTestCA(t *testing.T, dir, name string) *testCA {
+	t.Helper()
+	ca := &testCA{}
+	ca.cert, ca.key = issueCert(t, &x509.Certificate{
+		Subject:               pkix.Name{CommonName: name},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}, nil)
+	ca.file = filepath.Join(dir, name+".pem")
+	writePEM(t, ca.file, "CERTIFICATE", ca.cert.Raw)
+	return ca
+}
+
+// issue writes a certificate for the template and its key in dir, returning
+// their paths.
+func (ca *testCA) issue(t *testing.T, dir, name string, usage x509.ExtKeyUsage) (certFile, keyFile string) {
+	t.Helper()
+	cert, key := issueCert(t, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: name},
+		IPAddresses: []net.IP{net.ParseIP("127.0.0.1")},
+		KeyUsage:    x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{usage},
+	}, ca)
+	certFile, keyFile = filepath.Join(dir, name+".pem"), filepath.Join(dir, name+"-key.pem")
+	writePEM(t, certFile, "CERTIFICATE", cert.Raw)
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writePEM(t, keyFile, "PRIVATE KEY", der)
+	return certFile, keyFile
+}
+
+// issueCert signs the template with the CA, or self-signs it if ca is nil.
+func issueCert(t *testing.T, tmpl *x509.Certificate, ca *testCA) (*x509.Certificate, *ecdsa.PrivateKey) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testSerial++
+	tmpl.SerialNumber = big.NewInt(testSerial)
+	tmpl.NotBefore, tmpl.NotAfter = time.Now().Add(-time.Hour), time.Now().Add(time.Hour)
+	parent, signer := tmpl, key
+	if ca != nil {
+		parent, signer = ca.cert, ca.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, parent, &key.PublicKey, signer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert, key
+}
+
+func writePEM(t *testing.T, path, typ string, der []byte) {
+	t.Helper()
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: typ, Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// replaceFile replaces dst by src, with a later modification time.
+func replaceFile(t *testing.T, src, dst string) {
+	t.Helper()
+	data, err := os.ReadFile(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(dst, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(dst, later, later); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMutualTLS(t *testing.T) {
+	interval := tlsreload.Interval
+	tlsreload.Interval = 0
+	t.Cleanup(func() { tlsreload.Interval = interval })
+
+	dir := t.TempDir()
+	ca := newTestCA(t, dir, "ca")
+	serverCert, serverKey := ca.issue(t, dir, "server", x509.ExtKeyUsageServerAuth)
+	clientCert, clientKey := ca.issue(t, dir, "client", x509.ExtKeyUsageClientAuth)
+	clientCA := filepath.Join(dir, "client-ca.pem")
+	replaceFile(t, ca.file, clientCA)
+
+	serverTLS := serverTLSConfig{certFile: serverCert, keyFile: serverKey, clientCAFile: clientCA}
+	if err := serverTLS.validate(); err != nil {
+		t.Fatal(err)
+	}
+	tlsConfig, err := serverTLS.config()
+	if err != nil {
+		t.Fatalf("config() error = %v", err)
+	}
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := &http.Server{
+		TLSConfig: tlsConfig,
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			io.WriteString(w, r.TLS.PeerCertificates[0].Subject.CommonName)
+		}),
+	}
+	go srv.ServeTLS(l, "", "")
+	t.Cleanup(func() { srv.Close() })
+	url := "https://" + l.Addr().String()
+
+	get := func(cfg remote.TLSConfig) (string, error) {
+		t.Helper()
+		transport, err := cfg.Transport()
+		if err != nil {
+			t.Fatalf("transport() error = %v", err)
+		}
+		resp, err := (&http.Client{Transport: transport}).Get(url)
+		if err != nil {
+			return "", err
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		return string(body), err
+	}
+
+	if got, err := get(remote.TLSConfig{CAFile: ca.file, CertFile: clientCert, KeyFile: clientKey}); err != nil || got != "client" {
+		t.Errorf("GET with a client certificate = %q, %v, want it accepted", got, err)
+	}
+	if _, err := get(remote.TLSConfig{CAFile: ca.file}); err == nil {
+		t.Error("GET without a client certificate succeeded")
+	}
+	if _, err := get(remote.TLSConfig{CertFile: clientCert, KeyFile: clientKey}); err == nil || !strings.Contains(err.Error(), "certificate") {
+		t.Errorf("GET without the custom CA error = %v, want the server certificate refused", err)
+	}
+
+	// Rotating the client CA of the server takes effect without a restart.
+	other := newTestCA(t, dir, "other-ca")
+	otherCert, otherKey := other.issue(t, dir, "other-client", x509.ExtKeyUsageClientAuth)
+	otherClient := remote.TLSConfig{CAFile: ca.file, CertFile: otherCert, KeyFile: otherKey}
+	if _, err := get(otherClient); err == nil {
+		t.Error("GET with a certificate of another CA succeeded")
+	}
+	replaceFile(t, other.file, clientCA)
+	if got, err := get(otherClient); err != nil || got != "other-client" {
+		t.Errorf("GET after rotating the client CA = %q, %v, want it accepted", got, err)
+	}
+
+	// So does rotating the client certificate of a transport in use.
+	transport, err := (remote.TLSConfig{CAFile: ca.file, CertFile: clientCert, KeyFile: clientKey}).Transport()
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := &http.Client{Transport: transport}
+	if resp, err := client.Get(url); err == nil {
+		resp.Body.Close()
+		t.Error("GET with a certificate of the replaced CA succeeded")
+	}
+	replaceFile(t, otherCert, clientCert)
+	replaceFile(t, otherKey, clientKey)
+	resp, err := client.Get(url)
+	if err != nil {
+		t.Fatalf("GET after rotating the client certificate error = %v", err)
+	}
+	resp.Body.Close()
+
+	// A broken file keeps the previous certificate in use.
+	if err := os.WriteFile(clientCA, []byte("not a certificate"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := get(otherClient); err != nil || got != "other-client" {
+		t.Errorf("GET with a broken client CA file = %q, %v, want the previous CA kept", got, err)
+	}
+}
+
+func TestTLSConfigValidation(t *testing.T) {
+	for _, c := range []serverTLSConfig{{certFile: "cert.pem"}, {keyFile: "key.pem"}, {clientCAFile: "ca.pem"}} {
+		if err := c.validate(); err == nil {
+			t.Errorf("validate() of %+v succeeded", c)
+		}
+	}
+	if err := (remote.TLSConfig{CertFile: "cert.pem"}).Validate(); err == nil {
+		t.Error("validate() of a client certificate without key succeeded")
+	}
+	if _, err := (remote.TLSConfig{CAFile: filepath.Join(t.TempDir(), "missing.pem")}).Transport(); err == nil {
+		t.Error("transport() with a missing CA bundle succeeded")
+	}
+}
@@ -0,0 +1,349 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package a2aserver
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+
+	a2acore "github.com/a2aproject/a2a-go/a2a"
+	"github.com/google/jsonschema-go/jsonschema"
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/server/adka2a"
+)
+
+// --- Agent Card ---
+//
+// The agent card served at the well-known path is generated from the agent:
+// its name, description and skills derived from its instruction and tools.
+// The program customizes it with card options, and the file given by
+// --agent_card (A2A_AGENT_CARD) customizes it further, e.g.
+//
+//	{
+//	  "version": "1.2.0",
+//	  "documentationUrl": "https://example.com/docs/check-prime",
+//	  "provider": {"organization": "Example", "url": "https://example.com"},
+//	  "defaultInputModes": ["text/plain"],
+//	  "defaultOutputModes": ["text/plain", "application/json"],
+//	  "skills": [{
+//	    "id": "check_prime",
+//	    "name": "Check primes",
+//	    "description": "Checks whether numbers are prime.",
+//	    "tags": ["prime", "primality"],
+//	    "examples": ["Is 7 prime?"]
+//	  }]
+//	}
+//
+// The fields have the names they have in the card; skills replace the
+// generated ones. The name and URL of the agent cannot be changed. The card is
+// validated against the A2A agent card schema before it is served, so that a
+// bad configuration fails at startup rather than confusing clients.
+
+const (
+	agentCardEnv = "A2A_AGENT_CARD"
+
+	// a2aProtocolVersion is the version of the A2A protocol of a2a-go.
+	a2aProtocolVersion = "0.3.0"
+	// defaultAgentVersion is advertised when no version is configured.
+	defaultAgentVersion = "1.0.0"
+)
+
+// CardOption customizes the agent card.
+type CardOption func(*a2acore.AgentCard)
+
+// WithDescription sets the description of the agent.
+func WithDescription(description string) CardOption {
+	return func(c *a2acore.AgentCard) { c.Description = description }
+}
+
+// withVersion sets the version of the agent.
+func withVersion(version string) CardOption {
+	return func(c *a2acore.AgentCard) { c.Version = version }
+}
+
+// withDocumentationURL sets the URL of the agent's documentation.
+func withDocumentationURL(u string) CardOption {
+	return func(c *a2acore.AgentCard) { c.DocumentationURL = u }
+}
+
+// withIconURL sets the URL of the agent's icon.
+func withIconURL(u string) CardOption {
+	return func(c *a2acore.AgentCard) { c.IconURL = u }
+}
+
+// withProvider sets the organization providing the agent.
+func withProvider(organization, u string) CardOption {
+	return func(c *a2acore.AgentCard) { c.Provider = &a2acore.AgentProvider{Org: organization, URL: u} }
+}
+
+// withInputModes sets the media types the agent accepts.
+func withInputModes(modes ...string) CardOption {
+	return func(c *a2acore.AgentCard) { c.DefaultInputModes = modes }
+}
+
+// withOutputModes sets the media types the agent produces.
+func withOutputModes(modes ...string) CardOption {
+	return func(c *a2acore.AgentCard) { c.DefaultOutputModes = modes }
+}
+
+// WithSkills replaces the skills generated from the agent.
+func WithSkills(skills ...a2acore.AgentSkill) CardOption {
+	return func(c *a2acore.AgentCard) { c.Skills = skills }
+}
+
+// WithCurrentSkills replaces the skills generated from the agent with the
+// ones skills returns each time the card is built, for agents whose skills
+// change while they are served.
+func WithCurrentSkills(skills func() []a2acore.AgentSkill) CardOption {
+	return func(c *a2acore.AgentCard) { c.Skills = skills() }
+}
+
+// WithStreaming sets whether clients should stream responses with
+// message/stream rather than wait for them with message/send.
+func WithStreaming(streaming bool) CardOption {
+	return func(c *a2acore.AgentCard) { c.Capabilities.Streaming = streaming }
+}
+
+// WithPushNotifications sets whether clients may ask for push notifications
+// of their tasks.
+func WithPushNotifications(enabled bool) CardOption {
+	return func(c *a2acore.AgentCard) { c.Capabilities.PushNotifications = enabled }
+}
+
+// agentCardConfig is the content of the --agent_card file. Empty fields keep
+// the card as it is.
+type agentCardConfig struct {
+	Description        string                 `json:"description,omitempty"`
+	Version            string                 `json:"version,omitempty"`
+	DocumentationURL   string                 `json:"documentationUrl,omitempty"`
+	IconURL            string                 `json:"iconUrl,omitempty"`
+	Provider           *a2acore.AgentProvider `json:"provider,omitempty"`
+	DefaultInputModes  []string               `json:"defaultInputModes,omitempty"`
+	DefaultOutputModes []string               `json:"defaultOutputModes,omitempty"`
+	Skills             []a2acore.AgentSkill   `json:"skills,omitempty"`
+}
+
+// loadAgentCardConfig reads the --agent_card file.
+func loadAgentCardConfig(path string) (*agentCardConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read agent card config: %w", err)
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	// Catch typos and fields which cannot be configured, such as the URL.
+	dec.DisallowUnknownFields()
+	cfg := &agentCardConfig{}
+	if err := dec.Decode(cfg); err != nil {
+		return nil, fmt.Errorf("invalid agent card config %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// options returns the card options setting the configured fields.
+func (c *agentCardConfig) options() []CardOption {
+	if c == nil {
+		return nil
+	}
+	var opts []CardOption
+	if c.Description != "" {
+		opts = append(opts, WithDescription(c.Description))
+	}
+	if c.Version != "" {
+		opts = append(opts, withVersion(c.Version))
+	}
+	if c.DocumentationURL != "" {
+		opts = append(opts, withDocumentationURL(c.DocumentationURL))
+	}
+	if c.IconURL != "" {
+		opts = append(opts, withIconURL(c.IconURL))
+	}
+	if c.Provider != nil {
+		opts = append(opts, withProvider(c.Provider.Org, c.Provider.URL))
+	}
+	if len(c.DefaultInputModes) > 0 {
+		opts = append(opts, withInputModes(c.DefaultInputModes...))
+	}
+	if len(c.DefaultOutputModes) > 0 {
+		opts = append(opts, withOutputModes(c.DefaultOutputModes...))
+	}
+	if len(c.Skills) > 0 {
+		opts = append(opts, WithSkills(c.Skills...))
+	}
+	return opts
+}
+
+// NewAgentCard returns the card of the agent served at invokeURL, customized
+// by opts in order.
+func NewAgentCard(ag agent.Agent, invokeURL string, opts ...CardOption) (*a2acore.AgentCard, error) {
+	card := &a2acore.AgentCard{
+		Name:               ag.Name(),
+		Description:        ag.Description(),
+		Version:            defaultAgentVersion,
+		ProtocolVersion:    a2aProtocolVersion,
+		DefaultInputModes:  []string{"text/plain"},
+		DefaultOutputModes: []string{"text/plain"},
+		URL:                invokeURL,
+		PreferredTransport: a2acore.TransportProtocolJSONRPC,
+		Skills:             adka2a.BuildAgentSkills(ag),
+		Capabilities:       a2acore.AgentCapabilities{Streaming: true},
+	}
+	for _, opt := range opts {
+		opt(card)
+	}
+	if err := validateAgentCard(card); err != nil {
+		return nil, fmt.Errorf("invalid agent card for %s: %w", card.Name, err)
+	}
+	return card, nil
+}
+
+// agentCardSchemaJSON is the JSON schema of the A2A agent card, with the
+// definitions of the A2A specification.
+//
+//go:embed agentcard.schema.json
+var agentCardSchemaJSON []byte
+
+// agentCardSchema returns the resolved agentCardSchemaJSON.
+var agentCardSchema = sync.OnceValues(func() (*jsonschema.Resolved, error) {
+	var s jsonschema.Schema
+	if err := json.Unmarshal(agentCardSchemaJSON, &s); err != nil {
+		return nil, fmt.Errorf("invalid agent card schema: %w", err)
+	}
+	return s.Resolve(nil)
+})
+
+// validateAgentCard validates the card against the A2A agent card schema,
+// which covers the required fields, the transports, the security schemes and
+// the extensions, then checks what the schema cannot express: the protocol
+// version is the one served, URLs are absolute http(s) URLs, modes are media
+// types, skill IDs are unique and the security requirements name declared
+// schemes.
+func validateAgentCard(card *a2acore.AgentCard) error {
+	var errs []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+	errs = append(errs, checkCardSchema(card))
+	check(card.Name != "", "name is required")
+	check(strings.TrimSpace(card.Description) != "", "description is required")
+	check(card.Version != "", "version is required")
+	check(card.ProtocolVersion == a2aProtocolVersion, "protocolVersion %q is not supported, only %s is", card.ProtocolVersion, a2aProtocolVersion)
+	errs = append(errs, checkCardURL("url", card.URL, true))
+	errs = append(errs, checkCardURL("documentationUrl", card.DocumentationURL, false))
+	errs = append(errs, checkCardURL("iconUrl", card.IconURL, false))
+	for i, iface := range card.AdditionalInterfaces {
+		errs = append(errs, checkCardURL(fmt.Sprintf("additionalInterfaces[%d].url", i), iface.URL, true))
+	}
+	for i, ext := range card.Capabilities.Extensions {
+		u, err := url.Parse(ext.URI)
+		check(err == nil && u.IsAbs(), "capabilities.extensions[%d].uri %q is not an absolute URI", i, ext.URI)
+	}
+	if p := card.Provider; p != nil {
+		check(p.Org != "", "provider.organization is required")
+		errs = append(errs, checkCardURL("provider.url", p.URL, true))
+	}
+	for _, req := range card.Security {
+		for name := range req {
+			_, ok := card.SecuritySchemes[name]
+			check(ok, "security: scheme %q is not in securitySchemes", name)
+		}
+	}
+	errs = append(errs, checkModes("defaultInputModes", card.DefaultInputModes, true))
+	errs = append(errs, checkModes("defaultOutputModes", card.DefaultOutputModes, true))
+
+	check(len(card.Skills) > 0, "skills: at least one skill is required")
+	ids := make(map[string]bool)
+	for i, s := range card.Skills {
+		field := fmt.Sprintf("skills[%d]", i)
+		check(s.ID != "", "%s.id is required", field)
+		check(!ids[s.ID], "%s.id %q is not unique", field, s.ID)
+		ids[s.ID] = true
+		check(s.Name != "", "%s.name is required", field)
+		check(strings.TrimSpace(s.Description) != "", "%s.description is required", field)
+		check(len(s.Tags) > 0, "%s.tags: at least one tag is required", field)
+		for _, tag := range s.Tags {
+			check(strings.TrimSpace(tag) != "", "%s.tags: empty tag", field)
+		}
+		for _, ex := range s.Examples {
+			check(strings.TrimSpace(ex) != "", "%s.examples: empty example", field)
+		}
+		errs = append(errs, checkModes(field+".inputModes", s.InputModes, false))
+		errs = append(errs, checkModes(field+".outputModes", s.OutputModes, false))
+	}
+	if err := errors.Join(errs...); err != nil {
+		// One line, for the logs.
+		return errors.New(strings.ReplaceAll(err.Error(), "\n", "; "))
+	}
+	return nil
+}
+
+// checkCardSchema validates the card, as served, against agentCardSchema.
+func checkCardSchema(card *a2acore.AgentCard) error {
+	schema, err := agentCardSchema()
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(card)
+	if err != nil {
+		return fmt.Errorf("failed to encode agent card: %w", err)
+	}
+	var instance any
+	if err := json.Unmarshal(data, &instance); err != nil {
+		return fmt.Errorf("failed to encode agent card: %w", err)
+	}
+	if err := schema.Validate(instance); err != nil {
+		return fmt.Errorf("does not match the A2A agent card schema: %w", err)
+	}
+	return nil
+}
+
+// checkCardURL checks that the field, if set or required, is an absolute
+// http(s) URL.
+func checkCardURL(field, raw string, required bool) error {
+	if raw == "" {
+		if required {
+			return fmt.Errorf("%s is required", field)
+		}
+		return nil
+	}
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%s %q is not an absolute http or https URL", field, raw)
+	}
+	return nil
+}
+
+// checkModes checks that the modes are media types such as text/plain.
+func checkModes(field string, modes []string, required bool) error {
+	if required && len(modes) == 0 {
+		return fmt.Errorf("%s: at least one media type is required", field)
+	}
+	for _, m := range modes {
+		mediaType, _, err := mime.ParseMediaType(m)
+		if err != nil || !strings.Contains(mediaType, "/") {
+			return fmt.Errorf("%s: %q is not a media type", field, m)
+		}
+	}
+	return nil
+}
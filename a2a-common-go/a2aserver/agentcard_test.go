@@ -0,0 +1,219 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package a2aserver
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	a2acore "github.com/a2aproject/a2a-go/a2a"
+	"google.golang.org/adk/agent"
+)
+
+func TestNewAgentCard(t *testing.T) {
+	ag, err := agent.New(agent.Config{Name: "check_prime_agent", Description: "Checks primes."})
+	if err != nil {
+		t.Fatal(err)
+	}
+	const invokeURL = "https://agents.example.com/a2a/invoke"
+
+	generated, err := NewAgentCard(ag, invokeURL)
+	if err != nil {
+		t.Fatalf("newAgentCard() error = %v", err)
+	}
+	if generated.Version != defaultAgentVersion || generated.ProtocolVersion != a2aProtocolVersion || len(generated.Skills) == 0 {
+		t.Errorf("generated card = %+v, want default versions and generated skills", generated)
+	}
+
+	path := filepath.Join(t.TempDir(), "card.json")
+	content := `{"version": "2.1.0", "documentationUrl": "https://example.com/docs",
+		"provider": {"organization": "Example", "url": "https://example.com"},
+		"defaultOutputModes": ["text/plain", "application/json"],
+		"skills": [{"id": "check_prime", "name": "Check primes", "description": "Checks whether numbers are prime.",
+			"tags": ["prime"], "examples": ["Is 7 prime?"]}]}`
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := loadAgentCardConfig(path)
+	if err != nil {
+		t.Fatalf("loadAgentCardConfig() error = %v", err)
+	}
+	// The file wins over the program's options.
+	opts := append([]CardOption{withVersion("1.5.0"), withIconURL("https://example.com/icon.png")}, cfg.options()...)
+	card, err := NewAgentCard(ag, invokeURL, opts...)
+	if err != nil {
+		t.Fatalf("newAgentCard() error = %v", err)
+	}
+	want := *generated
+	want.Version = "2.1.0"
+	want.DocumentationURL = "https://example.com/docs"
+	want.IconURL = "https://example.com/icon.png"
+	want.Provider = &a2acore.AgentProvider{Org: "Example", URL: "https://example.com"}
+	want.DefaultOutputModes = []string{"text/plain", "application/json"}
+	want.Skills = []a2acore.AgentSkill{{ID: "check_prime", Name: "Check primes", Description: "Checks whether numbers are prime.",
+		Tags: []string{"prime"}, Examples: []string{"Is 7 prime?"}}}
+	if !reflect.DeepEqual(*card, want) {
+		t.Errorf("customized card = %+v, want %+v", *card, want)
+	}
+
+	if err := os.WriteFile(path, []byte(`{"url": "http://elsewhere"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadAgentCardConfig(path); err == nil || !strings.Contains(err.Error(), `unknown field "url"`) {
+		t.Errorf("loadAgentCardConfig() with a URL error = %v, want the field refused", err)
+	}
+}
+
+// validTestCard returns a valid agent card.
+func validTestCard() *a2acore.AgentCard {
+	return &a2acore.AgentCard{
+		Name:               "check_prime_agent",
+		Description:        "Checks primes.",
+		URL:                "http://localhost:8086/a2a/invoke",
+		Version:            "1.0.0",
+		ProtocolVersion:    a2aProtocolVersion,
+		PreferredTransport: a2acore.TransportProtocolJSONRPC,
+		DefaultInputModes:  []string{"text/plain"},
+		DefaultOutputModes: []string{"text/plain"},
+		Skills:             []a2acore.AgentSkill{{ID: "check_prime", Name: "check_prime", Description: "Checks primes.", Tags: []string{"prime"}}},
+	}
+}
+
+func TestValidateAgentCard(t *testing.T) {
+	tests := []struct {
+		name    string
+		change  func(*a2acore.AgentCard)
+		wantErr []string
+	}{
+		{name: "valid", change: func(*a2acore.AgentCard) {}},
+		{
+			name:    "missing fields",
+			change:  func(c *a2acore.AgentCard) { c.Description, c.Version, c.URL = " ", "", "" },
+			wantErr: []string{"description is required", "version is required", "url is required"},
+		},
+		{
+			name: "bad URLs",
+			change: func(c *a2acore.AgentCard) {
+				c.DocumentationURL = "/docs"
+				c.Provider = &a2acore.AgentProvider{Org: "Example", URL: "ftp://example.com"}
+			},
+			wantErr: []string{`documentationUrl "/docs" is not an absolute http or https URL`, `provider.url "ftp://example.com"`},
+		},
+		{
+			name:    "bad modes",
+			change:  func(c *a2acore.AgentCard) { c.DefaultInputModes, c.DefaultOutputModes = nil, []string{"text"} },
+			wantErr: []string{"defaultInputModes: at least one media type is required", `defaultOutputModes: "text" is not a media type`},
+		},
+		{
+			name:    "unknown transport",
+			change:  func(c *a2acore.AgentCard) { c.PreferredTransport = "SOAP" },
+			wantErr: []string{`enum: SOAP does not equal any of`},
+		},
+		{
+			name:    "unsupported protocol version",
+			change:  func(c *a2acore.AgentCard) { c.ProtocolVersion = "0.2.5" },
+			wantErr: []string{`protocolVersion "0.2.5" is not supported`},
+		},
+		{
+			name: "security schemes",
+			change: func(c *a2acore.AgentCard) {
+				c.SecuritySchemes = a2acore.NamedSecuritySchemes{
+					"apiKey": a2acore.APIKeySecurityScheme{In: a2acore.APIKeySecuritySchemeInHeader, Name: "X-API-Key"},
+					"oauth": a2acore.OAuth2SecurityScheme{Flows: a2acore.OAuthFlows{
+						ClientCredentials: &a2acore.ClientCredentialsOAuthFlow{TokenURL: "https://auth.example.com/token", Scopes: map[string]string{"roll": "Roll dice."}},
+					}},
+				}
+				c.Security = []a2acore.SecurityRequirements{{"apiKey": {}}, {"oauth": {"roll"}}}
+			},
+		},
+		{
+			name: "bad security schemes",
+			change: func(c *a2acore.AgentCard) {
+				c.SecuritySchemes = a2acore.NamedSecuritySchemes{
+					"apiKey": a2acore.APIKeySecurityScheme{In: "body", Name: "X-API-Key"},
+					"oauth": a2acore.OAuth2SecurityScheme{Flows: a2acore.OAuthFlows{
+						Password: &a2acore.PasswordOAuthFlow{TokenURL: "https://auth.example.com/token"},
+					}},
+				}
+				c.Security = []a2acore.SecurityRequirements{{"mtls": {}}}
+			},
+			wantErr: []string{"securitySchemes/additionalProperties", `security: scheme "mtls" is not in securitySchemes`},
+		},
+		{
+			name: "missing scopes",
+			change: func(c *a2acore.AgentCard) {
+				c.SecuritySchemes = a2acore.NamedSecuritySchemes{"mtls": a2acore.MutualTLSSecurityScheme{}}
+				c.Security = []a2acore.SecurityRequirements{{"mtls": nil}}
+			},
+			wantErr: []string{"security/items"},
+		},
+		{
+			name: "bad extensions and interfaces",
+			change: func(c *a2acore.AgentCard) {
+				c.Capabilities.Extensions = []a2acore.AgentExtension{{URI: "https://example.com/ext/v1"}, {URI: "ext/v1"}}
+				c.AdditionalInterfaces = []a2acore.AgentInterface{{Transport: "SOAP", URL: "/a2a"}}
+			},
+			wantErr: []string{
+				`capabilities.extensions[1].uri "ext/v1" is not an absolute URI`,
+				`additionalInterfaces[0].url "/a2a" is not an absolute http or https URL`,
+				"enum: SOAP does not equal any of",
+			},
+		},
+		{
+			name: "bad skills",
+			change: func(c *a2acore.AgentCard) {
+				c.Skills = append(c.Skills, a2acore.AgentSkill{ID: "check_prime", Name: "again", OutputModes: []string{"json"}})
+			},
+			wantErr: []string{
+				`skills[1].id "check_prime" is not unique`,
+				"skills[1].description is required",
+				"skills[1].tags: at least one tag is required",
+				`skills[1].outputModes: "json" is not a media type`,
+			},
+		},
+		{
+			name:    "no skills",
+			change:  func(c *a2acore.AgentCard) { c.Skills = nil },
+			wantErr: []string{"at least one skill is required"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			card := validTestCard()
+			tt.change(card)
+			err := validateAgentCard(card)
+			if len(tt.wantErr) == 0 {
+				if err != nil {
+					t.Errorf("validateAgentCard() error = %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("validateAgentCard() succeeded")
+			}
+			if strings.Contains(err.Error(), "\n") {
+				t.Errorf("validateAgentCard() error = %q, want one line", err)
+			}
+			for _, want := range tt.wantErr {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("validateAgentCard() error = %v, want %q", err, want)
+				}
+			}
+		})
+	}
+}
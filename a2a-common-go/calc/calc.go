@@ -0,0 +1,637 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package calc evaluates arithmetic expressions exactly, for the calculate
+// tool.
+package calc
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"google.golang.org/adk/tool"
+	"google.golang.org/adk/tool/functiontool"
+
+	"a2a-common-go/toolkit"
+)
+
+// --- Calculator ---
+//
+// Models are unreliable at arithmetic, so agents hand it to the calculate
+// tool. Expressions are parsed and evaluated here, exactly, on arbitrary
+// precision rationals: nothing is ever executed, and the result of 1/3*3 is
+// 1. Syntax:
+//
+//	numbers     42, 2.5, 1e6
+//	operators   + - * / % ^ (or **) ! (factorial), with the usual precedence
+//	functions   abs min max sum avg gcd lcm floor ceil round sqrt pow mod binom
+//
+// Errors name the position, counted in characters from 1, where evaluation
+// failed.
+
+const (
+	// maxCalcBits bounds the size of numbers, so a short expression such as
+	// 9^9^9 cannot exhaust memory.
+	maxCalcBits = 1 << 16
+	// maxCalcDepth bounds the nesting of parentheses and unary operators.
+	maxCalcDepth = 100
+	// calcDecimalDigits is the number of decimals shown for non-integers.
+	calcDecimalDigits = 20
+)
+
+// calcError is a malformed expression or an undefined operation, such as a
+// division by zero.
+type calcError struct {
+	pos int // 1-based character position
+	msg string
+}
+
+func (e *calcError) Error() string {
+	return fmt.Sprintf("%s at position %d", e.msg, e.pos)
+}
+
+// Value is the result of an expression.
+type Value struct {
+	// Result is the exact result: an integer or a fraction in lowest terms.
+	Result string `json:"result"`
+	// Decimal is the result in decimal notation, rounded if Exact is false.
+	Decimal   string `json:"decimal"`
+	Exact     bool   `json:"exact"`
+	IsInteger bool   `json:"is_integer"`
+}
+
+// Evaluate evaluates an expression.
+func Evaluate(expr string) (Value, error) {
+	p := &calcParser{src: []rune(expr)}
+	p.next()
+	v, err := p.expr(0)
+	if err != nil {
+		return Value{}, err
+	}
+	if p.tok.kind != tokEOF {
+		return Value{}, p.unexpected("an operator")
+	}
+
+	result := Value{Result: v.RatString(), Decimal: v.RatString(), Exact: true, IsInteger: v.IsInt()}
+	if !v.IsInt() {
+		decimal := v.FloatString(calcDecimalDigits)
+		rounded, _ := new(big.Rat).SetString(decimal)
+		result.Exact = rounded.Cmp(v) == 0
+		result.Decimal = strings.TrimSuffix(strings.TrimRight(decimal, "0"), ".")
+	}
+	return result, nil
+}
+
+// --- Lexer ---
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokNumber
+	tokIdent
+	tokOp // one of + - * / % ^ ! ( ) , and ** as ^
+)
+
+type calcToken struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+func (t calcToken) String() string {
+	switch t.kind {
+	case tokEOF:
+		return "end of expression"
+	case tokNumber:
+		return "number " + t.text
+	case tokIdent:
+		return "name " + t.text
+	default:
+		return fmt.Sprintf("%q", t.text)
+	}
+}
+
+type calcParser struct {
+	src   []rune
+	off   int
+	tok   calcToken
+	depth int
+	err   error // a lexing error, reported by the parser
+}
+
+// next reads the next token into p.tok.
+func (p *calcParser) next() {
+	for p.off < len(p.src) && unicode.IsSpace(p.src[p.off]) {
+		p.off++
+	}
+	start := p.off
+	if p.off == len(p.src) {
+		p.tok = calcToken{kind: tokEOF, pos: start + 1}
+		return
+	}
+	c := p.src[p.off]
+	switch {
+	case unicode.IsDigit(c) || c == '.':
+		for p.off < len(p.src) && (unicode.IsDigit(p.src[p.off]) || p.src[p.off] == '.' || p.src[p.off] == '_') {
+			p.off++
+		}
+		// An exponent, e.g. 1e6 or 2.5E-3.
+		if p.off < len(p.src) && (p.src[p.off] == 'e' || p.src[p.off] == 'E') {
+			end := p.off + 1
+			if end < len(p.src) && (p.src[end] == '+' || p.src[end] == '-') {
+				end++
+			}
+			if end < len(p.src) && unicode.IsDigit(p.src[end]) {
+				for end < len(p.src) && unicode.IsDigit(p.src[end]) {
+					end++
+				}
+				p.off = end
+			}
+		}
+		p.tok = calcToken{kind: tokNumber, text: string(p.src[start:p.off]), pos: start + 1}
+	case unicode.IsLetter(c):
+		for p.off < len(p.src) && (unicode.IsLetter(p.src[p.off]) || unicode.IsDigit(p.src[p.off]) || p.src[p.off] == '_') {
+			p.off++
+		}
+		p.tok = calcToken{kind: tokIdent, text: strings.ToLower(string(p.src[start:p.off])), pos: start + 1}
+	case c == '*' && p.off+1 < len(p.src) && p.src[p.off+1] == '*':
+		p.off += 2
+		p.tok = calcToken{kind: tokOp, text: "^", pos: start + 1}
+	case strings.ContainsRune("+-*/%^!(),", c):
+		p.off++
+		p.tok = calcToken{kind: tokOp, text: string(c), pos: start + 1}
+	case c == '×' || c == '÷' || c == '−':
+		p.off++
+		p.tok = calcToken{kind: tokOp, text: map[rune]string{'×': "*", '÷': "/", '−': "-"}[c], pos: start + 1}
+	default:
+		p.off++
+		p.tok = calcToken{kind: tokOp, text: string(c), pos: start + 1}
+		p.err = &calcError{pos: start + 1, msg: fmt.Sprintf("unexpected character %q", c)}
+	}
+}
+
+func (p *calcParser) is(op string) bool {
+	return p.tok.kind == tokOp && p.tok.text == op
+}
+
+func (p *calcParser) unexpected(want string) error {
+	if p.err != nil {
+		return p.err
+	}
+	return &calcError{pos: p.tok.pos, msg: fmt.Sprintf("expected %s but found %s", want, p.tok)}
+}
+
+// --- Parser and Evaluator ---
+
+// Binary operator precedences; higher binds tighter.
+var calcPrecedence = map[string]int{"+": 1, "-": 1, "*": 2, "/": 2, "%": 2}
+
+// expr parses a sequence of unary expressions joined by binary operators of
+// at least precedence minPrec.
+func (p *calcParser) expr(minPrec int) (*big.Rat, error) {
+	left, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	for p.tok.kind == tokOp {
+		op := p.tok.text
+		prec, ok := calcPrecedence[op]
+		if !ok || prec < minPrec {
+			break
+		}
+		pos := p.tok.pos
+		p.next()
+		right, err := p.expr(prec + 1)
+		if err != nil {
+			return nil, err
+		}
+		if left, err = binaryOp(op, left, right, pos); err != nil {
+			return nil, err
+		}
+	}
+	return left, nil
+}
+
+// unary parses a signed power.
+func (p *calcParser) unary() (*big.Rat, error) {
+	p.depth++
+	defer func() { p.depth-- }()
+	if p.depth > maxCalcDepth {
+		return nil, &calcError{pos: p.tok.pos, msg: "expression is nested too deeply"}
+	}
+	if p.is("-") || p.is("+") {
+		neg := p.is("-")
+		p.next()
+		v, err := p.unary()
+		if err != nil || !neg {
+			return v, err
+		}
+		return v.Neg(v), nil
+	}
+	return p.power()
+}
+
+// power parses a postfix expression, optionally raised to a power. Powers
+// are right associative and bind tighter than a leading minus: -2^2 is -4.
+func (p *calcParser) power() (*big.Rat, error) {
+	base, err := p.postfix()
+	if err != nil {
+		return nil, err
+	}
+	if !p.is("^") {
+		return base, nil
+	}
+	pos := p.tok.pos
+	p.next()
+	exp, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	return ratPow(base, exp, pos)
+}
+
+// postfix parses a primary expression followed by any number of factorials.
+func (p *calcParser) postfix() (*big.Rat, error) {
+	v, err := p.primary()
+	if err != nil {
+		return nil, err
+	}
+	for p.is("!") {
+		pos := p.tok.pos
+		p.next()
+		if v, err = factorial(v, pos); err != nil {
+			return nil, err
+		}
+	}
+	return v, nil
+}
+
+// primary parses a number, a parenthesized expression or a function call.
+func (p *calcParser) primary() (*big.Rat, error) {
+	tok := p.tok
+	switch {
+	case tok.kind == tokNumber:
+		text := strings.ReplaceAll(tok.text, "_", "")
+		// big.Rat.SetString refuses huge exponents and is slow with large
+		// ones, so they are rejected before parsing.
+		if !checkExponent(text) {
+			return nil, &calcError{pos: tok.pos, msg: "number is too large"}
+		}
+		v, ok := new(big.Rat).SetString(text)
+		if !ok {
+			return nil, &calcError{pos: tok.pos, msg: fmt.Sprintf("malformed number %q", tok.text)}
+		}
+		if checkSize(v, tok.pos) != nil {
+			return nil, &calcError{pos: tok.pos, msg: "number is too large"}
+		}
+		p.next()
+		return v, nil
+	case tok.kind == tokOp && tok.text == "(":
+		p.next()
+		v, err := p.expr(0)
+		if err != nil {
+			return nil, err
+		}
+		if !p.is(")") {
+			return nil, p.unexpected(`")"`)
+		}
+		p.next()
+		return v, nil
+	case tok.kind == tokIdent:
+		fn, ok := calcFunctions[tok.text]
+		if !ok {
+			return nil, &calcError{pos: tok.pos, msg: fmt.Sprintf("unknown function %q", tok.text)}
+		}
+		p.next()
+		if !p.is("(") {
+			return nil, p.unexpected(fmt.Sprintf(`"(" after %s`, tok.text))
+		}
+		p.next()
+		var args []*big.Rat
+		for !p.is(")") {
+			if len(args) > 0 {
+				if !p.is(",") {
+					return nil, p.unexpected(`"," or ")"`)
+				}
+				p.next()
+			}
+			arg, err := p.expr(0)
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, arg)
+		}
+		p.next()
+		if len(args) < fn.minArgs || (fn.maxArgs >= 0 && len(args) > fn.maxArgs) {
+			return nil, &calcError{pos: tok.pos, msg: fmt.Sprintf("%s takes %s, got %d", tok.text, fn.arity(), len(args))}
+		}
+		v, err := fn.eval(args, tok.pos)
+		if err != nil {
+			return nil, err
+		}
+		return v, checkSize(v, tok.pos)
+	default:
+		return nil, p.unexpected("a number, function or \"(\"")
+	}
+}
+
+func binaryOp(op string, a, b *big.Rat, pos int) (*big.Rat, error) {
+	var v *big.Rat
+	switch op {
+	case "+":
+		v = new(big.Rat).Add(a, b)
+	case "-":
+		v = new(big.Rat).Sub(a, b)
+	case "*":
+		v = new(big.Rat).Mul(a, b)
+	case "/":
+		if b.Sign() == 0 {
+			return nil, &calcError{pos: pos, msg: "division by zero"}
+		}
+		v = new(big.Rat).Quo(a, b)
+	case "%":
+		return ratMod(a, b, pos)
+	}
+	return v, checkSize(v, pos)
+}
+
+// checkSize rejects numbers too large to work with.
+func checkSize(v *big.Rat, pos int) error {
+	if v.Num().BitLen()+v.Denom().BitLen() > maxCalcBits {
+		return &calcError{pos: pos, msg: "result is too large"}
+	}
+	return nil
+}
+
+// checkExponent reports whether the exponent of a number literal, if any, is
+// small enough for the number to pass checkSize. A power of ten takes more
+// than three bits per digit, so any exponent beyond maxCalcBits is too large
+// for the digits before it to make up for.
+func checkExponent(text string) bool {
+	i := strings.IndexAny(text, "eE")
+	if i < 0 {
+		return true
+	}
+	exp, err := strconv.ParseInt(text[i+1:], 10, 64)
+	return err == nil && exp >= -maxCalcBits && exp <= maxCalcBits
+}
+
+// ratPow raises base to an integer power.
+func ratPow(base, exp *big.Rat, pos int) (*big.Rat, error) {
+	if !exp.IsInt() {
+		return nil, &calcError{pos: pos, msg: "exponent must be an integer"}
+	}
+	if base.Sign() == 0 {
+		if exp.Sign() < 0 {
+			return nil, &calcError{pos: pos, msg: "division by zero"}
+		}
+		if exp.Sign() == 0 {
+			return big.NewRat(1, 1), nil
+		}
+		return new(big.Rat), nil
+	}
+	e := new(big.Int).Abs(exp.Num())
+	// |base| other than 1 grows by at least a bit per step.
+	bits := max(base.Num().BitLen(), base.Denom().BitLen())
+	if base.Num().CmpAbs(base.Denom()) != 0 && (!e.IsInt64() || e.Int64()*int64(max(bits-1, 1)) > maxCalcBits) {
+		return nil, &calcError{pos: pos, msg: "result is too large"}
+	}
+	num := new(big.Int).Exp(base.Num(), e, nil)
+	den := new(big.Int).Exp(base.Denom(), e, nil)
+	if exp.Sign() < 0 {
+		num, den = den, num
+	}
+	v := new(big.Rat).SetFrac(num, den)
+	return v, checkSize(v, pos)
+}
+
+// ratMod returns a mod b for integers, with the sign of b as in floored
+// division, so -1 % 3 is 2.
+func ratMod(a, b *big.Rat, pos int) (*big.Rat, error) {
+	if !a.IsInt() || !b.IsInt() {
+		return nil, &calcError{pos: pos, msg: "modulo needs integers"}
+	}
+	if b.Sign() == 0 {
+		return nil, &calcError{pos: pos, msg: "modulo by zero"}
+	}
+	m := new(big.Int).Mod(a.Num(), b.Num()) // Euclidean, never negative
+	if b.Sign() < 0 && m.Sign() != 0 {
+		m.Add(m, b.Num())
+	}
+	return new(big.Rat).SetInt(m), nil
+}
+
+// maxFactorial bounds the argument of factorials and binomials.
+const maxFactorial = 5000
+
+func factorial(v *big.Rat, pos int) (*big.Rat, error) {
+	if !v.IsInt() || v.Sign() < 0 {
+		return nil, &calcError{pos: pos, msg: "factorial needs a non-negative integer"}
+	}
+	if v.Num().Cmp(big.NewInt(maxFactorial)) > 0 {
+		return nil, &calcError{pos: pos, msg: "result is too large"}
+	}
+	f := new(big.Int).MulRange(1, v.Num().Int64())
+	r := new(big.Rat).SetInt(f)
+	return r, checkSize(r, pos)
+}
+
+// calcFunction is a function callable in expressions.
+type calcFunction struct {
+	minArgs, maxArgs int // maxArgs is -1 for any number
+	eval             func(args []*big.Rat, pos int) (*big.Rat, error)
+}
+
+func (f calcFunction) arity() string {
+	switch {
+	case f.maxArgs < 0:
+		return fmt.Sprintf("at least %d arguments", f.minArgs)
+	case f.minArgs == f.maxArgs && f.minArgs == 1:
+		return "1 argument"
+	case f.minArgs == f.maxArgs:
+		return fmt.Sprintf("%d arguments", f.minArgs)
+	default:
+		return fmt.Sprintf("%d to %d arguments", f.minArgs, f.maxArgs)
+	}
+}
+
+var calcFunctions map[string]calcFunction
+
+func init() {
+	one := func(f func(v *big.Rat, pos int) (*big.Rat, error)) calcFunction {
+		return calcFunction{1, 1, func(args []*big.Rat, pos int) (*big.Rat, error) { return f(args[0], pos) }}
+	}
+	ints := func(name string, args []*big.Rat, pos int) ([]*big.Int, error) {
+		out := make([]*big.Int, len(args))
+		for i, a := range args {
+			if !a.IsInt() {
+				return nil, &calcError{pos: pos, msg: name + " needs integers"}
+			}
+			out[i] = a.Num()
+		}
+		return out, nil
+	}
+	calcFunctions = map[string]calcFunction{
+		"abs": one(func(v *big.Rat, _ int) (*big.Rat, error) { return new(big.Rat).Abs(v), nil }),
+		"floor": one(func(v *big.Rat, _ int) (*big.Rat, error) {
+			return new(big.Rat).SetInt(floorDiv(v.Num(), v.Denom())), nil
+		}),
+		"ceil": one(func(v *big.Rat, _ int) (*big.Rat, error) {
+			n := new(big.Int).Neg(v.Num())
+			return new(big.Rat).SetInt(n.Neg(floorDiv(n, v.Denom()))), nil
+		}),
+		// round rounds half away from zero, like most calculators.
+		"round": one(func(v *big.Rat, _ int) (*big.Rat, error) {
+			half := new(big.Rat).Add(new(big.Rat).Abs(v), big.NewRat(1, 2))
+			r := floorDiv(half.Num(), half.Denom())
+			if v.Sign() < 0 {
+				r.Neg(r)
+			}
+			return new(big.Rat).SetInt(r), nil
+		}),
+		// sqrt is exact, so it is only defined for squares of rationals.
+		"sqrt": one(func(v *big.Rat, pos int) (*big.Rat, error) {
+			if v.Sign() < 0 {
+				return nil, &calcError{pos: pos, msg: "square root of a negative number"}
+			}
+			n, d := new(big.Int).Sqrt(v.Num()), new(big.Int).Sqrt(v.Denom())
+			r := new(big.Rat).SetFrac(n, d)
+			if new(big.Rat).Mul(r, r).Cmp(v) != 0 {
+				return nil, &calcError{pos: pos, msg: fmt.Sprintf("square root of %s is irrational", v.RatString())}
+			}
+			return r, nil
+		}),
+		"pow": {2, 2, func(args []*big.Rat, pos int) (*big.Rat, error) { return ratPow(args[0], args[1], pos) }},
+		"mod": {2, 2, func(args []*big.Rat, pos int) (*big.Rat, error) { return ratMod(args[0], args[1], pos) }},
+		"min": {1, -1, func(args []*big.Rat, _ int) (*big.Rat, error) {
+			m := args[0]
+			for _, a := range args[1:] {
+				if a.Cmp(m) < 0 {
+					m = a
+				}
+			}
+			return m, nil
+		}},
+		"max": {1, -1, func(args []*big.Rat, _ int) (*big.Rat, error) {
+			m := args[0]
+			for _, a := range args[1:] {
+				if a.Cmp(m) > 0 {
+					m = a
+				}
+			}
+			return m, nil
+		}},
+		"sum": {1, -1, func(args []*big.Rat, _ int) (*big.Rat, error) {
+			s := new(big.Rat)
+			for _, a := range args {
+				s.Add(s, a)
+			}
+			return s, nil
+		}},
+		"avg": {1, -1, func(args []*big.Rat, _ int) (*big.Rat, error) {
+			s := new(big.Rat)
+			for _, a := range args {
+				s.Add(s, a)
+			}
+			return s.Quo(s, big.NewRat(int64(len(args)), 1)), nil
+		}},
+		"gcd": {2, -1, func(args []*big.Rat, pos int) (*big.Rat, error) {
+			ns, err := ints("gcd", args, pos)
+			if err != nil {
+				return nil, err
+			}
+			g := new(big.Int).Abs(ns[0])
+			for _, n := range ns[1:] {
+				g.GCD(nil, nil, g, new(big.Int).Abs(n))
+			}
+			return new(big.Rat).SetInt(g), nil
+		}},
+		"lcm": {2, -1, func(args []*big.Rat, pos int) (*big.Rat, error) {
+			ns, err := ints("lcm", args, pos)
+			if err != nil {
+				return nil, err
+			}
+			l := new(big.Int).Abs(ns[0])
+			for _, n := range ns[1:] {
+				n = new(big.Int).Abs(n)
+				if l.Sign() == 0 || n.Sign() == 0 {
+					l.SetInt64(0)
+					continue
+				}
+				g := new(big.Int).GCD(nil, nil, l, n)
+				l.Mul(l, new(big.Int).Quo(n, g))
+				if l.BitLen() > maxCalcBits {
+					return nil, &calcError{pos: pos, msg: "result is too large"}
+				}
+			}
+			return new(big.Rat).SetInt(l), nil
+		}},
+		"binom": {2, 2, func(args []*big.Rat, pos int) (*big.Rat, error) {
+			ns, err := ints("binom", args, pos)
+			if err != nil {
+				return nil, err
+			}
+			n, k := ns[0], ns[1]
+			if n.Sign() < 0 || k.Sign() < 0 {
+				return nil, &calcError{pos: pos, msg: "binom needs non-negative integers"}
+			}
+			if n.Cmp(big.NewInt(maxFactorial)) > 0 {
+				return nil, &calcError{pos: pos, msg: "result is too large"}
+			}
+			if k.Cmp(n) > 0 {
+				return new(big.Rat), nil
+			}
+			return new(big.Rat).SetInt(new(big.Int).Binomial(n.Int64(), k.Int64())), nil
+		}},
+	}
+}
+
+// floorDiv returns the largest integer not greater than n/d, for d > 0.
+func floorDiv(n, d *big.Int) *big.Int {
+	q, m := new(big.Int).QuoRem(n, d, new(big.Int))
+	if m.Sign() < 0 {
+		q.Sub(q, big.NewInt(1))
+	}
+	return q
+}
+
+// --- Calculate Tool ---
+
+type calculateToolArgs struct {
+	Expression string `json:"expression" jsonschema:"The arithmetic expression to evaluate, e.g. (3+4+6)/2 or gcd(12, 18)." validate:"min=1,max=1000"`
+}
+
+func calculate(tc tool.Context, args calculateToolArgs) (Value, error) {
+	return Evaluate(args.Expression)
+}
+
+// NewTool creates the calculate tool, so agents do not do arithmetic
+// themselves.
+func NewTool() (toolkit.FunctionTool, error) {
+	t, err := toolkit.NewValidatedTool(functiontool.Config{
+		Name: "calculate",
+		Description: "Evaluate an arithmetic expression exactly and return the result as an integer or fraction and in decimal notation. " +
+			"Supports + - * / % ^ and ! (factorial), parentheses and the functions " +
+			"abs, min, max, sum, avg, gcd, lcm, floor, ceil, round, sqrt, pow, mod and binom. " +
+			"Use it for any arithmetic, such as summing rolls, instead of computing the result yourself.",
+	}, calculate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create calculate tool: %w", err)
+	}
+	return t, nil
+}
@@ -0,0 +1,155 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package calc
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"a2a-common-go/internal/testutil"
+	"a2a-common-go/toolkit"
+)
+
+func TestEvaluate(t *testing.T) {
+	tests := []struct {
+		expr    string
+		want    string
+		decimal string
+	}{
+		{expr: "3 + 4 + 6", want: "13"},
+		{expr: "sum(3, 4, 6) / 2", want: "13/2", decimal: "6.5"},
+		{expr: "1/3*3", want: "1"},
+		{expr: "1/3", want: "1/3", decimal: "0.33333333333333333333"},
+		{expr: "2 + 3 * 4 - 6 / 2", want: "11"},
+		{expr: "1 - 2 - 3", want: "-4"},
+		{expr: "-2^2", want: "-4"},
+		{expr: "2^3^2", want: "512"},
+		{expr: "2 ** -2", want: "1/4", decimal: "0.25"},
+		{expr: "(-2)^3", want: "-8"},
+		{expr: "2^100", want: "1267650600228229401496703205376"},
+		{expr: "0.1 + 0.2", want: "3/10", decimal: "0.3"},
+		{expr: "1.5e3", want: "1500"},
+		{expr: "2.5E-3 * 1e+4", want: "25"},
+		{expr: "0.001e3", want: "1"},
+		{expr: "5! / 3!", want: "20"},
+		{expr: "-7 % 3", want: "2"},
+		{expr: "mod(7, -3)", want: "-2"},
+		{expr: "gcd(12, 18, 27) + lcm(4, 6)", want: "15"},
+		{expr: "floor(-7/2) + ceil(7/2) + round(5/2) + round(-5/2)", want: "0"},
+		{expr: "sqrt(9/16)", want: "3/4", decimal: "0.75"},
+		{expr: "min(3, 1/2, 2) + max(1, 2) + abs(-3)", want: "11/2", decimal: "5.5"},
+		{expr: "avg(1, 2, 4)", want: "7/3", decimal: "2.33333333333333333333"},
+		{expr: "binom(52, 5)", want: "2598960"},
+		{expr: "6 × 7 − 2 ÷ 2", want: "41"},
+	}
+	for _, tt := range tests {
+		got, err := Evaluate(tt.expr)
+		if err != nil {
+			t.Errorf("evaluate(%q) error = %v", tt.expr, err)
+			continue
+		}
+		decimal := tt.decimal
+		if decimal == "" {
+			decimal = tt.want
+		}
+		if got.Result != tt.want || got.Decimal != decimal {
+			t.Errorf("evaluate(%q) = %+v, want %s (%s)", tt.expr, got, tt.want, decimal)
+		}
+	}
+}
+
+func TestEvaluateErrors(t *testing.T) {
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{expr: "", want: `expected a number, function or "(" but found end of expression at position 1`},
+		{expr: "2 +", want: `expected a number, function or "(" but found end of expression at position 4`},
+		{expr: "(1 + 2", want: `expected ")" but found end of expression at position 7`},
+		{expr: "2 3", want: "expected an operator but found number 3 at position 3"},
+		{expr: "1 / (2 - 2)", want: "division by zero at position 3"},
+		{expr: "4 % 0", want: "modulo by zero at position 3"},
+		{expr: "1.5 % 1", want: "modulo needs integers at position 5"},
+		{expr: "2 $ 3", want: `unexpected character '$' at position 3`},
+		{expr: "1.2.3", want: `malformed number "1.2.3" at position 1`},
+		{expr: "foo(1)", want: `unknown function "foo" at position 1`},
+		{expr: "sqrt", want: `expected "(" after sqrt but found end of expression at position 5`},
+		{expr: "max(1 2)", want: `expected "," or ")" but found number 2 at position 7`},
+		{expr: "pow(2)", want: "pow takes 2 arguments, got 1 at position 1"},
+		{expr: "sqrt(2)", want: "square root of 2 is irrational at position 1"},
+		{expr: "2 ^ 0.5", want: "exponent must be an integer at position 3"},
+		{expr: "(-1)!", want: "factorial needs a non-negative integer at position 5"},
+		{expr: "9^9^9", want: "result is too large at position 2"},
+		{expr: "100000!", want: "result is too large at position 7"},
+		{expr: "2 + 1e9999999", want: "number is too large at position 5"},
+		{expr: "1e99999999999999999999", want: "number is too large at position 1"},
+		{expr: "1e-70000", want: "number is too large at position 1"},
+		{expr: "1e30000", want: "number is too large at position 1"},
+		{expr: strings.Repeat("(", 200) + "1" + strings.Repeat(")", 200), want: "expression is nested too deeply"},
+	}
+	for _, tt := range tests {
+		_, err := Evaluate(tt.expr)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("evaluate(%q) error = %v, want %q", tt.expr, err, tt.want)
+		}
+	}
+}
+
+func TestTool(t *testing.T) {
+	calcTool, err := NewTool()
+	if err != nil {
+		t.Fatalf("NewTool() error = %v", err)
+	}
+	tc := testutil.ToolContext(t.Context())
+
+	tests := []struct {
+		name string
+		args map[string]any
+		want map[string]any
+	}{
+		{
+			name: "integer",
+			args: map[string]any{"expression": "3 + 4 + 6"},
+			want: map[string]any{"result": "13", "decimal": "13", "exact": true, "is_integer": true},
+		},
+		{
+			name: "fraction",
+			args: map[string]any{"expression": "sum(3, 4, 6) / 2"},
+			want: map[string]any{"result": "13/2", "decimal": "6.5", "exact": true, "is_integer": false},
+		},
+		{
+			name: "undefined operation",
+			args: map[string]any{"expression": "1/0"},
+			want: toolkit.Error(toolkit.CodeFailed, "division by zero at position 2"),
+		},
+		{
+			name: "empty expression",
+			args: map[string]any{"expression": ""},
+			want: toolkit.Error(toolkit.CodeInvalidArguments, "length of expression must be at least 1, got 0"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := calcTool.Run(tc, tt.args)
+			if err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Run() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
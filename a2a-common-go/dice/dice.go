@@ -0,0 +1,331 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package dice rolls dice, written in dice notation, with auditable
+// randomness.
+package dice
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"google.golang.org/adk/tool"
+)
+
+// --- Dice Notation ---
+
+// Limits applied to every dice expression.
+const (
+	maxDiceTerms    = 10   // dice groups and constants in one expression
+	maxDiceCount    = 100  // dice rolled in one expression, including explosions
+	maxDiceSides    = 1000 // sides on a single die
+	maxDiceModifier = 1000 // absolute value of a constant term
+)
+
+// diceKeep describes which dice of a group count towards the total.
+type diceKeep struct {
+	highest bool // keep (or drop) the highest dice rather than the lowest
+	drop    bool // n is the number of dice to drop rather than to keep
+	n       int
+}
+
+// diceTerm is a single "NdS" group or a constant in a dice expression.
+type diceTerm struct {
+	sign      int // +1 or -1
+	count     int // 0 for a constant term
+	sides     int
+	exploding bool
+	keep      *diceKeep
+	constant  int
+}
+
+// diceGroupResult is the outcome of rolling one dice group.
+type diceGroupResult struct {
+	Notation string `json:"notation"`
+	Rolls    []int  `json:"rolls"`
+	Kept     []int  `json:"kept"`
+	Dropped  []int  `json:"dropped,omitempty"`
+	Subtotal int    `json:"subtotal"`
+}
+
+// diceResult is the outcome of evaluating a dice expression.
+type diceResult struct {
+	Expression string            `json:"expression"`
+	Groups     []diceGroupResult `json:"groups"`
+	Modifier   int               `json:"modifier"`
+	Total      int               `json:"total"`
+}
+
+// diceAliases maps the long-hand modifiers users type to their short form.
+var diceAliases = strings.NewReplacer(
+	"drop lowest", "dl",
+	"drop highest", "dh",
+	"keep lowest", "kl",
+	"keep highest", "kh",
+)
+
+// parseDice parses expressions such as "3d6+2", "4d6 drop lowest",
+// "2d20kh1", "d%" or "3d6!" (exploding).
+func parseDice(expr string) ([]diceTerm, error) {
+	s := diceAliases.Replace(strings.ToLower(expr))
+	s = strings.Join(strings.Fields(s), "")
+	if s == "" {
+		return nil, fmt.Errorf("empty dice expression")
+	}
+
+	p := &diceParser{s: s}
+	var terms []diceTerm
+	for i := 0; p.pos < len(p.s); i++ {
+		sign := 1
+		switch {
+		case p.accept("+"):
+		case p.accept("-"):
+			sign = -1
+		case i > 0:
+			return nil, p.errorf("expected + or -")
+		}
+		t, err := p.term()
+		if err != nil {
+			return nil, err
+		}
+		t.sign = sign
+		terms = append(terms, t)
+		if len(terms) > maxDiceTerms {
+			return nil, fmt.Errorf("too many terms, at most %d are allowed", maxDiceTerms)
+		}
+	}
+
+	dice := 0
+	for _, t := range terms {
+		dice += t.count
+	}
+	if dice > maxDiceCount {
+		return nil, fmt.Errorf("too many dice, at most %d can be rolled at once", maxDiceCount)
+	}
+	return terms, nil
+}
+
+type diceParser struct {
+	s   string
+	pos int
+}
+
+func (p *diceParser) errorf(format string, args ...any) error {
+	return fmt.Errorf("invalid dice expression at position %d: %s", p.pos+1, fmt.Sprintf(format, args...))
+}
+
+func (p *diceParser) accept(prefix string) bool {
+	if strings.HasPrefix(p.s[p.pos:], prefix) {
+		p.pos += len(prefix)
+		return true
+	}
+	return false
+}
+
+// number parses a decimal integer, reporting ok=false if there is none.
+func (p *diceParser) number() (n int, ok bool, err error) {
+	start := p.pos
+	for p.pos < len(p.s) && p.s[p.pos] >= '0' && p.s[p.pos] <= '9' {
+		p.pos++
+	}
+	if start == p.pos {
+		return 0, false, nil
+	}
+	n, err = strconv.Atoi(p.s[start:p.pos])
+	if err != nil {
+		p.pos = start
+		return 0, false, p.errorf("number %q is out of range", p.s[start:])
+	}
+	return n, true, nil
+}
+
+func (p *diceParser) term() (diceTerm, error) {
+	n, hasCount, err := p.number()
+	if err != nil {
+		return diceTerm{}, err
+	}
+	if !p.accept("d") {
+		if !hasCount {
+			return diceTerm{}, p.errorf("expected a number or a dice group like 2d6")
+		}
+		if n > maxDiceModifier {
+			return diceTerm{}, p.errorf("constant %d is larger than %d", n, maxDiceModifier)
+		}
+		return diceTerm{constant: n}, nil
+	}
+
+	t := diceTerm{count: 1}
+	if hasCount {
+		t.count = n
+	}
+	if t.count < 1 {
+		return diceTerm{}, p.errorf("dice count must be at least 1")
+	}
+	if p.accept("%") {
+		t.sides = 100
+	} else {
+		sides, ok, err := p.number()
+		if err != nil {
+			return diceTerm{}, err
+		}
+		if !ok {
+			return diceTerm{}, p.errorf("expected the number of sides after 'd'")
+		}
+		t.sides = sides
+	}
+	if t.sides < 2 || t.sides > maxDiceSides {
+		return diceTerm{}, p.errorf("dice must have between 2 and %d sides", maxDiceSides)
+	}
+	t.exploding = p.accept("!")
+
+	for _, m := range []struct {
+		prefix        string
+		highest, drop bool
+	}{
+		{"kh", true, false}, {"kl", false, false},
+		{"dh", true, true}, {"dl", false, true},
+		{"k", true, false}, {"d", false, true},
+	} {
+		if !p.accept(m.prefix) {
+			continue
+		}
+		n, ok, err := p.number()
+		if err != nil {
+			return diceTerm{}, err
+		}
+		if !ok {
+			n = 1
+		}
+		if n < 0 || n > t.count {
+			return diceTerm{}, p.errorf("cannot keep or drop %d of %d dice", n, t.count)
+		}
+		t.keep = &diceKeep{highest: m.highest, drop: m.drop, n: n}
+		break
+	}
+	return t, nil
+}
+
+// notation renders the group in canonical short form, e.g. "4d6dl1".
+func (t diceTerm) notation() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%dd%d", t.count, t.sides)
+	if t.exploding {
+		b.WriteString("!")
+	}
+	if k := t.keep; k != nil {
+		op, end := "k", "l"
+		if k.drop {
+			op = "d"
+		}
+		if k.highest {
+			end = "h"
+		}
+		fmt.Fprintf(&b, "%s%s%d", op, end, k.n)
+	}
+	return b.String()
+}
+
+// rollDice evaluates a parsed expression, drawing each die from roll.
+func rollDice(expr string, terms []diceTerm, roll func(sides int) (int, error)) (diceResult, error) {
+	res := diceResult{Expression: expr}
+	rolled := 0
+	for _, t := range terms {
+		if t.count == 0 {
+			res.Modifier += t.sign * t.constant
+			continue
+		}
+
+		g := diceGroupResult{Notation: t.notation()}
+		for i := 0; i < t.count; i++ {
+			for {
+				rolled++
+				if rolled > maxDiceCount {
+					return diceResult{}, fmt.Errorf("too many dice, exploding dice went over the limit of %d", maxDiceCount)
+				}
+				r, err := roll(t.sides)
+				if err != nil {
+					return diceResult{}, err
+				}
+				g.Rolls = append(g.Rolls, r)
+				if !t.exploding || r != t.sides {
+					break
+				}
+			}
+		}
+
+		g.Kept = slices.Clone(g.Rolls)
+		if k := t.keep; k != nil {
+			dropN, dropHighest := k.n, k.highest
+			if !k.drop {
+				dropN, dropHighest = len(g.Rolls)-k.n, !k.highest
+			}
+			sorted := slices.Clone(g.Rolls)
+			slices.Sort(sorted)
+			if dropHighest {
+				slices.Reverse(sorted)
+			}
+			g.Dropped = sorted[:dropN]
+			for _, d := range g.Dropped {
+				i := slices.Index(g.Kept, d)
+				g.Kept = slices.Delete(g.Kept, i, i+1)
+			}
+		}
+		for _, r := range g.Kept {
+			g.Subtotal += r
+		}
+		g.Subtotal *= t.sign
+		res.Total += g.Subtotal
+		res.Groups = append(res.Groups, g)
+	}
+	res.Total += res.Modifier
+	return res, nil
+}
+
+type rollDieToolArgs struct {
+	Sides int `json:"sides" jsonschema:"The number of sides on the die." validate:"min=2,max=1000"`
+}
+
+// RollDieTool is the handler of the roll_die tool. It rolls one die with
+// args.Sides sides and records the roll in the session's audit trail.
+func (r *Roller) RollDieTool(tc tool.Context, args rollDieToolArgs) (int, error) {
+	rec := r.begin(tc, "roll_die")
+	result, err := rec.roll(args.Sides)
+	if err != nil {
+		return 0, err
+	}
+	return result, rec.commit()
+}
+
+type rollDiceToolArgs struct {
+	Expression string `json:"expression" jsonschema:"Dice notation such as 3d6+2, 4d6 drop lowest, 2d20 keep highest or 3d6! for exploding dice." validate:"min=1,max=100"`
+}
+
+// RollDiceTool is the handler of the roll_dice tool. It rolls the dice of
+// args.Expression, reports each die and the total, and records the rolls in
+// the session's audit trail. Malformed expressions are rejected before any
+// die is rolled.
+func (r *Roller) RollDiceTool(tc tool.Context, args rollDiceToolArgs) (diceResult, error) {
+	terms, err := parseDice(args.Expression)
+	if err != nil {
+		return diceResult{}, err
+	}
+	rec := r.begin(tc, "roll_dice")
+	res, err := rollDice(args.Expression, terms, rec.roll)
+	if err != nil {
+		return diceResult{}, err
+	}
+	return res, rec.commit()
+}
@@ -0,0 +1,95 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dice
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseDice(t *testing.T) {
+	tests := []struct {
+		expr string
+		want []diceTerm
+	}{
+		{"3d6+2", []diceTerm{{sign: 1, count: 3, sides: 6}, {sign: 1, constant: 2}}},
+		{"d20", []diceTerm{{sign: 1, count: 1, sides: 20}}},
+		{"d%", []diceTerm{{sign: 1, count: 1, sides: 100}}},
+		{"-1d4 - 2", []diceTerm{{sign: -1, count: 1, sides: 4}, {sign: -1, constant: 2}}},
+		{"3d6!", []diceTerm{{sign: 1, count: 3, sides: 6, exploding: true}}},
+		{"4d6 Drop Lowest", []diceTerm{{sign: 1, count: 4, sides: 6, keep: &diceKeep{drop: true, n: 1}}}},
+		{"4d6dl1", []diceTerm{{sign: 1, count: 4, sides: 6, keep: &diceKeep{drop: true, n: 1}}}},
+		{"2d20kh1", []diceTerm{{sign: 1, count: 2, sides: 20, keep: &diceKeep{highest: true, n: 1}}}},
+		{"2d20kl", []diceTerm{{sign: 1, count: 2, sides: 20, keep: &diceKeep{n: 1}}}},
+		{"5d10k3", []diceTerm{{sign: 1, count: 5, sides: 10, keep: &diceKeep{highest: true, n: 3}}}},
+		{"3d8dh2", []diceTerm{{sign: 1, count: 3, sides: 8, keep: &diceKeep{highest: true, drop: true, n: 2}}}},
+		{"3d6!d1+1d4", []diceTerm{
+			{sign: 1, count: 3, sides: 6, exploding: true, keep: &diceKeep{drop: true, n: 1}},
+			{sign: 1, count: 1, sides: 4},
+		}},
+	}
+	for _, tt := range tests {
+		got, err := parseDice(tt.expr)
+		if err != nil {
+			t.Errorf("parseDice(%q) error = %v", tt.expr, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseDice(%q) = %+v, want %+v", tt.expr, got, tt.want)
+		}
+	}
+}
+
+func TestParseDiceErrors(t *testing.T) {
+	tests := []struct {
+		expr    string
+		wantErr string
+	}{
+		// Malformed expressions.
+		{"", "empty dice expression"},
+		{"   ", "empty dice expression"},
+		{"abc", "at position 1: expected a number or a dice group"},
+		{"2d", "at position 3: expected the number of sides"},
+		{"2d6*3", "at position 4: expected + or -"},
+		{"2d6+", "at position 5: expected a number or a dice group"},
+		{"2d6++1", "at position 5: expected a number or a dice group"},
+		{"0d6", "dice count must be at least 1"},
+		{"2d6x", "expected + or -"},
+		// Limits.
+		{"2d1", "between 2 and 1000 sides"},
+		{"1d1001", "between 2 and 1000 sides"},
+		{"1001", "constant 1001 is larger than 1000"},
+		{"101d6", "at most 100 can be rolled"},
+		{"60d6+41d6", "at most 100 can be rolled"},
+		{"2d6kh3", "cannot keep or drop 3 of 2 dice"},
+		{"1+1+1+1+1+1+1+1+1+1+1", "at most 10 are allowed"},
+		{"99999999999999999999d6", "out of range"},
+	}
+	for _, tt := range tests {
+		_, err := parseDice(tt.expr)
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("parseDice(%q) error = %v, want %q", tt.expr, err, tt.wantErr)
+		}
+	}
+}
+
+func TestParseDiceLimits(t *testing.T) {
+	for _, expr := range []string{"100d6", "1d1000", "1000", "-1000", "50d6+50d6", "1+1+1+1+1+1+1+1+1+1"} {
+		if _, err := parseDice(expr); err != nil {
+			t.Errorf("parseDice(%q) error = %v, want it accepted at the limit", expr, err)
+		}
+	}
+}
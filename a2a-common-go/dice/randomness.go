@@ -0,0 +1,258 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dice
+
+import (
+	cryptorand "crypto/rand"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"math/big"
+	"math/rand/v2"
+	"os"
+	"strconv"
+	"strings"
+
+	"google.golang.org/adk/session"
+	"google.golang.org/adk/tool"
+)
+
+// --- Auditable Randomness ---
+
+const (
+	// rollSeedEnv selects deterministic, replayable rolls when set.
+	rollSeedEnv = "ROLL_SEED"
+
+	// rollAuditKeyPrefix prefixes the session state keys holding the roll
+	// audit trail. Each tool call is recorded under its own key, named after
+	// the sequence number of its first roll and its call ID, so entries are
+	// never overwritten, not even by calls that ran in parallel.
+	rollAuditKeyPrefix = "roll_audit:"
+	rollNextSeqKey     = rollAuditKeyPrefix + "next_seq"
+)
+
+// Roll sources recorded in the audit trail.
+const (
+	rollSourceSeeded = "seeded"
+	rollSourceCrypto = "crypto"
+)
+
+// Roller is the random source used by roll_agent's tools.
+//
+// Without a seed every roll comes from crypto/rand. With a seed, roll number
+// seq of a session is a pure function of (seed, session ID, seq, sides), see
+// seededRoll, so a recorded session can be replayed and each roll verified.
+type Roller struct {
+	seed *uint64
+}
+
+// newDieRoller creates a roller that uses seed when it is non-nil and
+// crypto/rand otherwise.
+func newDieRoller(seed *uint64) *Roller {
+	return &Roller{seed: seed}
+}
+
+// RollerFromEnv creates a roller configured by the ROLL_SEED variable.
+func RollerFromEnv() (*Roller, error) {
+	v := os.Getenv(rollSeedEnv)
+	if v == "" {
+		return newDieRoller(nil), nil
+	}
+	seed, err := strconv.ParseUint(v, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s %q: %w", rollSeedEnv, v, err)
+	}
+	return newDieRoller(&seed), nil
+}
+
+// Source returns where the roller's rolls come from, as recorded in the
+// audit trail: "seeded" when ROLL_SEED is set, "crypto" otherwise.
+func (r *Roller) Source() string {
+	if r.seed != nil {
+		return rollSourceSeeded
+	}
+	return rollSourceCrypto
+}
+
+// Concurrent reports whether tool calls may roll concurrently. Seeded rolls
+// depend on the order in which calls draw their sequence numbers, so a
+// session can only be replayed if its calls ran one after another.
+func (r *Roller) Concurrent() bool {
+	return r.seed == nil
+}
+
+// seededRoll returns roll number seq of a session for the given seed. It is
+// what a seeded Roller uses, and what a verifier recomputes on replay.
+func seededRoll(seed uint64, sessionID string, seq uint64, sides int) int {
+	h := fnv.New64a()
+	h.Write([]byte(sessionID))
+	return rand.New(rand.NewPCG(seed, h.Sum64()^seq)).IntN(sides) + 1
+}
+
+// rollAuditEntry is the audit record of one tool call.
+type rollAuditEntry struct {
+	Tool      string        `json:"tool"`
+	CallID    string        `json:"call_id"`
+	SessionID string        `json:"session_id"`
+	Source    string        `json:"source"`
+	Seed      *uint64       `json:"seed,omitempty"`
+	Rolls     []auditedRoll `json:"rolls"`
+}
+
+type auditedRoll struct {
+	Seq    uint64 `json:"seq"`
+	Sides  int    `json:"sides"`
+	Result int    `json:"result"`
+}
+
+// rollRecorder draws the dice of a single tool call and records them.
+type rollRecorder struct {
+	roller *Roller
+	tc     tool.Context
+	entry  rollAuditEntry
+	next   *uint64 // next sequence number, read from the state on first use
+}
+
+// begin starts recording the rolls of the tool call behind tc.
+func (r *Roller) begin(tc tool.Context, toolName string) *rollRecorder {
+	return &rollRecorder{
+		roller: r,
+		tc:     tc,
+		entry: rollAuditEntry{
+			Tool:      toolName,
+			CallID:    tc.FunctionCallID(),
+			SessionID: tc.SessionID(),
+			Source:    r.Source(),
+			Seed:      r.seed,
+		},
+	}
+}
+
+// roll draws a single die with the given number of sides.
+func (rec *rollRecorder) roll(sides int) (int, error) {
+	seq, err := rec.nextSeq()
+	if err != nil {
+		return 0, err
+	}
+	var result int
+	if seed := rec.roller.seed; seed != nil {
+		result = seededRoll(*seed, rec.entry.SessionID, seq, sides)
+	} else {
+		n, err := cryptorand.Int(cryptorand.Reader, big.NewInt(int64(sides)))
+		if err != nil {
+			return 0, fmt.Errorf("failed to read random number: %w", err)
+		}
+		result = int(n.Int64()) + 1
+	}
+	rec.entry.Rolls = append(rec.entry.Rolls, auditedRoll{Seq: seq, Sides: sides, Result: result})
+	return result, nil
+}
+
+// commit writes the audit entry and the next sequence number to the session
+// state. Nothing is written if no dice were rolled.
+func (rec *rollRecorder) commit() error {
+	if len(rec.entry.Rolls) == 0 {
+		return nil
+	}
+	data, err := json.Marshal(rec.entry)
+	if err != nil {
+		return fmt.Errorf("failed to encode roll audit entry: %w", err)
+	}
+	var value map[string]any
+	if err := json.Unmarshal(data, &value); err != nil {
+		return fmt.Errorf("failed to encode roll audit entry: %w", err)
+	}
+	state := rec.tc.State()
+	key := fmt.Sprintf("%s%08d:%s", rollAuditKeyPrefix, rec.entry.Rolls[0].Seq, rec.entry.CallID)
+	if err := state.Set(key, value); err != nil {
+		return fmt.Errorf("failed to record rolls: %w", err)
+	}
+	last := rec.entry.Rolls[len(rec.entry.Rolls)-1].Seq
+	if err := state.Set(rollNextSeqKey, last+1); err != nil {
+		return fmt.Errorf("failed to record roll sequence: %w", err)
+	}
+	return nil
+}
+
+// nextSeq hands out the next sequence number of the session. The sequence
+// lives in the session state, where commit stores it, and is read once per
+// tool call. Calls running in parallel may draw the same numbers, which is
+// why seeded rolls, the only ones depending on them, never do.
+func (rec *rollRecorder) nextSeq() (uint64, error) {
+	if rec.next == nil {
+		next, err := storedNextSeq(rec.tc.State())
+		if err != nil {
+			return 0, fmt.Errorf("failed to read roll sequence: %w", err)
+		}
+		rec.next = &next
+	}
+	seq := *rec.next
+	*rec.next++
+	return seq, nil
+}
+
+// storedNextSeq returns the first sequence number not used in the audit
+// trail. The next_seq key alone is not enough: calls that ran in parallel
+// each write it, and the last write is not necessarily the highest.
+func storedNextSeq(state session.State) (uint64, error) {
+	var next uint64
+	for key, v := range state.All() {
+		if !strings.HasPrefix(key, rollAuditKeyPrefix) {
+			continue
+		}
+		if key == rollNextSeqKey {
+			n, err := toUint64(v)
+			if err != nil {
+				return 0, err
+			}
+			next = max(next, n)
+			continue
+		}
+		m, ok := v.(map[string]any)
+		if !ok {
+			continue
+		}
+		rolls, _ := m["rolls"].([]any)
+		for _, roll := range rolls {
+			if r, ok := roll.(map[string]any); ok {
+				n, err := toUint64(r["seq"])
+				if err != nil {
+					return 0, err
+				}
+				next = max(next, n+1)
+			}
+		}
+	}
+	return next, nil
+}
+
+// toUint64 converts a number read back from session state, which may have
+// been round-tripped through JSON by the session service.
+func toUint64(v any) (uint64, error) {
+	switch n := v.(type) {
+	case uint64:
+		return n, nil
+	case int:
+		return uint64(n), nil
+	case int64:
+		return uint64(n), nil
+	case float64:
+		return uint64(n), nil
+	case json.Number:
+		return strconv.ParseUint(n.String(), 10, 64)
+	default:
+		return 0, fmt.Errorf("unexpected type %T", v)
+	}
+}
@@ -0,0 +1,109 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dice
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"google.golang.org/adk/tool"
+
+	"a2a-common-go/internal/testutil"
+)
+
+// auditEntries returns the roll audit entries recorded in tc's state.
+func auditEntries(t *testing.T, tc tool.Context) map[string]map[string]any {
+	t.Helper()
+	entries := make(map[string]map[string]any)
+	for key, v := range tc.State().All() {
+		if strings.HasPrefix(key, rollAuditKeyPrefix) && key != rollNextSeqKey {
+			entries[key] = v.(map[string]any)
+		}
+	}
+	return entries
+}
+
+func TestSeededRollsReplay(t *testing.T) {
+	t.Setenv(rollSeedEnv, "42")
+
+	roll := func() (diceResult, tool.Context) {
+		t.Helper()
+		r, err := RollerFromEnv()
+		if err != nil {
+			t.Fatalf("RollerFromEnv() error = %v", err)
+		}
+		tc := testutil.ToolContext(t.Context())
+		res, err := r.RollDiceTool(tc, rollDiceToolArgs{Expression: "4d6+1d20"})
+		if err != nil {
+			t.Fatalf("RollDiceTool() error = %v", err)
+		}
+		return res, tc
+	}
+
+	first, tc := roll()
+	second, _ := roll()
+	if !reflect.DeepEqual(first, second) {
+		t.Errorf("rolls with the same seed differ: %+v and %+v", first, second)
+	}
+
+	// Every audited roll can be recomputed from the seed.
+	entries := auditEntries(t, tc)
+	entry, ok := entries[rollAuditKeyPrefix+"00000000:call-1"]
+	if !ok || len(entries) != 1 {
+		t.Fatalf("audit entries = %v, want one for call-1", entries)
+	}
+	if entry["source"] != rollSourceSeeded || entry["seed"] != 42.0 {
+		t.Errorf("audit entry = %v, want seeded with seed 42", entry)
+	}
+	rolls := entry["rolls"].([]any)
+	if len(rolls) != 5 {
+		t.Fatalf("audited rolls = %v, want 5", rolls)
+	}
+	for i, v := range rolls {
+		roll := v.(map[string]any)
+		want := seededRoll(42, "session-1", uint64(i), int(roll["sides"].(float64)))
+		if roll["seq"] != float64(i) || roll["result"] != float64(want) {
+			t.Errorf("audited roll %d = %v, want seq %d and result %d", i, roll, i, want)
+		}
+	}
+}
+
+func TestRollSequenceInState(t *testing.T) {
+	r := newDieRoller(nil)
+	// The session already holds 3 rolls, from a previous run.
+	tc := testutil.ToolContext(t.Context(), map[string]any{rollNextSeqKey: 3.0})
+
+	for range 2 {
+		if _, err := r.RollDiceTool(tc, rollDiceToolArgs{Expression: "2d6"}); err != nil {
+			t.Fatalf("RollDiceTool() error = %v", err)
+		}
+	}
+
+	if got, err := tc.State().Get(rollNextSeqKey); err != nil || got != uint64(7) {
+		t.Errorf("next sequence number = %v, %v, want 7", got, err)
+	}
+	entries := auditEntries(t, tc)
+	for _, key := range []string{"00000003:call-1", "00000005:call-1"} {
+		entry, ok := entries[rollAuditKeyPrefix+key]
+		if !ok {
+			t.Errorf("audit entries = %v, want %s", entries, key)
+			continue
+		}
+		if entry["source"] != rollSourceCrypto || len(entry["rolls"].([]any)) != 2 {
+			t.Errorf("audit entry %s = %v, want 2 crypto rolls", key, entry)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package flagutil holds the command line helpers shared by the programs.
+package flagutil
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"google.golang.org/adk/agent"
+)
+
+// StreamingModeEnv is the default of --streaming.
+const StreamingModeEnv = "STREAMING_MODE"
+
+// Parse parses the command line, which must only contain flags.
+func Parse(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return fmt.Errorf("unexpected arguments: %s", strings.Join(fs.Args(), " "))
+	}
+	return nil
+}
+
+// ParseStreamingMode parses a streaming mode: none or sse.
+func ParseStreamingMode(s string) (agent.StreamingMode, error) {
+	switch m := agent.StreamingMode(strings.ToLower(strings.TrimSpace(s))); m {
+	case agent.StreamingModeNone, agent.StreamingModeSSE:
+		return m, nil
+	}
+	return "", fmt.Errorf("invalid streaming mode %q: must be none or sse", s)
+}
+
+// StreamingModeVar registers --streaming on fs, setting mode, which defaults
+// to $STREAMING_MODE, else none. usage tells what the mode applies to.
+func StreamingModeVar(fs *flag.FlagSet, mode *agent.StreamingMode, usage string) error {
+	*mode = agent.StreamingModeNone
+	if v := os.Getenv(StreamingModeEnv); v != "" {
+		m, err := ParseStreamingMode(v)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", StreamingModeEnv, err)
+		}
+		*mode = m
+	}
+	fs.Func("streaming", usage+": none or sse. Defaults to $"+StreamingModeEnv+", else none.", func(s string) error {
+		m, err := ParseStreamingMode(s)
+		if err != nil {
+			return err
+		}
+		*mode = m
+		return nil
+	})
+	return nil
+}
@@ -0,0 +1,170 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package testutil provides helpers shared by the tests of the other
+// packages.
+package testutil
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"iter"
+	"maps"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"google.golang.org/adk/session"
+	"google.golang.org/adk/tool"
+)
+
+// toolContext is a tool.Context backed by a context.Context and an
+// in-memory session state. Changes to the state are recorded in its actions,
+// as in a real invocation.
+type toolContext struct {
+	tool.Context
+	ctx     context.Context
+	actions *session.EventActions
+
+	mu    sync.Mutex
+	state map[string]any
+}
+
+// ToolContext returns a tool.Context for calling tools directly. Its
+// context.Context methods, SessionID, FunctionCallID, Actions, State and
+// ReadonlyState may be used; the session state starts out as state.
+func ToolContext(ctx context.Context, state ...map[string]any) tool.Context {
+	c := &toolContext{
+		ctx:     ctx,
+		actions: &session.EventActions{StateDelta: make(map[string]any)},
+		state:   make(map[string]any),
+	}
+	for _, s := range state {
+		maps.Copy(c.state, s)
+	}
+	return c
+}
+
+func (c *toolContext) Deadline() (time.Time, bool) { return c.ctx.Deadline() }
+func (c *toolContext) Done() <-chan struct{}       { return c.ctx.Done() }
+func (c *toolContext) Err() error                  { return c.ctx.Err() }
+func (c *toolContext) Value(key any) any           { return c.ctx.Value(key) }
+
+func (c *toolContext) SessionID() string                    { return "session-1" }
+func (c *toolContext) FunctionCallID() string               { return "call-1" }
+func (c *toolContext) Actions() *session.EventActions       { return c.actions }
+func (c *toolContext) State() session.State                 { return toolState{c} }
+func (c *toolContext) ReadonlyState() session.ReadonlyState { return toolState{c} }
+
+// toolState is the session state of a toolContext.
+type toolState struct {
+	c *toolContext
+}
+
+func (s toolState) Get(key string) (any, error) {
+	s.c.mu.Lock()
+	defer s.c.mu.Unlock()
+	v, ok := s.c.state[key]
+	if !ok {
+		return nil, session.ErrStateKeyNotExist
+	}
+	return v, nil
+}
+
+func (s toolState) Set(key string, value any) error {
+	s.c.mu.Lock()
+	defer s.c.mu.Unlock()
+	s.c.state[key] = value
+	s.c.actions.StateDelta[key] = value
+	return nil
+}
+
+func (s toolState) All() iter.Seq2[string, any] {
+	s.c.mu.Lock()
+	state := maps.Clone(s.c.state)
+	s.c.mu.Unlock()
+	return maps.All(state)
+}
+
+// Keys are signing keys generated for a test, with their JWKS file listing
+// them as rsa-1 and ec-1, and an encryption key enc-1.
+type Keys struct {
+	rsa      *rsa.PrivateKey
+	ec       *ecdsa.PrivateKey
+	JWKSFile string
+}
+
+// NewKeys generates the keys and writes their JWKS file.
+func NewKeys(t *testing.T) *Keys {
+	t.Helper()
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b64 := base64.RawURLEncoding.EncodeToString
+	jwks, err := json.Marshal(map[string]any{"keys": []map[string]string{
+		{"kty": "RSA", "kid": "rsa-1", "use": "sig", "alg": "RS256", "n": b64(rsaKey.N.Bytes()), "e": b64(big.NewInt(int64(rsaKey.E)).Bytes())},
+		{"kty": "EC", "kid": "ec-1", "crv": "P-256", "x": b64(ecKey.X.FillBytes(make([]byte, 32))), "y": b64(ecKey.Y.FillBytes(make([]byte, 32)))},
+		{"kty": "RSA", "kid": "enc-1", "use": "enc"},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(path, jwks, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return &Keys{rsa: rsaKey, ec: ecKey, JWKSFile: path}
+}
+
+// Sign returns a JWT with the claims, signed with the key of the algorithm,
+// RS256 or ES256.
+func (k *Keys) Sign(t *testing.T, alg, kid string, claims map[string]any) string {
+	t.Helper()
+	b64 := base64.RawURLEncoding.EncodeToString
+	header, _ := json.Marshal(map[string]string{"alg": alg, "kid": kid, "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	input := b64(header) + "." + b64(payload)
+	digest := sha256.Sum256([]byte(input))
+	var sig []byte
+	switch alg {
+	case "RS256":
+		s, err := rsa.SignPKCS1v15(rand.Reader, k.rsa, crypto.SHA256, digest[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		sig = s
+	case "ES256":
+		r, s, err := ecdsa.Sign(rand.Reader, k.ec, digest[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		sig = append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+	}
+	return input + "." + b64(sig)
+}
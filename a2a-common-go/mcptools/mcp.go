@@ -0,0 +1,238 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package mcptools gives agents the tools of MCP servers.
+package mcptools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/exec"
+	"sync"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"google.golang.org/adk/tool"
+	"google.golang.org/adk/tool/mcptoolset"
+)
+
+// --- MCP Tool Bridge ---
+//
+// Agents can use the tools of Model Context Protocol servers. The servers,
+// and which agent uses which server, are read from the JSON file named by
+// MCP_CONFIG:
+//
+//	{
+//	  "servers": {
+//	    "filesystem": {"command": "mcp-filesystem", "args": ["/srv/docs"]},
+//	    "tickets": {
+//	      "url": "https://tickets.example.com/mcp",
+//	      "headers": {"Authorization": "Bearer ..."},
+//	      "tools": ["search_tickets"]
+//	    }
+//	  },
+//	  "agents": {"root_agent": ["filesystem", "tickets"]}
+//	}
+//
+// Servers with a command are started as child processes and spoken to over
+// stdio; servers with a url use the streamable HTTP transport. "tools"
+// optionally restricts which tools of a server are exposed.
+//
+// Each server becomes an ADK MCP toolset, which connects to the server when
+// the agent first lists its tools. The connections stay open until Close.
+
+// configEnv names the MCP configuration file.
+const configEnv = "MCP_CONFIG"
+
+// Config is the content of the MCP configuration file.
+type Config struct {
+	Servers map[string]serverConfig `json:"servers"`
+	// Agents lists the servers each agent uses, by agent name.
+	Agents map[string][]string `json:"agents"`
+
+	mu     sync.Mutex
+	conns  map[*connection]struct{}
+	closed bool
+}
+
+// serverConfig describes how to reach one MCP server.
+type serverConfig struct {
+	// Command and Args start a server speaking MCP over stdio.
+	Command string            `json:"command,omitempty"`
+	Args    []string          `json:"args,omitempty"`
+	Env     map[string]string `json:"env,omitempty"`
+	// URL is the endpoint of a streamable HTTP server.
+	URL     string            `json:"url,omitempty"`
+	Headers map[string]string `json:"headers,omitempty"`
+	// Tools, if set, is the list of tools exposed to the agent.
+	Tools []string `json:"tools,omitempty"`
+}
+
+// ConfigFromEnv loads the file named by MCP_CONFIG. Without it no agent
+// uses MCP tools.
+func ConfigFromEnv() (*Config, error) {
+	path := os.Getenv(configEnv)
+	if path == "" {
+		return &Config{}, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read MCP config: %w", err)
+	}
+	var cfg Config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse MCP config %s: %w", path, err)
+	}
+	for agentName, servers := range cfg.Agents {
+		for _, name := range servers {
+			if _, ok := cfg.Servers[name]; !ok {
+				return nil, fmt.Errorf("invalid MCP config %s: agent %q uses unknown server %q", path, agentName, name)
+			}
+		}
+	}
+	for name, s := range cfg.Servers {
+		if (s.Command == "") == (s.URL == "") {
+			return nil, fmt.Errorf("invalid MCP config %s: server %q needs either a command or a url", path, name)
+		}
+	}
+	return &cfg, nil
+}
+
+// ToolsetsFor returns a toolset for each server the agent uses.
+func (c *Config) ToolsetsFor(agentName string) ([]tool.Toolset, error) {
+	var toolsets []tool.Toolset
+	for _, name := range c.Agents[agentName] {
+		server := c.Servers[name]
+		var filter tool.Predicate
+		if len(server.Tools) > 0 {
+			filter = tool.StringPredicate(server.Tools)
+		}
+		ts, err := mcptoolset.New(mcptoolset.Config{
+			Transport:  &transport{name: name, server: server, cfg: c},
+			ToolFilter: filter,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create toolset of MCP server %q: %w", name, err)
+		}
+		log.Printf("Agent %q uses the tools of MCP server %q", agentName, name)
+		toolsets = append(toolsets, ts)
+	}
+	return toolsets, nil
+}
+
+// Close closes the connections to the MCP servers, which stops the servers
+// started as child processes. Toolsets cannot connect anymore afterwards.
+func (c *Config) Close() error {
+	c.mu.Lock()
+	conns := c.conns
+	c.conns = nil
+	c.closed = true
+	c.mu.Unlock()
+
+	var errs []error
+	for conn := range conns {
+		if err := conn.Connection.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("failed to close MCP server %q: %w", conn.name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// --- Transports ---
+
+// transport connects to one server with the go-sdk stdio or streamable
+// HTTP transport. A new transport is made for each connection, since a
+// command can only be started once, and the connection is kept so that
+// Close can close it.
+type transport struct {
+	name   string
+	server serverConfig
+	cfg    *Config
+}
+
+// Connect implements mcp.Transport.
+func (t *transport) Connect(ctx context.Context) (mcp.Connection, error) {
+	conn, err := t.server.transport().Connect(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to MCP server %q: %w", t.name, err)
+	}
+	tracked := &connection{Connection: conn, name: t.name, cfg: t.cfg}
+
+	t.cfg.mu.Lock()
+	defer t.cfg.mu.Unlock()
+	if t.cfg.closed {
+		conn.Close()
+		return nil, fmt.Errorf("failed to connect to MCP server %q: connections are closed", t.name)
+	}
+	if t.cfg.conns == nil {
+		t.cfg.conns = make(map[*connection]struct{})
+	}
+	t.cfg.conns[tracked] = struct{}{}
+	log.Printf("Connected to MCP server %q", t.name)
+	return tracked, nil
+}
+
+// transport returns the go-sdk transport for the server.
+func (s serverConfig) transport() mcp.Transport {
+	if s.Command != "" {
+		cmd := exec.Command(s.Command, s.Args...)
+		cmd.Env = os.Environ()
+		for k, v := range s.Env {
+			cmd.Env = append(cmd.Env, k+"="+v)
+		}
+		cmd.Stderr = os.Stderr
+		return &mcp.CommandTransport{Command: cmd}
+	}
+	client := http.DefaultClient
+	if len(s.Headers) > 0 {
+		client = &http.Client{Transport: &headerTransport{headers: s.Headers, base: http.DefaultTransport}}
+	}
+	return &mcp.StreamableClientTransport{Endpoint: s.URL, HTTPClient: client}
+}
+
+// connection is an open connection to a server. It forgets itself when
+// closed, by the session or by Config.Close.
+type connection struct {
+	mcp.Connection
+	name string
+	cfg  *Config
+}
+
+// Close implements mcp.Connection.
+func (c *connection) Close() error {
+	c.cfg.mu.Lock()
+	delete(c.cfg.conns, c)
+	c.cfg.mu.Unlock()
+	return c.Connection.Close()
+}
+
+// headerTransport adds the configured headers to the requests sent to an
+// HTTP server.
+type headerTransport struct {
+	headers map[string]string
+	base    http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	for k, v := range t.headers {
+		req.Header.Set(k, v)
+	}
+	return t.base.RoundTrip(req)
+}
@@ -0,0 +1,261 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mcptools
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/jsonschema-go/jsonschema"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"google.golang.org/adk/tool"
+
+	"a2a-common-go/internal/testutil"
+)
+
+// stubServerEnv makes the test binary act as a stub MCP server on stdio.
+const stubServerEnv = "MCP_STUB_SERVER"
+
+func TestMain(m *testing.M) {
+	if os.Getenv(stubServerEnv) == "1" {
+		if err := newStubServer().Run(context.Background(), &mcp.StdioTransport{}); err != nil {
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// newStubServer returns a tiny MCP server with three tools: echo returns
+// text, add returns structured content and fail reports a tool error.
+func newStubServer() *mcp.Server {
+	server := mcp.NewServer(&mcp.Implementation{Name: "stub", Version: "0.0.1"}, nil)
+	numbers := &jsonschema.Schema{Type: "object", Properties: map[string]*jsonschema.Schema{
+		"a": {Type: "number"},
+		"b": {Type: "number"},
+	}}
+	text := &jsonschema.Schema{Type: "object", Properties: map[string]*jsonschema.Schema{
+		"text": {Type: "string"},
+	}}
+	server.AddTool(&mcp.Tool{Name: "echo", Description: "Echoes text.", InputSchema: text}, func(ctx context.Context, req *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		var args struct{ Text string }
+		json.Unmarshal(req.Params.Arguments, &args)
+		return &mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: args.Text}}}, nil
+	})
+	server.AddTool(&mcp.Tool{Name: "add", Description: "Adds numbers.", InputSchema: numbers}, func(ctx context.Context, req *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		var args struct{ A, B float64 }
+		json.Unmarshal(req.Params.Arguments, &args)
+		return &mcp.CallToolResult{Content: []mcp.Content{}, StructuredContent: map[string]any{"sum": args.A + args.B}}, nil
+	})
+	server.AddTool(&mcp.Tool{Name: "fail", Description: "Always fails.", InputSchema: &jsonschema.Schema{Type: "object"}}, func(ctx context.Context, req *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return &mcp.CallToolResult{IsError: true, Content: []mcp.Content{&mcp.TextContent{Text: "boom"}}}, nil
+	})
+	return server
+}
+
+// stubHTTPServer serves the stub over streamable HTTP and checks that the
+// configured header is sent.
+func stubHTTPServer(t *testing.T) *httptest.Server {
+	server := newStubServer()
+	handler := mcp.NewStreamableHTTPHandler(func(*http.Request) *mcp.Server { return server }, nil)
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "Bearer stub" {
+			t.Errorf("Authorization = %q, want %q", got, "Bearer stub")
+		}
+		handler.ServeHTTP(w, r)
+	}))
+}
+
+func stdioServer() serverConfig {
+	return serverConfig{
+		Command: os.Args[0],
+		Env:     map[string]string{stubServerEnv: "1"},
+	}
+}
+
+// toolsOf lists the tools of the toolsets by name.
+func toolsOf(t *testing.T, ctx context.Context, toolsets []tool.Toolset) map[string]tool.Tool {
+	t.Helper()
+	byName := map[string]tool.Tool{}
+	for _, ts := range toolsets {
+		tools, err := ts.Tools(testutil.ToolContext(ctx))
+		if err != nil {
+			t.Fatalf("Tools() error = %v", err)
+		}
+		for _, tl := range tools {
+			byName[tl.Name()] = tl
+		}
+	}
+	return byName
+}
+
+type runnableTool interface {
+	tool.Tool
+	Run(ctx tool.Context, args any) (map[string]any, error)
+}
+
+func TestMCPTools(t *testing.T) {
+	httpServer := stubHTTPServer(t)
+	defer httpServer.Close()
+
+	transports := []struct {
+		name   string
+		server serverConfig
+	}{
+		{
+			name:   "stdio",
+			server: stdioServer(),
+		},
+		{
+			name:   "http",
+			server: serverConfig{URL: httpServer.URL, Headers: map[string]string{"Authorization": "Bearer stub"}},
+		},
+	}
+	calls := []struct {
+		tool    string
+		args    map[string]any
+		want    map[string]any
+		wantErr string
+	}{
+		{
+			tool: "echo",
+			args: map[string]any{"text": "hello"},
+			want: map[string]any{"output": "hello"},
+		},
+		{
+			tool: "add",
+			args: map[string]any{"a": 2, "b": 3},
+			want: map[string]any{"output": map[string]any{"sum": 5.0}},
+		},
+		{
+			tool:    "fail",
+			args:    map[string]any{},
+			wantErr: "boom",
+		},
+	}
+
+	for _, tt := range transports {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+
+			cfg := &Config{
+				Servers: map[string]serverConfig{"stub": tt.server},
+				Agents:  map[string][]string{"root_agent": {"stub"}},
+			}
+			defer cfg.Close()
+			toolsets, err := cfg.ToolsetsFor("root_agent")
+			if err != nil {
+				t.Fatalf("ToolsetsFor() error = %v", err)
+			}
+			byName := toolsOf(t, ctx, toolsets)
+			if len(byName) != 3 {
+				t.Fatalf("Tools() returned %d tools, want 3", len(byName))
+			}
+
+			for _, c := range calls {
+				got, err := byName[c.tool].(runnableTool).Run(testutil.ToolContext(ctx), c.args)
+				if c.wantErr != "" {
+					if err == nil || !strings.Contains(err.Error(), c.wantErr) {
+						t.Errorf("Run(%s) error = %v, want %q", c.tool, err, c.wantErr)
+					}
+					continue
+				}
+				if err != nil {
+					t.Fatalf("Run(%s) error = %v", c.tool, err)
+				}
+				if !reflect.DeepEqual(got, c.want) {
+					t.Errorf("Run(%s) = %v, want %v", c.tool, got, c.want)
+				}
+			}
+		})
+	}
+}
+
+func TestMCPToolsAllowlist(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	server := stdioServer()
+	server.Tools = []string{"add"}
+	cfg := &Config{
+		Servers: map[string]serverConfig{"stub": server},
+		Agents:  map[string][]string{"root_agent": {"stub"}},
+	}
+	defer cfg.Close()
+	toolsets, err := cfg.ToolsetsFor("root_agent")
+	if err != nil {
+		t.Fatalf("ToolsetsFor() error = %v", err)
+	}
+	if got := toolsOf(t, ctx, toolsets); len(got) != 1 || got["add"] == nil {
+		t.Errorf("Tools() = %v, want only add", got)
+	}
+
+	if toolsets, err := cfg.ToolsetsFor("roll_agent"); err != nil || len(toolsets) != 0 {
+		t.Errorf("ToolsetsFor(roll_agent) = %v, %v, want no toolsets", toolsets, err)
+	}
+}
+
+func TestMCPClose(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	cfg := &Config{
+		Servers: map[string]serverConfig{
+			"stub":    stdioServer(),
+			"missing": {Command: "/nonexistent/mcp-server"},
+		},
+		Agents: map[string][]string{"root_agent": {"stub", "missing"}},
+	}
+	toolsets, err := cfg.ToolsetsFor("root_agent")
+	if err != nil {
+		t.Fatalf("ToolsetsFor() error = %v", err)
+	}
+	echo := toolsOf(t, ctx, toolsets[:1])["echo"].(runnableTool)
+	if _, err := toolsets[1].Tools(testutil.ToolContext(ctx)); err == nil {
+		t.Errorf("Tools() of missing server error = nil, want error")
+	}
+	if len(cfg.conns) != 1 {
+		t.Errorf("open connections = %d, want 1", len(cfg.conns))
+	}
+
+	if err := cfg.Close(); err != nil {
+		t.Errorf("Close() error = %v", err)
+	}
+	if len(cfg.conns) != 0 {
+		t.Errorf("open connections after Close() = %d, want 0", len(cfg.conns))
+	}
+	if _, err := echo.Run(testutil.ToolContext(ctx), map[string]any{"text": "hello"}); err == nil {
+		t.Errorf("Run() after Close() error = nil, want error")
+	}
+
+	// A closed config does not connect again.
+	cfg2 := &Config{Servers: cfg.Servers, Agents: map[string][]string{"root_agent": {"stub"}}}
+	cfg2.Close()
+	toolsets, _ = cfg2.ToolsetsFor("root_agent")
+	if _, err := toolsets[0].Tools(testutil.ToolContext(ctx)); err == nil {
+		t.Errorf("Tools() after Close() error = nil, want error")
+	}
+	if len(cfg2.conns) != 0 {
+		t.Errorf("open connections after Close() = %d, want 0", len(cfg2.conns))
+	}
+}
@@ -0,0 +1,621 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package openapi generates tools calling the operations of HTTP APIs
+// described by OpenAPI documents.
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/google/jsonschema-go/jsonschema"
+	"google.golang.org/adk/tool"
+	"google.golang.org/adk/tool/functiontool"
+	"gopkg.in/yaml.v3"
+
+	"a2a-common-go/toolkit"
+)
+
+// --- OpenAPI Tools ---
+//
+// HTTP APIs described by an OpenAPI 3 document can be exposed to agents
+// without writing a tool per endpoint: every allowed operation becomes a
+// tool whose arguments are the operation's parameters plus, if it has one,
+// its JSON request body. The APIs, and which agent uses which API, are read
+// from the JSON file named by OPENAPI_CONFIG:
+//
+//	{
+//	  "apis": {
+//	    "inventory": {
+//	      "spec": "specs/inventory.yaml",
+//	      "base_url": "https://inventory.example.com/v1",
+//	      "headers": {"Authorization": "Bearer ${INVENTORY_TOKEN}"},
+//	      "operations": ["listItems", "getItem"],
+//	      "max_response_bytes": 8192
+//	    }
+//	  },
+//	  "agents": {"roll_agent": ["inventory"]}
+//	}
+//
+// "spec" is relative to the configuration file. Header values may refer to
+// environment variables, so secrets need not be stored in the file. Only the
+// operations listed in "operations", by operationId or generated tool name,
+// are exposed.
+
+const (
+	// configEnv names the OpenAPI tools configuration file.
+	configEnv = "OPENAPI_CONFIG"
+
+	defaultMaxResponseBytes = 16 * 1024
+	// maxSchemaDepth bounds the expansion of recursive schemas.
+	maxSchemaDepth = 8
+)
+
+// Config is the content of the OpenAPI tools configuration file.
+type Config struct {
+	APIs map[string]apiConfig `json:"apis"`
+	// Agents lists the APIs each agent uses, by agent name.
+	Agents map[string][]string `json:"agents"`
+
+	dir string // directory of the configuration file
+}
+
+// apiConfig describes one API.
+type apiConfig struct {
+	Spec    string            `json:"spec"`
+	BaseURL string            `json:"base_url,omitempty"`
+	Headers map[string]string `json:"headers,omitempty"`
+	// Operations is the allowlist of operations exposed as tools.
+	Operations       []string `json:"operations"`
+	MaxResponseBytes int      `json:"max_response_bytes,omitempty"`
+}
+
+// ConfigFromEnv loads the file named by OPENAPI_CONFIG. Without it no
+// agent uses OpenAPI tools.
+func ConfigFromEnv() (*Config, error) {
+	path := os.Getenv(configEnv)
+	if path == "" {
+		return &Config{}, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read OpenAPI config: %w", err)
+	}
+	cfg := &Config{dir: filepath.Dir(path)}
+	if err := json.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse OpenAPI config %s: %w", path, err)
+	}
+	for agentName, apis := range cfg.Agents {
+		for _, name := range apis {
+			if _, ok := cfg.APIs[name]; !ok {
+				return nil, fmt.Errorf("invalid OpenAPI config %s: agent %q uses unknown API %q", path, agentName, name)
+			}
+		}
+	}
+	for name, api := range cfg.APIs {
+		if api.Spec == "" {
+			return nil, fmt.Errorf("invalid OpenAPI config %s: API %q has no spec", path, name)
+		}
+		if len(api.Operations) == 0 {
+			return nil, fmt.Errorf("invalid OpenAPI config %s: API %q allows no operations", path, name)
+		}
+	}
+	return cfg, nil
+}
+
+// ToolsFor generates the tools of the APIs used by the agent.
+func (c *Config) ToolsFor(agentName string) ([]tool.Tool, error) {
+	var tools []tool.Tool
+	for _, name := range c.Agents[agentName] {
+		api := c.APIs[name]
+		path := api.Spec
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(c.dir, path)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read spec of API %q: %w", name, err)
+		}
+		apiTools, err := newOpenAPITools(data, api)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate tools for API %q: %w", name, err)
+		}
+		log.Printf("Agent %q uses %d operations of API %q", agentName, len(apiTools), name)
+		tools = append(tools, apiTools...)
+	}
+	return tools, nil
+}
+
+// --- OpenAPI Documents ---
+
+// document is the part of an OpenAPI 3 document the generator uses.
+type document struct {
+	OpenAPI string `json:"openapi"`
+	Servers []struct {
+		URL string `json:"url"`
+	} `json:"servers"`
+	Paths map[string]map[string]json.RawMessage `json:"paths"`
+
+	raw map[string]any // the whole document, to resolve $refs
+}
+
+type operation struct {
+	OperationID string       `json:"operationId"`
+	Summary     string       `json:"summary"`
+	Description string       `json:"description"`
+	Parameters  []parameter  `json:"parameters"`
+	RequestBody *requestBody `json:"requestBody"`
+}
+
+type parameter struct {
+	Ref         string         `json:"$ref"`
+	Name        string         `json:"name"`
+	In          string         `json:"in"`
+	Description string         `json:"description"`
+	Required    bool           `json:"required"`
+	Schema      map[string]any `json:"schema"`
+}
+
+type requestBody struct {
+	Ref         string `json:"$ref"`
+	Description string `json:"description"`
+	Required    bool   `json:"required"`
+	Content     map[string]struct {
+		Schema map[string]any `json:"schema"`
+	} `json:"content"`
+}
+
+// methods are the operations of a path item, in a stable order.
+var methods = []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"}
+
+// parseOpenAPI parses a JSON or YAML OpenAPI 3 document.
+func parseOpenAPI(data []byte) (*document, error) {
+	var raw map[string]any
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("malformed document: %w", err)
+	}
+	// Round-trip through JSON so the document can be decoded into structs.
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil, fmt.Errorf("malformed document: %w", err)
+	}
+	doc := &document{}
+	if err := json.Unmarshal(data, doc); err != nil {
+		return nil, fmt.Errorf("malformed document: %w", err)
+	}
+	if !strings.HasPrefix(doc.OpenAPI, "3.") {
+		return nil, fmt.Errorf("unsupported OpenAPI version %q, want 3.x", doc.OpenAPI)
+	}
+	if err := json.Unmarshal(data, &doc.raw); err != nil {
+		return nil, fmt.Errorf("malformed document: %w", err)
+	}
+	return doc, nil
+}
+
+// resolve returns the object a local reference such as
+// "#/components/schemas/Item" points to.
+func (d *document) resolve(ref string) (any, error) {
+	path, ok := strings.CutPrefix(ref, "#/")
+	if !ok {
+		return nil, fmt.Errorf("unsupported reference %q, only local references are supported", ref)
+	}
+	var v any = d.raw
+	for _, part := range strings.Split(path, "/") {
+		part = strings.NewReplacer("~1", "/", "~0", "~").Replace(part)
+		m, ok := v.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("unresolvable reference %q", ref)
+		}
+		if v, ok = m[part]; !ok {
+			return nil, fmt.Errorf("unresolvable reference %q", ref)
+		}
+	}
+	return v, nil
+}
+
+// resolveInto decodes the object behind ref into v.
+func (d *document) resolveInto(ref string, v any) error {
+	obj, err := d.resolve(ref)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// inlineSchema returns a copy of the schema with all references replaced by
+// what they point to. Recursive schemas are cut off at maxSchemaDepth.
+func (d *document) inlineSchema(schema any, depth int) (any, error) {
+	switch s := schema.(type) {
+	case map[string]any:
+		if ref, ok := s["$ref"].(string); ok {
+			if depth >= maxSchemaDepth {
+				return map[string]any{"type": "object"}, nil
+			}
+			target, err := d.resolve(ref)
+			if err != nil {
+				return nil, err
+			}
+			return d.inlineSchema(target, depth+1)
+		}
+		out := make(map[string]any, len(s))
+		for k, v := range s {
+			// Keep examples and extensions out of the declaration.
+			if k == "example" || k == "examples" || k == "xml" || strings.HasPrefix(k, "x-") {
+				continue
+			}
+			inlined, err := d.inlineSchema(v, depth)
+			if err != nil {
+				return nil, err
+			}
+			out[k] = inlined
+		}
+		return out, nil
+	case []any:
+		out := make([]any, len(s))
+		for i, v := range s {
+			inlined, err := d.inlineSchema(v, depth)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = inlined
+		}
+		return out, nil
+	default:
+		return schema, nil
+	}
+}
+
+// --- Tool Generation ---
+
+// toolNameChars matches the characters not allowed in tool names.
+var toolNameChars = regexp.MustCompile(`[^a-zA-Z0-9_]+`)
+
+// toolName derives a tool name from the operation ID or, if there is
+// none, from the method and path.
+func toolName(op *operation, method, path string) string {
+	name := op.OperationID
+	if name == "" {
+		name = method + "_" + path
+	}
+	name = strings.Trim(toolNameChars.ReplaceAllString(name, "_"), "_")
+	if len(name) > 64 {
+		name = name[:64]
+	}
+	return name
+}
+
+// newOpenAPITools generates the tools for the allowed operations of the
+// document.
+func newOpenAPITools(data []byte, cfg apiConfig) ([]tool.Tool, error) {
+	doc, err := parseOpenAPI(data)
+	if err != nil {
+		return nil, err
+	}
+	baseURL := cfg.BaseURL
+	if baseURL == "" && len(doc.Servers) > 0 {
+		baseURL = doc.Servers[0].URL
+	}
+	if _, err := url.Parse(baseURL); err != nil || baseURL == "" {
+		return nil, fmt.Errorf("invalid base URL %q: set base_url or a server in the document", baseURL)
+	}
+	maxBytes := cfg.MaxResponseBytes
+	if maxBytes <= 0 {
+		maxBytes = defaultMaxResponseBytes
+	}
+	headers := make(map[string]string, len(cfg.Headers))
+	for k, v := range cfg.Headers {
+		headers[k] = os.ExpandEnv(v)
+	}
+
+	paths := make([]string, 0, len(doc.Paths))
+	for p := range doc.Paths {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	var tools []tool.Tool
+	found := make(map[string]bool)
+	for _, path := range paths {
+		item := doc.Paths[path]
+		var shared []parameter
+		if raw, ok := item["parameters"]; ok {
+			if err := json.Unmarshal(raw, &shared); err != nil {
+				return nil, fmt.Errorf("malformed parameters of %s: %w", path, err)
+			}
+		}
+		for _, method := range methods {
+			raw, ok := item[method]
+			if !ok {
+				continue
+			}
+			var op operation
+			if err := json.Unmarshal(raw, &op); err != nil {
+				return nil, fmt.Errorf("malformed operation %s %s: %w", strings.ToUpper(method), path, err)
+			}
+			name := toolName(&op, method, path)
+			allowed := ""
+			for _, id := range []string{op.OperationID, name} {
+				if id != "" && slices.Contains(cfg.Operations, id) {
+					allowed = id
+				}
+			}
+			if allowed == "" {
+				continue
+			}
+			found[allowed] = true
+
+			t, err := newOpenAPITool(doc, name, method, path, &op, shared)
+			if err != nil {
+				return nil, fmt.Errorf("operation %s %s: %w", strings.ToUpper(method), path, err)
+			}
+			t.baseURL = strings.TrimSuffix(baseURL, "/")
+			t.headers = headers
+			t.maxBytes = maxBytes
+			tools = append(tools, t)
+		}
+	}
+	for _, id := range cfg.Operations {
+		if !found[id] {
+			return nil, fmt.Errorf("allowed operation %q is not in the document", id)
+		}
+	}
+	return tools, nil
+}
+
+// bodyArg is the argument holding the request body.
+const bodyArg = "body"
+
+func newOpenAPITool(doc *document, name, method, path string, op *operation, shared []parameter) (*Tool, error) {
+	t := &Tool{method: strings.ToUpper(method), path: path}
+	description := strings.TrimSpace(op.Summary + "\n\n" + op.Description)
+	if description == "" {
+		description = t.method + " " + path
+	}
+
+	// Operation parameters override path-level ones with the same name and
+	// location.
+	params := make(map[string]parameter)
+	var order []string
+	for _, list := range [][]parameter{shared, op.Parameters} {
+		for _, p := range list {
+			if p.Ref != "" {
+				if err := doc.resolveInto(p.Ref, &p); err != nil {
+					return nil, err
+				}
+			}
+			key := p.In + ":" + p.Name
+			if _, ok := params[key]; !ok {
+				order = append(order, key)
+			}
+			params[key] = p
+		}
+	}
+
+	properties := make(map[string]any)
+	var required []string
+	for _, key := range order {
+		p := params[key]
+		if p.In == "cookie" {
+			continue
+		}
+		if _, ok := properties[p.Name]; ok || p.Name == bodyArg {
+			return nil, fmt.Errorf("parameter %q clashes with another argument", p.Name)
+		}
+		schema, err := doc.inlineSchema(p.Schema, 0)
+		if err != nil {
+			return nil, err
+		}
+		s, _ := schema.(map[string]any)
+		if s == nil {
+			s = map[string]any{"type": "string"}
+		}
+		if p.Description != "" {
+			s["description"] = p.Description
+		}
+		properties[p.Name] = s
+		if p.Required || p.In == "path" {
+			required = append(required, p.Name)
+		}
+		t.params = append(t.params, p)
+	}
+
+	if body := op.RequestBody; body != nil {
+		if body.Ref != "" {
+			if err := doc.resolveInto(body.Ref, body); err != nil {
+				return nil, err
+			}
+		}
+		media, ok := body.Content["application/json"]
+		if !ok {
+			return nil, fmt.Errorf("only application/json request bodies are supported")
+		}
+		schema, err := doc.inlineSchema(media.Schema, 0)
+		if err != nil {
+			return nil, err
+		}
+		s, _ := schema.(map[string]any)
+		if s == nil {
+			s = map[string]any{"type": "object"}
+		}
+		if body.Description != "" {
+			s["description"] = body.Description
+		}
+		properties[bodyArg] = s
+		if body.Required {
+			required = append(required, bodyArg)
+		}
+		t.hasBody = true
+	}
+
+	schema := map[string]any{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	// The schema is built from the document as JSON; functiontool takes it
+	// as a jsonschema.Schema and checks the arguments against it.
+	data, err := json.Marshal(schema)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode schema: %w", err)
+	}
+	var inputSchema jsonschema.Schema
+	if err := json.Unmarshal(data, &inputSchema); err != nil {
+		return nil, fmt.Errorf("unsupported schema: %w", err)
+	}
+	t.FunctionTool, err = toolkit.NewValidatedTool(functiontool.Config{
+		Name:        name,
+		Description: description,
+		InputSchema: &inputSchema,
+	}, t.call)
+	if err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// Tool calls one operation of an HTTP API.
+type Tool struct {
+	toolkit.FunctionTool
+	method  string
+	path    string
+	params  []parameter
+	hasBody bool
+
+	baseURL  string
+	headers  map[string]string
+	maxBytes int
+}
+
+// ReadOnly reports whether the operation only reads, so calls can safely
+// run concurrently.
+func (t *Tool) ReadOnly() bool {
+	return t.method == http.MethodGet || t.method == http.MethodHead
+}
+
+// call performs the HTTP request. The response body is returned under
+// "body", decoded if it is JSON, and cut to the configured size.
+func (t *Tool) call(ctx tool.Context, values map[string]any) (map[string]any, error) {
+
+	path := t.path
+	query := url.Values{}
+	header := http.Header{}
+	for _, p := range t.params {
+		v, ok := values[p.Name]
+		if !ok {
+			// Required arguments are checked against the schema.
+			continue
+		}
+		switch p.In {
+		case "path":
+			path = strings.ReplaceAll(path, "{"+p.Name+"}", url.PathEscape(paramString(v)))
+		case "query":
+			if list, ok := v.([]any); ok {
+				for _, item := range list {
+					query.Add(p.Name, paramString(item))
+				}
+			} else {
+				query.Add(p.Name, paramString(v))
+			}
+		case "header":
+			header.Set(p.Name, paramString(v))
+		}
+	}
+	target := t.baseURL + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+
+	var body io.Reader
+	if v, ok := values[bodyArg]; ok && t.hasBody {
+		data, err := json.Marshal(v)
+		if err != nil {
+			return toolkit.Error(toolkit.CodeInvalidArguments, fmt.Sprintf("failed to encode body: %v", err)), nil
+		}
+		header.Set("Content-Type", "application/json")
+		body = bytes.NewReader(data)
+	}
+	return t.do(ctx, target, header, body)
+}
+
+func (t *Tool) do(ctx tool.Context, target string, header http.Header, body io.Reader) (map[string]any, error) {
+	req, err := http.NewRequestWithContext(ctx, t.method, target, body)
+	if err != nil {
+		return toolkit.Error(toolkit.CodeInvalidArguments, err.Error()), nil
+	}
+	req.Header = header
+	req.Header.Set("Accept", "application/json")
+	for k, v := range t.headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return toolkit.Error(toolkit.CodeFailed, fmt.Sprintf("%s %s failed: %v", t.method, t.path, err)), nil
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, int64(t.maxBytes)+1))
+	if err != nil {
+		return toolkit.Error(toolkit.CodeFailed, fmt.Sprintf("failed to read response of %s %s: %v", t.method, t.path, err)), nil
+	}
+	truncated := len(data) > t.maxBytes
+	if truncated {
+		data = data[:t.maxBytes]
+	}
+
+	if resp.StatusCode/100 != 2 {
+		return toolkit.Error(toolkit.CodeFailed, fmt.Sprintf("%s %s returned %s: %s", t.method, t.path, resp.Status, data)), nil
+	}
+	result := map[string]any{"status": resp.StatusCode}
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	var decoded any
+	if !truncated && strings.HasSuffix(mediaType, "json") && json.Unmarshal(data, &decoded) == nil {
+		result["body"] = decoded
+	} else {
+		result["body"] = string(data)
+	}
+	if truncated {
+		result["truncated"] = true
+	}
+	return result, nil
+}
+
+// paramString formats an argument for a path, query or header parameter.
+func paramString(v any) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		data, _ := json.Marshal(v)
+		return string(data)
+	}
+}
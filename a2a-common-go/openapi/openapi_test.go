@@ -0,0 +1,216 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package openapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"a2a-common-go/internal/testutil"
+	"a2a-common-go/toolkit"
+)
+
+const testOpenAPISpec = `
+openapi: 3.0.3
+info: {title: Inventory, version: "1"}
+servers:
+  - url: https://unused.example.com
+paths:
+  /items:
+    get:
+      operationId: listItems
+      summary: List items.
+      parameters:
+        - $ref: "#/components/parameters/Limit"
+        - {name: tag, in: query, schema: {type: array, items: {type: string}}}
+    post:
+      operationId: createItem
+      summary: Create an item.
+      requestBody:
+        required: true
+        content:
+          application/json:
+            schema: {$ref: "#/components/schemas/Item"}
+  /items/{id}:
+    parameters:
+      - {name: id, in: path, required: true, schema: {type: string}}
+    get:
+      operationId: getItem
+      summary: Get an item.
+    delete:
+      operationId: deleteItem
+      summary: Delete an item.
+  /big:
+    get:
+      operationId: big
+      summary: A large response.
+components:
+  parameters:
+    Limit: {name: limit, in: query, description: Maximum items., schema: {type: integer}}
+  schemas:
+    Item:
+      type: object
+      properties:
+        name: {type: string}
+      example: {name: dice}
+`
+
+func TestOpenAPITools(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "Bearer secret" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/items":
+			json.NewEncoder(w).Encode(map[string]any{"query": r.URL.RawQuery})
+		case r.Method == http.MethodPost && r.URL.Path == "/items":
+			var item map[string]any
+			json.NewDecoder(r.Body).Decode(&item)
+			json.NewEncoder(w).Encode(map[string]any{"created": item["name"]})
+		case r.URL.Path == "/items/a b":
+			json.NewEncoder(w).Encode(map[string]any{"id": "a b"})
+		case r.URL.Path == "/big":
+			w.Write([]byte(strings.Repeat("x", 100)))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	t.Setenv("TEST_TOKEN", "secret")
+	tools, err := newOpenAPITools([]byte(testOpenAPISpec), apiConfig{
+		BaseURL:          server.URL,
+		Headers:          map[string]string{"Authorization": "Bearer ${TEST_TOKEN}"},
+		Operations:       []string{"listItems", "createItem", "getItem", "big"},
+		MaxResponseBytes: 64,
+	})
+	if err != nil {
+		t.Fatalf("newOpenAPITools() error = %v", err)
+	}
+	byName := map[string]*Tool{}
+	for _, tl := range tools {
+		byName[tl.Name()] = tl.(*Tool)
+	}
+	if len(byName) != 4 || byName["deleteItem"] != nil {
+		t.Fatalf("newOpenAPITools() returned %v, want the 4 allowed operations", tools)
+	}
+
+	wantSchema := map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"body": map[string]any{
+				"type":       "object",
+				"properties": map[string]any{"name": map[string]any{"type": "string"}},
+			},
+		},
+		"required": []any{"body"},
+	}
+	schemaOf := func(name string) map[string]any {
+		data, err := json.Marshal(byName[name].Declaration().ParametersJsonSchema)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var schema map[string]any
+		if err := json.Unmarshal(data, &schema); err != nil {
+			t.Fatal(err)
+		}
+		return schema
+	}
+	if got := schemaOf("createItem"); !reflect.DeepEqual(got, wantSchema) {
+		t.Errorf("createItem schema = %v, want %v", got, wantSchema)
+	}
+	if got := schemaOf("listItems")["properties"].(map[string]any)["limit"]; !reflect.DeepEqual(got, map[string]any{"type": "integer", "description": "Maximum items."}) {
+		t.Errorf("listItems limit schema = %v", got)
+	}
+
+	// Results come back as JSON values, with numbers as float64.
+	calls := []struct {
+		tool string
+		args map[string]any
+		want map[string]any
+	}{
+		{
+			tool: "listItems",
+			args: map[string]any{"limit": 2.0, "tag": []any{"a", "b"}},
+			want: map[string]any{"status": 200.0, "body": map[string]any{"query": "limit=2&tag=a&tag=b"}},
+		},
+		{
+			tool: "createItem",
+			args: map[string]any{"body": map[string]any{"name": "dice"}},
+			want: map[string]any{"status": 200.0, "body": map[string]any{"created": "dice"}},
+		},
+		{
+			tool: "getItem",
+			args: map[string]any{"id": "a b"},
+			want: map[string]any{"status": 200.0, "body": map[string]any{"id": "a b"}},
+		},
+		{
+			tool: "getItem",
+			args: map[string]any{},
+			want: toolkit.Error(toolkit.CodeInvalidArguments, `validating root: required: missing properties: ["id"]`),
+		},
+		{
+			tool: "big",
+			want: map[string]any{"status": 200.0, "body": strings.Repeat("x", 64), "truncated": true},
+		},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	for _, c := range calls {
+		got, err := byName[c.tool].Run(testutil.ToolContext(ctx), c.args)
+		if err != nil {
+			t.Fatalf("Run(%s) error = %v", c.tool, err)
+		}
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("Run(%s, %v) = %v, want %v", c.tool, c.args, got, c.want)
+		}
+	}
+}
+
+func TestOpenAPIToolsUnknownOperation(t *testing.T) {
+	_, err := newOpenAPITools([]byte(testOpenAPISpec), apiConfig{Operations: []string{"listItems", "missing"}})
+	if err == nil || !strings.Contains(err.Error(), `"missing"`) {
+		t.Errorf("newOpenAPITools() error = %v, want unknown operation error", err)
+	}
+}
+
+func TestOpenAPIString(t *testing.T) {
+	tests := []struct {
+		v    any
+		want string
+	}{
+		{"dice", "dice"},
+		{3.0, "3"},
+		{2.5, "2.5"},
+		{-40.0, "-40"},
+		{1e-7, "0.0000001"},
+		{1e21, "1000000000000000000000"},
+		{true, "true"},
+		{nil, "null"},
+	}
+	for _, tt := range tests {
+		if got := paramString(tt.v); got != tt.want {
+			t.Errorf("paramString(%v) = %q, want %q", tt.v, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,305 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package remote
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	a2acore "github.com/a2aproject/a2a-go/a2a"
+	"github.com/a2aproject/a2a-go/a2aclient/agentcard"
+	"google.golang.org/adk/agent"
+)
+
+// --- Agent Discovery ---
+//
+// Besides the configured remote agents, the root agent delegates to the
+// agents found in a registry, reloaded every --agent_registry_interval
+// (AGENT_REGISTRY_INTERVAL, 30s by default). The registry is made of:
+//
+//   - --agent_registry (AGENT_REGISTRY): a JSON file in the format of the
+//     remote agents config, or a directory of agent card files (*.json);
+//   - --agent_registry_urls (AGENT_REGISTRY_URLS): comma separated base URLs
+//     whose well-known agent cards are polled.
+//
+// Agents are named after their cards and described by the card description
+// and skills, unless the registry file says otherwise. When the discovered
+// agents change, the root agent is rebuilt with them as sub-agents. The
+// configured remote agents win over discovered ones with the same name.
+//
+// Once read, a card is kept until the registry no longer lists it: an agent
+// whose card cannot be fetched for a while stays, with its last card, and its
+// health probes mark it offline meanwhile.
+
+const (
+	agentRegistryEnv         = "AGENT_REGISTRY"
+	agentRegistryURLsEnv     = "AGENT_REGISTRY_URLS"
+	agentRegistryIntervalEnv = "AGENT_REGISTRY_INTERVAL"
+
+	defaultAgentRegistryInterval = 30 * time.Second
+)
+
+// Registry finds the remote agents.
+type Registry struct {
+	static []AgentSpec // configured agents
+	path   string
+	urls   []string
+	client *http.Client
+
+	mu    sync.Mutex
+	cards map[string]*a2acore.AgentCard // last card read, by URL or file
+}
+
+func newAgentRegistry(static []AgentSpec, path string, urls []string) *Registry {
+	return &Registry{
+		static: static,
+		path:   path,
+		urls:   urls,
+		client: &http.Client{Timeout: remoteProbeTimeout, Transport: remoteTransport},
+	}
+}
+
+// Load returns the configured agents followed by the discovered ones. Cards
+// which cannot be read are replaced by the last one read, if any, or else
+// skipped, so one bad entry does not hide the others; but a registry which
+// cannot be read at all is an error.
+func (r *Registry) Load(ctx context.Context) ([]AgentSpec, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	// Only the cards still in the registry are kept for the next load.
+	cards := make(map[string]*a2acore.AgentCard)
+	var read cardReader = func(source string, load func() (*a2acore.AgentCard, error)) (*a2acore.AgentCard, error) {
+		card, err := load()
+		if err != nil {
+			last, ok := r.cards[source]
+			if !ok {
+				return nil, err
+			}
+			log.Printf("Keeping the last card of %s: %v", source, err)
+			card = last
+		}
+		cards[source] = card
+		return card, nil
+	}
+
+	specs := append([]AgentSpec(nil), r.static...)
+	seen := make(map[string]bool)
+	for _, s := range specs {
+		seen[s.Name] = true
+	}
+	add := func(s AgentSpec, from string) {
+		if err := s.validate(); err != nil {
+			log.Printf("Skipping agent from %s: %v", from, err)
+			return
+		}
+		if seen[s.Name] {
+			return
+		}
+		seen[s.Name] = true
+		specs = append(specs, s)
+	}
+
+	if r.path != "" {
+		found, err := r.loadPath(ctx, read)
+		if err != nil {
+			return nil, err
+		}
+		for _, s := range found {
+			add(s, r.path)
+		}
+	}
+	for _, u := range r.urls {
+		card, err := read(u, r.fetch(ctx, u))
+		if err != nil {
+			log.Printf("Skipping agent at %s: %v", u, err)
+			continue
+		}
+		add(AgentSpec{Name: agentNameFromCard(card), Description: DescribeCard(card), URL: u}, u)
+	}
+	r.cards = cards
+	return specs, nil
+}
+
+// fetch returns a function fetching the card of the agent at u.
+func (r *Registry) fetch(ctx context.Context, u string) func() (*a2acore.AgentCard, error) {
+	return func() (*a2acore.AgentCard, error) {
+		return agentcard.NewResolver(r.client).Resolve(ctx, u)
+	}
+}
+
+// cardReader reads the card of source with load.
+type cardReader func(source string, load func() (*a2acore.AgentCard, error)) (*a2acore.AgentCard, error)
+
+// loadPath reads the registry file or directory, reading cards with read.
+func (r *Registry) loadPath(ctx context.Context, read cardReader) ([]AgentSpec, error) {
+	info, err := os.Stat(r.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read agent registry: %w", err)
+	}
+	if !info.IsDir() {
+		specs, err := loadRemoteAgentsFile(r.path)
+		if err != nil {
+			return nil, err
+		}
+		for i, s := range specs {
+			if s.Description == "" && s.URL != "" {
+				// Describe the agent by its card, if it can be read.
+				if card, err := read(s.URL, r.fetch(ctx, s.URL)); err == nil {
+					specs[i].Description = DescribeCard(card)
+				}
+			}
+		}
+		return specs, nil
+	}
+
+	files, err := filepath.Glob(filepath.Join(r.path, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+	var specs []AgentSpec
+	for _, f := range files {
+		card, err := read(f, func() (*a2acore.AgentCard, error) { return LoadAgentCard(f) })
+		if err != nil {
+			log.Printf("Skipping agent card: %v", err)
+			continue
+		}
+		specs = append(specs, AgentSpec{Name: agentNameFromCard(card), Description: DescribeCard(card), CardFile: f})
+	}
+	return specs, nil
+}
+
+// Watch reloads the registry every interval until ctx is done, calling
+// changed with the agents when they differ from last.
+func (r *Registry) Watch(ctx context.Context, interval time.Duration, last []AgentSpec, changed func([]AgentSpec)) {
+	if interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		specs, err := r.Load(ctx)
+		if err != nil {
+			log.Printf("Failed to reload agent registry: %v", err)
+			continue
+		}
+		if !reflect.DeepEqual(specs, last) {
+			last = specs
+			changed(specs)
+		}
+	}
+}
+
+var nonNameChars = regexp.MustCompile(`[^a-z0-9_]+`)
+
+// agentNameFromCard turns the card name into an agent name, e.g. "Prime
+// Checker" into prime_checker.
+func agentNameFromCard(card *a2acore.AgentCard) string {
+	name := strings.Trim(nonNameChars.ReplaceAllString(strings.ToLower(card.Name), "_"), "_")
+	if name != "" && name[0] >= '0' && name[0] <= '9' {
+		name = "agent_" + name
+	}
+	return name
+}
+
+// DescribeCard describes the agent by its card description and skills.
+func DescribeCard(card *a2acore.AgentCard) string {
+	desc := strings.TrimSpace(card.Description)
+	var skills []string
+	for _, s := range card.Skills {
+		skill := s.Name
+		if s.Description != "" {
+			skill += ": " + s.Description
+		}
+		skills = append(skills, skill)
+	}
+	if len(skills) > 0 {
+		if desc != "" && !strings.HasSuffix(desc, ".") {
+			desc += "."
+		}
+		desc = strings.TrimSpace(desc + " Skills: " + strings.Join(skills, "; ") + ".")
+	}
+	return desc
+}
+
+// LiveRoot holds the root agent, rebuilt whenever the remote agents change.
+type LiveRoot struct {
+	health *Health
+	build  func(remoteAgents []agent.Agent) (agent.Agent, error)
+
+	mu   sync.RWMutex
+	root agent.Agent
+}
+
+// NewLiveRoot returns a LiveRoot that builds the root agent with build,
+// passing it the remote agents wrapped with health's circuit breakers. It
+// holds no root agent until the first call to Update.
+func NewLiveRoot(health *Health, build func([]agent.Agent) (agent.Agent, error)) *LiveRoot {
+	return &LiveRoot{health: health, build: build}
+}
+
+// Update rebuilds the root agent with the remote agents. On error the
+// previous root agent stays in place.
+func (l *LiveRoot) Update(ctx context.Context, specs []AgentSpec) error {
+	var remoteAgents []agent.Agent
+	for _, spec := range specs {
+		remoteAgent, err := NewAgent(spec)
+		if err != nil {
+			return err
+		}
+		if remoteAgent, err = l.health.wrap(remoteAgent); err != nil {
+			return err
+		}
+		remoteAgents = append(remoteAgents, remoteAgent)
+	}
+	root, err := l.build(remoteAgents)
+	if err != nil {
+		return err
+	}
+	l.health.update(specs)
+	l.mu.Lock()
+	l.root = root
+	l.mu.Unlock()
+
+	names := make([]string, len(specs))
+	for i, s := range specs {
+		names[i] = s.Name
+	}
+	log.Printf("Remote agents: %s", strings.Join(names, ", "))
+	l.health.checkAll(ctx)
+	return nil
+}
+
+// Current returns the root agent to run.
+func (l *LiveRoot) Current() agent.Agent {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.root
+}
@@ -0,0 +1,202 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package remote
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	a2acore "github.com/a2aproject/a2a-go/a2a"
+	"github.com/a2aproject/a2a-go/a2asrv"
+	"google.golang.org/adk/agent"
+
+	"a2a-common-go/a2aserver"
+)
+
+func TestAgentRegistry(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	factCard := write("fact.json", `{"name": "Fact Checker", "description": "Checks facts", "url": "http://facts:8087/a2a/invoke",
+		"skills": [{"id": "check", "name": "check_fact", "description": "Checks a claim."}, {"id": "cite", "name": "cite"}]}`)
+	write("prime.json", `{"name": "prime_agent", "description": "Another prime agent.", "url": "http://other:8086/a2a/invoke"}`)
+	write("broken.json", `{"name": `)
+	write("notes.txt", `not a card`)
+
+	mux := http.NewServeMux()
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+	mux.Handle(a2asrv.WellKnownAgentCardPath, a2asrv.NewStaticAgentCardHandler(&a2acore.AgentCard{
+		Name:        "42 Jokes",
+		Description: "Tells jokes.",
+		URL:         srv.URL + a2aserver.InvokePath,
+	}))
+
+	prime := defaultRemoteAgents[0]
+	r := newAgentRegistry([]AgentSpec{prime}, dir, []string{srv.URL, "http://127.0.0.1:1"})
+	got, err := r.Load(context.Background())
+	if err != nil {
+		t.Fatalf("load() error = %v", err)
+	}
+	want := []AgentSpec{
+		prime,
+		{Name: "fact_checker", Description: "Checks facts. Skills: check_fact: Checks a claim.; cite.", CardFile: factCard},
+		{Name: "agent_42_jokes", Description: "Tells jokes.", URL: srv.URL},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("load() = %+v, want %+v", got, want)
+	}
+
+	// Changes to the registry are picked up.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	changed := make(chan []AgentSpec, 1)
+	go r.Watch(ctx, 10*time.Millisecond, got, func(specs []AgentSpec) { changed <- specs })
+	if err := os.Remove(factCard); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case specs := <-changed:
+		if want := []AgentSpec{want[0], want[2]}; !reflect.DeepEqual(specs, want) {
+			t.Errorf("changed agents = %+v, want %+v", specs, want)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("registry change not noticed")
+	}
+
+	if _, err := newAgentRegistry(nil, filepath.Join(dir, "missing"), nil).Load(context.Background()); err == nil {
+		t.Error("load() of a missing registry succeeded")
+	}
+}
+
+func TestAgentRegistryKeepsAgents(t *testing.T) {
+	dir := t.TempDir()
+	cardFile := filepath.Join(dir, "fact.json")
+	if err := os.WriteFile(cardFile, []byte(`{"name": "fact_agent", "url": "http://facts:8087/a2a/invoke"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var failing atomic.Bool
+	mux := http.NewServeMux()
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+	card := a2asrv.NewStaticAgentCardHandler(&a2acore.AgentCard{Name: "joke_agent", Description: "Tells jokes.", URL: srv.URL + a2aserver.InvokePath})
+	mux.HandleFunc(a2asrv.WellKnownAgentCardPath, func(w http.ResponseWriter, r *http.Request) {
+		if failing.Load() {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		card.ServeHTTP(w, r)
+	})
+
+	r := newAgentRegistry(nil, dir, []string{srv.URL})
+	want := []AgentSpec{
+		{Name: "fact_agent", CardFile: cardFile},
+		{Name: "joke_agent", Description: "Tells jokes.", URL: srv.URL},
+	}
+	load := func(want []AgentSpec) {
+		t.Helper()
+		got, err := r.Load(context.Background())
+		if err != nil {
+			t.Fatalf("load() error = %v", err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("load() = %+v, want %+v", got, want)
+		}
+	}
+	load(want)
+
+	// Unreadable cards are replaced by the last ones read.
+	failing.Store(true)
+	if err := os.WriteFile(cardFile, []byte(`{"name": `), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	load(want)
+
+	// Agents leave when the registry no longer lists them.
+	if err := os.Remove(cardFile); err != nil {
+		t.Fatal(err)
+	}
+	r.urls = nil
+	load(nil)
+	// Their cards are forgotten too.
+	if err := os.WriteFile(cardFile, []byte(`{"name": `), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	r.urls = []string{srv.URL}
+	load(nil)
+}
+
+func TestLiveRoot(t *testing.T) {
+	dir := t.TempDir()
+	card := filepath.Join(dir, "fact.json")
+	if err := os.WriteFile(card, []byte(`{"name": "fact_agent", "url": "http://127.0.0.1:1/a2a/invoke"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	prime := defaultRemoteAgents[0]
+	fact := AgentSpec{Name: "fact_agent", CardFile: card}
+
+	health := NewHealth(nil, breakerConfig{callTimeout: time.Second, failures: 1, cooldown: time.Minute})
+	root := NewLiveRoot(health, func(remoteAgents []agent.Agent) (agent.Agent, error) {
+		return agent.New(agent.Config{Name: "root_agent", SubAgents: remoteAgents})
+	})
+	names := func() []string {
+		var names []string
+		for _, a := range root.Current().SubAgents() {
+			names = append(names, a.Name())
+		}
+		return names
+	}
+
+	ctx := context.Background()
+	if err := root.Update(ctx, []AgentSpec{prime, fact}); err != nil {
+		t.Fatalf("update() error = %v", err)
+	}
+	if got := names(); !reflect.DeepEqual(got, []string{"prime_agent", "fact_agent"}) {
+		t.Errorf("sub-agents = %v", got)
+	}
+	if got := health.agents(); !reflect.DeepEqual(got, []AgentSpec{prime, fact}) {
+		t.Errorf("tracked agents = %+v", got)
+	}
+
+	// A failed update keeps the previous root agent.
+	before := root.Current()
+	if err := root.Update(ctx, []AgentSpec{{Name: "fact_agent", CardFile: filepath.Join(dir, "missing.json")}}); err == nil {
+		t.Error("update() with a missing card succeeded")
+	}
+	if root.Current() != before {
+		t.Error("failed update replaced the root agent")
+	}
+
+	if err := root.Update(ctx, []AgentSpec{fact}); err != nil {
+		t.Fatalf("update() error = %v", err)
+	}
+	if got := names(); !reflect.DeepEqual(got, []string{"fact_agent"}) {
+		t.Errorf("sub-agents after removing prime_agent = %v", got)
+	}
+}
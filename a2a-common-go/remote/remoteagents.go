@@ -0,0 +1,354 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package remote lets the root agent delegate to remote agents over A2A:
+// their configuration, discovery, health, routing and tasks.
+package remote
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	a2acore "github.com/a2aproject/a2a-go/a2a"
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/agent/remoteagent"
+)
+
+// --- Remote Agents ---
+//
+// The root agent delegates to remote agents over A2A. Each one is found
+// either at a URL, whose agent card is fetched on first use, or through a
+// local agent card file, which is handy for offline testing. They are
+// configured, each source overriding the previous ones by agent name, from:
+//
+//  1. the default prime_agent at http://localhost:8086;
+//  2. the JSON file named by --remote_agents_config or REMOTE_AGENTS_CONFIG:
+//
+//     {"agents": [
+//       {"name": "prime_agent", "url": "https://prime-abc.a.run.app"},
+//       {"name": "fact_agent", "description": "Knows facts.", "card_file": "cards/fact.json"}
+//     ]}
+//
+//     with credentials for the agents requiring them, see remoteauth.go,
+//     and TLS settings for all of them in remotetls.go;
+//
+//  3. REMOTE_AGENTS, a comma separated list of name=source;
+//  4. --remote_agent name=source, which can be repeated.
+//
+// A source starting with http:// or https:// is a URL, anything else is a card
+// file. Relative card files in the config file are resolved against its
+// directory.
+
+const (
+	remoteAgentsConfigEnv = "REMOTE_AGENTS_CONFIG"
+	remoteAgentsEnv       = "REMOTE_AGENTS"
+	remoteHealthEnv       = "REMOTE_HEALTH_INTERVAL"
+
+	remoteCallTimeoutEnv     = "REMOTE_CALL_TIMEOUT"
+	remoteBreakerFailuresEnv = "REMOTE_BREAKER_FAILURES"
+	remoteBreakerCooldownEnv = "REMOTE_BREAKER_COOLDOWN"
+)
+
+// agentNamePattern matches the names ADK accepts for agents.
+var agentNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// defaultRemoteAgents are used unless overridden.
+var defaultRemoteAgents = []AgentSpec{{
+	Name:        "prime_agent",
+	Description: "Agent that handles checking if numbers are prime.",
+	URL:         "http://localhost:8086",
+}}
+
+// AgentSpec describes one remote agent. Exactly one of URL and
+// CardFile is set.
+type AgentSpec struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	URL         string `json:"url,omitempty"`
+	CardFile    string `json:"card_file,omitempty"`
+
+	Credentials *remoteCredentials `json:"credentials,omitempty"`
+}
+
+// Config is where the remote agents are configured.
+type Config struct {
+	file  string
+	flags remoteAgentFlags
+	// HealthInterval is how often the remote agents are probed, see
+	// Health.
+	HealthInterval time.Duration
+	Breaker        breakerConfig
+	TLS            TLSConfig
+
+	// The agent registry, see Registry.
+	registryPath     string
+	registryURLs     string
+	RegistryInterval time.Duration
+}
+
+// remoteAgentFlags collects the values of the repeated --remote_agent flag.
+type remoteAgentFlags []string
+
+func (f *remoteAgentFlags) String() string { return strings.Join(*f, ",") }
+
+func (f *remoteAgentFlags) Set(v string) error {
+	*f = append(*f, v)
+	return nil
+}
+
+// NewConfig reads the remote agents configuration from the
+// environment and registers the flags overriding it on fs. Call agents once fs
+// is parsed.
+func NewConfig(fs *flag.FlagSet) (*Config, error) {
+	cfg := &Config{
+		file:             os.Getenv(remoteAgentsConfigEnv),
+		HealthInterval:   defaultRemoteHealthInterval,
+		registryPath:     os.Getenv(agentRegistryEnv),
+		registryURLs:     os.Getenv(agentRegistryURLsEnv),
+		RegistryInterval: defaultAgentRegistryInterval,
+		TLS: TLSConfig{
+			CAFile:   os.Getenv(remoteTLSCAEnv),
+			CertFile: os.Getenv(remoteTLSCertEnv),
+			KeyFile:  os.Getenv(remoteTLSKeyEnv),
+		},
+		Breaker: breakerConfig{
+			callTimeout: defaultRemoteCallTimeout,
+			failures:    defaultRemoteBreakerFailures,
+			cooldown:    defaultRemoteBreakerCooldown,
+		},
+	}
+	for env, d := range map[string]*time.Duration{
+		remoteHealthEnv:          &cfg.HealthInterval,
+		remoteCallTimeoutEnv:     &cfg.Breaker.callTimeout,
+		remoteBreakerCooldownEnv: &cfg.Breaker.cooldown,
+		agentRegistryIntervalEnv: &cfg.RegistryInterval,
+	} {
+		if v := os.Getenv(env); v != "" {
+			parsed, err := time.ParseDuration(v)
+			if err != nil || parsed < 0 {
+				return nil, fmt.Errorf("invalid %s %q: must be a non-negative duration", env, v)
+			}
+			*d = parsed
+		}
+	}
+	if v := os.Getenv(remoteBreakerFailuresEnv); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s %q: must be a number", remoteBreakerFailuresEnv, v)
+		}
+		cfg.Breaker.failures = n
+	}
+	fs.StringVar(&cfg.file, "remote_agents_config", cfg.file, "JSON file listing the remote agents. Defaults to $"+remoteAgentsConfigEnv+".")
+	fs.Var(&cfg.flags, "remote_agent", "Remote agent as name=source, where source is a URL or an agent card file. Can be repeated.")
+	fs.DurationVar(&cfg.HealthInterval, "remote_health_interval", cfg.HealthInterval, "How often to check that the remote agents are reachable, 0 to only check at startup. Defaults to $"+remoteHealthEnv+".")
+	fs.DurationVar(&cfg.Breaker.callTimeout, "remote_call_timeout", cfg.Breaker.callTimeout, "Deadline of each call to a remote agent. Defaults to $"+remoteCallTimeoutEnv+".")
+	fs.IntVar(&cfg.Breaker.failures, "remote_breaker_failures", cfg.Breaker.failures, "Consecutive failed calls opening the circuit of a remote agent. Defaults to $"+remoteBreakerFailuresEnv+".")
+	fs.DurationVar(&cfg.Breaker.cooldown, "remote_breaker_cooldown", cfg.Breaker.cooldown, "How long an open circuit refuses calls before letting one through. Defaults to $"+remoteBreakerCooldownEnv+".")
+	fs.StringVar(&cfg.TLS.CAFile, "remote_tls_ca", cfg.TLS.CAFile, "PEM CA bundle verifying the certificates of remote agents instead of the system roots. Defaults to $"+remoteTLSCAEnv+".")
+	fs.StringVar(&cfg.TLS.CertFile, "remote_tls_cert", cfg.TLS.CertFile, "PEM client certificate chain to present to remote agents. Defaults to $"+remoteTLSCertEnv+".")
+	fs.StringVar(&cfg.TLS.KeyFile, "remote_tls_key", cfg.TLS.KeyFile, "PEM private key of --remote_tls_cert. Defaults to $"+remoteTLSKeyEnv+".")
+	fs.StringVar(&cfg.registryPath, "agent_registry", cfg.registryPath, "JSON file or directory of agent cards to discover remote agents in. Defaults to $"+agentRegistryEnv+".")
+	fs.StringVar(&cfg.registryURLs, "agent_registry_urls", cfg.registryURLs, "Comma separated base URLs of remote agents to discover by their agent cards. Defaults to $"+agentRegistryURLsEnv+".")
+	fs.DurationVar(&cfg.RegistryInterval, "agent_registry_interval", cfg.RegistryInterval, "How often to reload the agent registry, 0 to only load it at startup. Defaults to $"+agentRegistryIntervalEnv+".")
+	return cfg, nil
+}
+
+// Registry returns the registry of the configured and discovered agents.
+func (c *Config) Registry(static []AgentSpec) (*Registry, error) {
+	var urls []string
+	for _, u := range strings.Split(c.registryURLs, ",") {
+		if u = strings.TrimSpace(u); u == "" {
+			continue
+		}
+		if !isHTTPURL(u) {
+			return nil, fmt.Errorf("invalid agent registry URL %q", u)
+		}
+		urls = append(urls, u)
+	}
+	return newAgentRegistry(static, c.registryPath, urls), nil
+}
+
+// Agents merges the configured remote agents.
+func (c *Config) Agents() ([]AgentSpec, error) {
+	if c.Breaker.callTimeout <= 0 {
+		return nil, fmt.Errorf("invalid remote call timeout %s: must be positive", c.Breaker.callTimeout)
+	}
+	if c.Breaker.failures < 1 {
+		return nil, fmt.Errorf("invalid remote breaker failures %d: must be at least 1", c.Breaker.failures)
+	}
+	if err := c.TLS.Validate(); err != nil {
+		return nil, err
+	}
+	specs := append([]AgentSpec(nil), defaultRemoteAgents...)
+	if c.file != "" {
+		fromFile, err := loadRemoteAgentsFile(c.file)
+		if err != nil {
+			return nil, err
+		}
+		for _, s := range fromFile {
+			specs = mergeRemoteAgent(specs, s)
+		}
+	}
+	var overrides []string
+	if v := os.Getenv(remoteAgentsEnv); v != "" {
+		overrides = strings.Split(v, ",")
+	}
+	overrides = append(overrides, c.flags...)
+	for _, o := range overrides {
+		s, err := parseRemoteAgent(strings.TrimSpace(o))
+		if err != nil {
+			return nil, err
+		}
+		specs = mergeRemoteAgent(specs, s)
+	}
+	for _, s := range specs {
+		if err := s.validate(); err != nil {
+			return nil, err
+		}
+	}
+	return specs, nil
+}
+
+// loadRemoteAgentsFile reads the remote agents configuration file.
+func loadRemoteAgentsFile(path string) ([]AgentSpec, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read remote agents config: %w", err)
+	}
+	var file struct {
+		Agents []AgentSpec `json:"agents"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse remote agents config %s: %w", path, err)
+	}
+	for i, s := range file.Agents {
+		if (s.URL == "") == (s.CardFile == "") {
+			return nil, fmt.Errorf("invalid remote agents config %s: agent %q must have either url or card_file", path, s.Name)
+		}
+		if s.CardFile != "" && !filepath.IsAbs(s.CardFile) {
+			file.Agents[i].CardFile = filepath.Join(filepath.Dir(path), s.CardFile)
+		}
+		if s.Credentials != nil {
+			s.Credentials.resolve(filepath.Dir(path))
+		}
+	}
+	return file.Agents, nil
+}
+
+// parseRemoteAgent parses name=source.
+func parseRemoteAgent(v string) (AgentSpec, error) {
+	name, source, ok := strings.Cut(v, "=")
+	if !ok || name == "" || source == "" {
+		return AgentSpec{}, fmt.Errorf("invalid remote agent %q: must be name=source", v)
+	}
+	if strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://") {
+		return AgentSpec{Name: name, URL: source}, nil
+	}
+	return AgentSpec{Name: name, CardFile: source}, nil
+}
+
+// mergeRemoteAgent replaces the source of the agent with the same name,
+// keeping its description and credentials unless s has them, or adds s.
+func mergeRemoteAgent(specs []AgentSpec, s AgentSpec) []AgentSpec {
+	for i := range specs {
+		if specs[i].Name != s.Name {
+			continue
+		}
+		if s.Description == "" {
+			s.Description = specs[i].Description
+		}
+		if s.Credentials == nil {
+			s.Credentials = specs[i].Credentials
+		}
+		specs[i] = s
+		return specs
+	}
+	return append(specs, s)
+}
+
+func (s AgentSpec) validate() error {
+	if !agentNamePattern.MatchString(s.Name) {
+		return fmt.Errorf("invalid remote agent name %q: must be a letter or underscore followed by letters, digits or underscores", s.Name)
+	}
+	if s.URL != "" && !isHTTPURL(s.URL) {
+		return fmt.Errorf("invalid URL %q for remote agent %q", s.URL, s.Name)
+	}
+	if s.Credentials != nil {
+		if err := s.Credentials.validate(); err != nil {
+			return fmt.Errorf("invalid credentials for remote agent %q: %w", s.Name, err)
+		}
+	}
+	return nil
+}
+
+// isHTTPURL reports whether raw is an absolute http or https URL.
+func isHTTPURL(raw string) bool {
+	u, err := url.Parse(raw)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// NewAgent creates the agent calling the remote agent of spec.
+func NewAgent(spec AgentSpec) (agent.Agent, error) {
+	cfg := remoteagent.A2AConfig{
+		Name:            spec.Name,
+		Description:     spec.Description,
+		AgentCardSource: spec.URL,
+		ClientFactory:   withRemotePush(spec.Name, newRemoteClientFactory(spec)),
+	}
+	if spec.CardFile != "" {
+		card, err := LoadAgentCard(spec.CardFile)
+		if err != nil {
+			return nil, err
+		}
+		cfg.AgentCard = card
+		if cfg.Description == "" {
+			cfg.Description = DescribeCard(card)
+		}
+	}
+	if cfg.Description == "" {
+		cfg.Description = fmt.Sprintf("Remote agent %s.", spec.Name)
+	}
+	remoteAgent, err := remoteagent.New(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create remote agent %s: %w", spec.Name, err)
+	}
+	return mergeRemoteStream(remoteAgent)
+}
+
+// LoadAgentCard reads an agent card file.
+func LoadAgentCard(path string) (*a2acore.AgentCard, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read agent card: %w", err)
+	}
+	var card a2acore.AgentCard
+	if err := json.Unmarshal(data, &card); err != nil {
+		return nil, fmt.Errorf("failed to parse agent card %s: %w", path, err)
+	}
+	if card.Name == "" {
+		return nil, fmt.Errorf("invalid agent card %s: no name", path)
+	}
+	if !isHTTPURL(card.URL) {
+		return nil, fmt.Errorf("invalid agent card %s: url %q is not an http or https URL", path, card.URL)
+	}
+	return &card, nil
+}
@@ -0,0 +1,142 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package remote
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"a2a-common-go/flagutil"
+)
+
+func TestRemoteAgentsConfig(t *testing.T) {
+	dir := t.TempDir()
+	configFile := filepath.Join(dir, "agents.json")
+	config := `{"agents": [
+		{"name": "prime_agent", "url": "https://prime.example.com"},
+		{"name": "fact_agent", "description": "Knows facts.", "card_file": "cards/fact.json"}
+	]}`
+	if err := os.WriteFile(configFile, []byte(config), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	prime := defaultRemoteAgents[0]
+
+	tests := []struct {
+		name    string
+		env     map[string]string
+		args    []string
+		want    []AgentSpec
+		wantErr string
+	}{
+		{
+			name: "defaults",
+			want: []AgentSpec{prime},
+		},
+		{
+			name: "config file",
+			args: []string{"--remote_agents_config", configFile},
+			want: []AgentSpec{
+				{Name: "prime_agent", Description: prime.Description, URL: "https://prime.example.com"},
+				{Name: "fact_agent", Description: "Knows facts.", CardFile: filepath.Join(dir, "cards/fact.json")},
+			},
+		},
+		{
+			name: "environment and flags override the config file",
+			env:  map[string]string{"REMOTE_AGENTS_CONFIG": configFile, "REMOTE_AGENTS": "prime_agent=http://prime:8086, joke_agent=http://jokes:8087"},
+			args: []string{"--remote_agent", "fact_agent=/cards/fact.json", "--remote_agent", "joke_agent=https://jokes.example.com"},
+			want: []AgentSpec{
+				{Name: "prime_agent", Description: prime.Description, URL: "http://prime:8086"},
+				{Name: "fact_agent", Description: "Knows facts.", CardFile: "/cards/fact.json"},
+				{Name: "joke_agent", URL: "https://jokes.example.com"},
+			},
+		},
+		{
+			name:    "no source",
+			args:    []string{"--remote_agent", "prime_agent"},
+			wantErr: `invalid remote agent "prime_agent": must be name=source`,
+		},
+		{
+			name:    "invalid name",
+			args:    []string{"--remote_agent", "prime-agent=http://prime:8086"},
+			wantErr: `invalid remote agent name "prime-agent"`,
+		},
+		{
+			name:    "invalid URL",
+			args:    []string{"--remote_agent", "prime_agent=http://"},
+			wantErr: `invalid URL "http://" for remote agent "prime_agent"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, k := range []string{"REMOTE_AGENTS_CONFIG", "REMOTE_AGENTS"} {
+				t.Setenv(k, tt.env[k])
+			}
+			fs := flag.NewFlagSet("test", flag.ContinueOnError)
+			cfg, err := NewConfig(fs)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := flagutil.Parse(fs, tt.args); err != nil {
+				t.Fatal(err)
+			}
+			got, err := cfg.Agents()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("agents() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("agents() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("agents() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadAgentCard(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	card, err := LoadAgentCard(write("prime.json", `{"name": "check_prime_agent", "description": "Checks primes.", "url": "http://localhost:8086/a2a/invoke", "preferredTransport": "JSONRPC"}`))
+	if err != nil {
+		t.Fatalf("loadAgentCard() error = %v", err)
+	}
+	if card.Name != "check_prime_agent" || card.URL != "http://localhost:8086/a2a/invoke" {
+		t.Errorf("loadAgentCard() = %+v", card)
+	}
+
+	for name, content := range map[string]string{
+		"no-name.json": `{"url": "http://localhost:8086/a2a/invoke"}`,
+		"no-url.json":  `{"name": "check_prime_agent"}`,
+		"bad.json":     `{"name": `,
+	} {
+		if _, err := LoadAgentCard(write(name, content)); err == nil {
+			t.Errorf("loadAgentCard(%s) error = nil, want error", name)
+		}
+	}
+}
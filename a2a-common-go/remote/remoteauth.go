@@ -0,0 +1,181 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package remote
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	a2acore "github.com/a2aproject/a2a-go/a2a"
+	"github.com/a2aproject/a2a-go/a2aclient"
+)
+
+// --- Remote Agent Credentials ---
+//
+// Remote agents requiring authentication get credentials from the remote
+// agents config file:
+//
+//	{"agents": [{"name": "prime_agent", "url": "https://prime.internal",
+//	  "credentials": {"api_key_env": "PRIME_API_KEY", "bearer_token_file": "prime.jwt"}}]}
+//
+// An API key and a bearer token can each be read from an environment variable
+// or a file, at every call so that they can be rotated without a restart. They
+// are sent as the security schemes in the agent card of the remote agent ask,
+// so an agent which declares none gets none.
+
+// remoteCredentials are where the credentials for a remote agent are read.
+type remoteCredentials struct {
+	APIKeyEnv       string `json:"api_key_env,omitempty"`
+	APIKeyFile      string `json:"api_key_file,omitempty"`
+	BearerTokenEnv  string `json:"bearer_token_env,omitempty"`
+	BearerTokenFile string `json:"bearer_token_file,omitempty"`
+}
+
+// resolve makes the credential files relative to dir absolute.
+func (c *remoteCredentials) resolve(dir string) {
+	for _, f := range []*string{&c.APIKeyFile, &c.BearerTokenFile} {
+		if *f != "" && !filepath.IsAbs(*f) {
+			*f = filepath.Join(dir, *f)
+		}
+	}
+}
+
+// validate checks that the credentials can be read now.
+func (c *remoteCredentials) validate() error {
+	if c.APIKeyEnv != "" && c.APIKeyFile != "" || c.BearerTokenEnv != "" && c.BearerTokenFile != "" {
+		return errors.New("set either the env or the file of a credential, not both")
+	}
+	if *c == (remoteCredentials{}) {
+		return errors.New("no credentials")
+	}
+	if _, err := c.apiKey(); err != nil {
+		return err
+	}
+	_, err := c.bearerToken()
+	return err
+}
+
+// apiKey returns the API key, or "" if none is configured.
+func (c *remoteCredentials) apiKey() (string, error) {
+	return readSecret("API key", c.APIKeyEnv, c.APIKeyFile)
+}
+
+// bearerToken returns the bearer token, or "" if none is configured.
+func (c *remoteCredentials) bearerToken() (string, error) {
+	return readSecret("bearer token", c.BearerTokenEnv, c.BearerTokenFile)
+}
+
+// readSecret reads a secret from the environment variable env or the file.
+func readSecret(what, env, file string) (string, error) {
+	switch {
+	case env != "":
+		v := strings.TrimSpace(os.Getenv(env))
+		if v == "" {
+			return "", fmt.Errorf("%s: $%s is not set", what, env)
+		}
+		return v, nil
+	case file != "":
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return "", fmt.Errorf("failed to read %s: %w", what, err)
+		}
+		v := strings.TrimSpace(string(data))
+		if v == "" {
+			return "", fmt.Errorf("%s: %s is empty", what, file)
+		}
+		return v, nil
+	}
+	return "", nil
+}
+
+// newRemoteClientFactory returns the factory of the A2A clients of the
+// remote agent, which track their tasks, see remotetasks.go.
+func newRemoteClientFactory(spec AgentSpec) *a2aclient.Factory {
+	opts := []a2aclient.FactoryOption{a2aclient.WithTransport(a2acore.TransportProtocolJSONRPC, remoteTaskTransports(spec.Name))}
+	if spec.Credentials != nil {
+		opts = append(opts, a2aclient.WithInterceptors(&credentialInterceptor{agent: spec.Name, creds: spec.Credentials}))
+	}
+	return a2aclient.NewFactory(opts...)
+}
+
+// credentialInterceptor sends the credentials asked for by the agent card.
+type credentialInterceptor struct {
+	a2aclient.PassthroughInterceptor
+	agent string
+	creds *remoteCredentials
+}
+
+func (i *credentialInterceptor) Before(ctx context.Context, req *a2aclient.Request) (context.Context, error) {
+	if req.Card == nil || len(req.Card.Security) == 0 {
+		return ctx, nil
+	}
+	var errs []error
+	// The requirements are alternatives; all the schemes of one are needed.
+	for _, requirement := range req.Card.Security {
+		meta := make(map[string]string)
+		var err error
+		for name := range requirement {
+			if err = i.credential(req.Card.SecuritySchemes[name], name, meta); err != nil {
+				break
+			}
+		}
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		for k, v := range meta {
+			req.Meta[k] = []string{v}
+		}
+		return ctx, nil
+	}
+	return ctx, fmt.Errorf("no credentials for %s: %w", i.agent, errors.Join(errs...))
+}
+
+// credential adds the header authenticating with the scheme to meta.
+func (i *credentialInterceptor) credential(scheme a2acore.SecurityScheme, name a2acore.SecuritySchemeName, meta map[string]string) error {
+	switch s := scheme.(type) {
+	case a2acore.APIKeySecurityScheme:
+		if s.In != a2acore.APIKeySecuritySchemeInHeader {
+			return fmt.Errorf("scheme %s: API keys in %s are not supported", name, s.In)
+		}
+		key, err := i.creds.apiKey()
+		if err != nil {
+			return err
+		}
+		if key == "" {
+			return fmt.Errorf("scheme %s: no API key configured", name)
+		}
+		meta[s.Name] = key
+	case a2acore.HTTPAuthSecurityScheme:
+		if !strings.EqualFold(s.Scheme, "bearer") {
+			return fmt.Errorf("scheme %s: HTTP %s authentication is not supported", name, s.Scheme)
+		}
+		token, err := i.creds.bearerToken()
+		if err != nil {
+			return err
+		}
+		if token == "" {
+			return fmt.Errorf("scheme %s: no bearer token configured", name)
+		}
+		meta["Authorization"] = "Bearer " + token
+	default:
+		return fmt.Errorf("scheme %s: unsupported or undeclared", name)
+	}
+	return nil
+}
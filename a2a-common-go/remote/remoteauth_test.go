@@ -0,0 +1,166 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package remote
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	a2acore "github.com/a2aproject/a2a-go/a2a"
+	"github.com/a2aproject/a2a-go/a2asrv"
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/runner"
+	"google.golang.org/adk/session"
+	"google.golang.org/genai"
+
+	"a2a-common-go/a2aserver"
+	"a2a-common-go/internal/testutil"
+)
+
+// newAuthPrimeServer serves a prime agent requiring an API key or a JWT.
+func newAuthPrimeServer(t *testing.T, keys *testutil.Keys, apiKey string) *httptest.Server {
+	t.Helper()
+	apiKeys := filepath.Join(t.TempDir(), "keys")
+	if err := os.WriteFile(apiKeys, []byte(apiKey+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	mux := http.NewServeMux()
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	a, err := a2aserver.NewAuthenticator(a2aserver.AuthConfig{APIKeysFile: apiKeys, JWKSFile: keys.JWKSFile}, srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	card := &a2acore.AgentCard{
+		Name:               "check_prime_agent",
+		URL:                srv.URL + a2aserver.InvokePath,
+		PreferredTransport: a2acore.TransportProtocolJSONRPC,
+	}
+	a.CardOption()(card)
+	mux.Handle(a2asrv.WellKnownAgentCardPath, a2asrv.NewStaticAgentCardHandler(card))
+	mux.Handle(a2aserver.InvokePath, a.Wrap(a2asrv.NewJSONRPCHandler(a2asrv.NewHandler(&fakePrimeExecutor{}))))
+	return srv
+}
+
+func TestRemoteCredentials(t *testing.T) {
+	keys := testutil.NewKeys(t)
+	srv := newAuthPrimeServer(t, keys, "prime-secret")
+	dir := t.TempDir()
+	token := filepath.Join(dir, "token")
+	if err := os.WriteFile(token, []byte(keys.Sign(t, "ES256", "ec-1", map[string]any{"aud": srv.URL, "exp": time.Now().Add(time.Hour).Unix()})+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PRIME_API_KEY", "prime-secret")
+	t.Setenv("WRONG_API_KEY", "guess")
+
+	tests := []struct {
+		name    string
+		creds   *remoteCredentials
+		wantErr string
+	}{
+		{name: "API key", creds: &remoteCredentials{APIKeyEnv: "PRIME_API_KEY"}},
+		{name: "bearer token", creds: &remoteCredentials{BearerTokenFile: token}},
+		{name: "wrong API key", creds: &remoteCredentials{APIKeyEnv: "WRONG_API_KEY"}, wantErr: "401"},
+		{name: "no credentials", wantErr: "401"},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			remote, err := NewAgent(AgentSpec{Name: "prime_agent", URL: srv.URL, Credentials: tt.creds})
+			if err != nil {
+				t.Fatal(err)
+			}
+			ctx := context.Background()
+			sessions := session.InMemoryService()
+			id := fmt.Sprintf("s%d", i)
+			if _, err := sessions.Create(ctx, &session.CreateRequest{AppName: "test", UserID: "user", SessionID: id}); err != nil {
+				t.Fatal(err)
+			}
+			r, err := runner.New(runner.Config{AppName: "test", Agent: remote, SessionService: sessions})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var text, errs strings.Builder
+			for event, err := range r.Run(ctx, "user", id, genai.NewContentFromText("Is 7 prime?", genai.RoleUser), agent.RunConfig{}) {
+				if err != nil {
+					errs.WriteString(err.Error())
+					continue
+				}
+				errs.WriteString(event.ErrorMessage)
+				if event.Content != nil {
+					for _, p := range event.Content.Parts {
+						text.WriteString(p.Text)
+					}
+				}
+			}
+			if tt.wantErr == "" {
+				if text.String() != "7 is prime." || errs.Len() > 0 {
+					t.Errorf("run = %q, error %q, want the answer", text.String(), errs.String())
+				}
+				return
+			}
+			if !strings.Contains(errs.String(), tt.wantErr) {
+				t.Errorf("run = %q, error %q, want %q", text.String(), errs.String(), tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRemoteCredentialsConfig(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "prime.jwt"), []byte("token\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	config := filepath.Join(dir, "agents.json")
+	content := `{"agents": [{"name": "prime_agent", "url": "http://prime:8086", "credentials": {"bearer_token_file": "prime.jwt"}}]}`
+	if err := os.WriteFile(config, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	specs, err := loadRemoteAgentsFile(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Credentials are kept when a flag moves the agent.
+	specs = mergeRemoteAgent(specs, AgentSpec{Name: "prime_agent", URL: "http://prime-2:8086"})
+	creds := specs[0].Credentials
+	if creds == nil || creds.BearerTokenFile != filepath.Join(dir, "prime.jwt") {
+		t.Fatalf("credentials = %+v, want the token file resolved against the config", creds)
+	}
+	if err := specs[0].validate(); err != nil {
+		t.Errorf("validate() error = %v", err)
+	}
+	if token, err := creds.bearerToken(); token != "token" || err != nil {
+		t.Errorf("bearerToken() = %q, %v, want the trimmed file content", token, err)
+	}
+
+	t.Setenv("UNSET_API_KEY", "")
+	for _, c := range []remoteCredentials{
+		{},
+		{APIKeyEnv: "UNSET_API_KEY"},
+		{BearerTokenFile: filepath.Join(dir, "missing.jwt")},
+		{APIKeyEnv: "PRIME_API_KEY", APIKeyFile: "key"},
+	} {
+		spec := AgentSpec{Name: "prime_agent", URL: "http://prime:8086", Credentials: &c}
+		if err := spec.validate(); err == nil {
+			t.Errorf("validate() with credentials %+v succeeded", c)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package remote
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"google.golang.org/adk/agent"
+)
+
+// --- Circuit Breaker ---
+//
+// Each call to a remote agent has a deadline, --remote_call_timeout
+// (REMOTE_CALL_TIMEOUT, 60s by default). After --remote_breaker_failures
+// (REMOTE_BREAKER_FAILURES, 3) consecutive failed or timed out calls the
+// circuit opens and calls are refused without reaching the agent. After
+// --remote_breaker_cooldown (REMOTE_BREAKER_COOLDOWN, 30s) it is half-open:
+// the next call goes through as a probe, closing the circuit if it succeeds
+// and opening it again if not.
+//
+// The state and counters of each agent are served as JSON on /status.
+
+const (
+	defaultRemoteCallTimeout     = 60 * time.Second
+	defaultRemoteBreakerFailures = 3
+	defaultRemoteBreakerCooldown = 30 * time.Second
+)
+
+// breakerConfig configures the circuit breakers of the remote agents.
+type breakerConfig struct {
+	callTimeout time.Duration
+	failures    int
+	cooldown    time.Duration
+}
+
+type breakerState string
+
+const (
+	breakerClosed   breakerState = "closed"
+	breakerOpen     breakerState = "open"
+	breakerHalfOpen breakerState = "half_open"
+)
+
+// circuitBreaker is the circuit breaker of one remote agent. It is guarded
+// by the Health mutex.
+type circuitBreaker struct {
+	state    breakerState
+	failures int // consecutive
+	openedAt time.Time
+	probeAt  time.Time // when the half-open probe call started
+}
+
+// allow reports whether a call may go through, moving an open circuit to
+// half-open once the cooldown is over. A half-open circuit lets a single
+// call through, and another one if that call has not ended by its deadline.
+func (b *circuitBreaker) allow(cfg breakerConfig, now time.Time) bool {
+	switch b.state {
+	case breakerOpen:
+		if now.Sub(b.openedAt) < cfg.cooldown {
+			return false
+		}
+	case breakerHalfOpen:
+		if now.Sub(b.probeAt) < cfg.callTimeout {
+			return false
+		}
+	default:
+		return true
+	}
+	b.state = breakerHalfOpen
+	b.probeAt = now
+	return true
+}
+
+func (b *circuitBreaker) success() {
+	b.state = breakerClosed
+	b.failures = 0
+}
+
+func (b *circuitBreaker) failure(cfg breakerConfig, now time.Time) {
+	b.failures++
+	if b.state == breakerHalfOpen || b.failures >= cfg.failures {
+		b.state = breakerOpen
+		b.openedAt = now
+	}
+}
+
+// deadlineContext is an invocation context with its own deadline.
+type deadlineContext struct {
+	agent.InvocationContext
+	ctx context.Context
+}
+
+func (c deadlineContext) Deadline() (time.Time, bool) { return c.ctx.Deadline() }
+func (c deadlineContext) Done() <-chan struct{}       { return c.ctx.Done() }
+func (c deadlineContext) Err() error                  { return c.ctx.Err() }
+func (c deadlineContext) Value(key any) any           { return c.ctx.Value(key) }
+
+// remoteAgentStatus is the status of a remote agent served on /status.
+type remoteAgentStatus struct {
+	Name                string       `json:"name"`
+	Source              string       `json:"source"`
+	Available           bool         `json:"available"`
+	ProbeError          string       `json:"probe_error,omitempty"`
+	LastProbe           *time.Time   `json:"last_probe,omitempty"`
+	Breaker             breakerState `json:"breaker"`
+	ConsecutiveFailures int          `json:"consecutive_failures"`
+	Calls               int64        `json:"calls"`
+	Failures            int64        `json:"failures"`
+	Timeouts            int64        `json:"timeouts"`
+	Rejected            int64        `json:"rejected"`
+	LastError           string       `json:"last_error,omitempty"`
+	LastLatencyMillis   int64        `json:"last_latency_ms"`
+}
+
+// statusOf returns the status of all remote agents.
+func (h *Health) statusOf() []remoteAgentStatus {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	var out []remoteAgentStatus
+	for _, s := range h.specs {
+		st := h.status[s.Name]
+		source := s.URL
+		if source == "" {
+			source = s.CardFile
+		}
+		as := remoteAgentStatus{
+			Name:                s.Name,
+			Source:              source,
+			Available:           st.available,
+			ProbeError:          st.err,
+			Breaker:             st.breaker.state,
+			ConsecutiveFailures: st.breaker.failures,
+			Calls:               st.calls,
+			Failures:            st.failures,
+			Timeouts:            st.timeouts,
+			Rejected:            st.rejected,
+			LastError:           st.lastErr,
+			LastLatencyMillis:   st.lastLatency.Milliseconds(),
+		}
+		if !st.checked.IsZero() {
+			checked := st.checked
+			as.LastProbe = &checked
+		}
+		out = append(out, as)
+	}
+	return out
+}
+
+// ServeHTTP serves the status of the remote agents.
+func (h *Health) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{"agents": h.statusOf()})
+}
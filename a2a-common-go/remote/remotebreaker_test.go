@@ -0,0 +1,192 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package remote
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	a2acore "github.com/a2aproject/a2a-go/a2a"
+	"github.com/a2aproject/a2a-go/a2asrv"
+	"github.com/a2aproject/a2a-go/a2asrv/eventqueue"
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/agent/remoteagent"
+	"google.golang.org/adk/runner"
+	"google.golang.org/adk/session"
+	"google.golang.org/genai"
+
+	"a2a-common-go/a2aserver"
+)
+
+// fakePrimeExecutor answers every message after delay.
+type fakePrimeExecutor struct {
+	delay atomic.Int64 // nanoseconds
+	calls atomic.Int32
+}
+
+func (e *fakePrimeExecutor) Execute(ctx context.Context, reqCtx *a2asrv.RequestContext, queue eventqueue.Queue) error {
+	e.calls.Add(1)
+	select {
+	case <-time.After(time.Duration(e.delay.Load())):
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return queue.Write(ctx, a2acore.NewMessage(a2acore.MessageRoleAgent, a2acore.TextPart{Text: "7 is prime."}))
+}
+
+func (e *fakePrimeExecutor) Cancel(ctx context.Context, reqCtx *a2asrv.RequestContext, queue eventqueue.Queue) error {
+	return nil
+}
+
+// newFakePrimeServer serves the executor over A2A like a2aserver.Serve.
+func newFakePrimeServer(t *testing.T, executor a2asrv.AgentExecutor) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	mux.Handle(a2asrv.WellKnownAgentCardPath, a2asrv.NewStaticAgentCardHandler(&a2acore.AgentCard{
+		Name:               "check_prime_agent",
+		URL:                srv.URL + a2aserver.InvokePath,
+		PreferredTransport: a2acore.TransportProtocolJSONRPC,
+		Capabilities:       a2acore.AgentCapabilities{Streaming: true},
+	}))
+	mux.Handle(a2aserver.InvokePath, a2asrv.NewJSONRPCHandler(a2asrv.NewHandler(executor)))
+	return srv
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	executor := &fakePrimeExecutor{}
+	srv := newFakePrimeServer(t, executor)
+	spec := AgentSpec{Name: "prime_agent", URL: srv.URL}
+	cfg := breakerConfig{callTimeout: 200 * time.Millisecond, failures: 2, cooldown: 300 * time.Millisecond}
+	h := NewHealth([]AgentSpec{spec}, cfg)
+	remote, err := remoteagent.New(remoteagent.A2AConfig{Name: spec.Name, AgentCardSource: spec.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	wrapped, err := h.wrap(remote)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	sessions := session.InMemoryService()
+	r, err := runner.New(runner.Config{AppName: "test", Agent: wrapped, SessionService: sessions})
+	if err != nil {
+		t.Fatal(err)
+	}
+	turn := 0
+	run := func() string {
+		t.Helper()
+		// A new session each time, so the whole conversation is sent.
+		turn++
+		id := fmt.Sprintf("s%d", turn)
+		if _, err := sessions.Create(ctx, &session.CreateRequest{AppName: "test", UserID: "user", SessionID: id}); err != nil {
+			t.Fatal(err)
+		}
+		var text strings.Builder
+		for event, err := range r.Run(ctx, "user", id, genai.NewContentFromText("Is 7 prime?", genai.RoleUser), agent.RunConfig{}) {
+			if err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+			if event.Content != nil {
+				for _, p := range event.Content.Parts {
+					text.WriteString(p.Text)
+				}
+			}
+		}
+		return text.String()
+	}
+	status := func() remoteAgentStatus {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/status", nil))
+		var body struct {
+			Agents []remoteAgentStatus `json:"agents"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil || len(body.Agents) != 1 {
+			t.Fatalf("status = %s, %v", rec.Body, err)
+		}
+		return body.Agents[0]
+	}
+
+	if got := run(); got != "7 is prime." {
+		t.Fatalf("run with the agent up = %q, want the answer", got)
+	}
+
+	// Slow calls time out and open the circuit.
+	executor.delay.Store(int64(2 * time.Second))
+	for i := 0; i < 2; i++ {
+		start := time.Now()
+		if got := run(); !strings.Contains(got, "no answer within 200ms") {
+			t.Errorf("slow run %d = %q, want a timeout message", i, got)
+		}
+		if d := time.Since(start); d > time.Second {
+			t.Errorf("slow run %d took %s, want it cut at the deadline", i, d)
+		}
+	}
+	if got := status(); got.Breaker != breakerOpen || got.Calls != 3 || got.Failures != 2 || got.Timeouts != 2 || got.ConsecutiveFailures != 2 {
+		t.Errorf("status after timeouts = %+v, want open with 3 calls, 2 timeouts", got)
+	}
+
+	// An open circuit refuses calls without reaching the agent.
+	calls := executor.calls.Load()
+	if got := run(); !strings.Contains(got, "prime_agent is currently unavailable") {
+		t.Errorf("run with the circuit open = %q, want an explanation", got)
+	}
+	if executor.calls.Load() != calls {
+		t.Error("agent called with the circuit open")
+	}
+	if got := status(); got.Rejected != 1 {
+		t.Errorf("status rejected = %d, want 1", got.Rejected)
+	}
+
+	// After the cooldown a probe call goes through and closes the circuit.
+	executor.delay.Store(0)
+	time.Sleep(cfg.cooldown)
+	if got := run(); got != "7 is prime." {
+		t.Errorf("run after the cooldown = %q, want the answer", got)
+	}
+	if got := status(); got.Breaker != breakerClosed || got.ConsecutiveFailures != 0 || got.Calls != 4 {
+		t.Errorf("status after recovery = %+v, want closed with 4 calls", got)
+	}
+}
+
+func TestCircuitBreakerHalfOpen(t *testing.T) {
+	cfg := breakerConfig{callTimeout: time.Minute, failures: 1, cooldown: time.Minute}
+	now := time.Now()
+	b := circuitBreaker{state: breakerClosed}
+	b.failure(cfg, now)
+	if b.state != breakerOpen || b.allow(cfg, now.Add(time.Second)) {
+		t.Fatalf("breaker after a failure = %+v, want open and refusing calls", b)
+	}
+	if !b.allow(cfg, now.Add(cfg.cooldown)) || b.state != breakerHalfOpen {
+		t.Fatalf("breaker after the cooldown = %+v, want half-open letting a call through", b)
+	}
+	if b.allow(cfg, now.Add(cfg.cooldown+time.Second)) {
+		t.Error("half-open breaker let a second call through")
+	}
+	b.failure(cfg, now.Add(cfg.cooldown+time.Second))
+	if b.state != breakerOpen {
+		t.Errorf("breaker after a failed probe = %+v, want open", b)
+	}
+}
@@ -0,0 +1,357 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package remote
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	a2acore "github.com/a2aproject/a2a-go/a2a"
+	"github.com/a2aproject/a2a-go/a2aclient/agentcard"
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/session"
+	"google.golang.org/genai"
+)
+
+// --- Remote Agent Health ---
+//
+// Remote agents are probed at startup and then every --remote_health_interval
+// (REMOTE_HEALTH_INTERVAL, 30s by default, 0 to only probe at startup): an
+// agent at a URL is up when its agent card can be fetched, one given by a
+// card file when the server in the card answers at all. Calls failing to
+// reach an agent open its circuit breaker, see circuitBreaker.
+//
+// The root agent is told which agents are offline so it does not transfer to
+// them, and an agent that is down answers with an explanation instead of an
+// error if it is transferred to anyway.
+
+const (
+	defaultRemoteHealthInterval = 30 * time.Second
+	remoteProbeTimeout          = 5 * time.Second
+)
+
+// Health tracks the availability of the remote agents.
+type Health struct {
+	breaker breakerConfig
+	client  *http.Client
+
+	mu     sync.Mutex
+	specs  []AgentSpec
+	status map[string]*remoteStatus // by agent name
+}
+
+// remoteStatus is the last known availability of a remote agent.
+type remoteStatus struct {
+	available bool
+	err       string // why the last probe failed
+	checked   time.Time
+	card      *a2acore.AgentCard // as last read by a probe
+
+	breaker     circuitBreaker
+	calls       int64
+	failures    int64
+	timeouts    int64
+	rejected    int64
+	lastErr     string
+	lastLatency time.Duration
+}
+
+// NewHealth tracks the availability of the remote agents in specs, each
+// behind a circuit breaker configured by breaker. Agents are probed by
+// resolving their agent card from their URL or, for agents given by a card
+// file, by sending a GET request to the card's URL. NewHealth does not
+// probe: Watch must be started separately to probe the agents periodically,
+// and until an agent is first probed it counts as available.
+func NewHealth(specs []AgentSpec, breaker breakerConfig) *Health {
+	h := &Health{
+		breaker: breaker,
+		client:  &http.Client{Timeout: remoteProbeTimeout, Transport: remoteTransport},
+		status:  make(map[string]*remoteStatus),
+	}
+	h.update(specs)
+	return h
+}
+
+// update sets the remote agents to track, keeping the status of those
+// already tracked.
+func (h *Health) update(specs []AgentSpec) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	status := make(map[string]*remoteStatus)
+	for _, s := range specs {
+		st, ok := h.status[s.Name]
+		if !ok {
+			// Agents count as available until probed.
+			st = &remoteStatus{available: true, breaker: circuitBreaker{state: breakerClosed}}
+		}
+		status[s.Name] = st
+	}
+	h.specs = append([]AgentSpec(nil), specs...)
+	h.status = status
+}
+
+// agents returns the tracked remote agents.
+func (h *Health) agents() []AgentSpec {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.specs
+}
+
+// checkAll probes all remote agents concurrently.
+func (h *Health) checkAll(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, s := range h.agents() {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			card, err := h.probe(ctx, s)
+			h.set(s.Name, card, err)
+		}()
+	}
+	wg.Wait()
+}
+
+// Watch probes the remote agents every interval until ctx is done.
+func (h *Health) Watch(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			h.checkAll(ctx)
+		}
+	}
+}
+
+// probe checks that the remote agent can be reached, returning its card.
+func (h *Health) probe(ctx context.Context, s AgentSpec) (*a2acore.AgentCard, error) {
+	ctx, cancel := context.WithTimeout(ctx, remoteProbeTimeout)
+	defer cancel()
+	if s.URL != "" {
+		return agentcard.NewResolver(h.client).Resolve(ctx, s.URL)
+	}
+	card, err := LoadAgentCard(s.CardFile)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, card.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+	return card, nil
+}
+
+// set records the outcome of reaching the agent, logging changes.
+func (h *Health) set(name string, card *a2acore.AgentCard, err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	st, ok := h.status[name]
+	if !ok {
+		return
+	}
+	available := err == nil
+	switch {
+	case available && (!st.available || st.checked.IsZero()):
+		log.Printf("Remote agent %s is available", name)
+	case !available && st.available:
+		log.Printf("Remote agent %s is unavailable: %v", name, err)
+	}
+	st.available = available
+	st.err = ""
+	if err != nil {
+		st.err = err.Error()
+	}
+	st.checked = time.Now()
+	if card != nil {
+		st.card = card
+	}
+}
+
+// card returns the agent card of the agent, if a probe read it.
+func (h *Health) card(name string) *a2acore.AgentCard {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if st, ok := h.status[name]; ok {
+		return st.card
+	}
+	return nil
+}
+
+// unavailable returns why the agent is unavailable, or "" if it is available
+// or its circuit is due for a probe.
+func (h *Health) unavailable(name string) string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	st, ok := h.status[name]
+	switch {
+	case !ok:
+		return ""
+	case !st.available:
+		return st.err
+	case st.breaker.state == breakerOpen && time.Since(st.breaker.openedAt) < h.breaker.cooldown:
+		return fmt.Sprintf("%d calls failed, last with: %s", st.breaker.failures, st.lastErr)
+	}
+	return ""
+}
+
+// begin checks that a call to the agent may go through, returning why not
+// otherwise.
+func (h *Health) begin(name string) string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	st, ok := h.status[name]
+	if !ok {
+		// The agent left the registry while an old root agent still uses it.
+		return ""
+	}
+	if !st.available {
+		st.rejected++
+		return st.err
+	}
+	if !st.breaker.allow(h.breaker, time.Now()) {
+		st.rejected++
+		if st.breaker.state == breakerHalfOpen {
+			return "another call is checking whether it recovered"
+		}
+		return fmt.Sprintf("%d calls failed, last with: %s", st.breaker.failures, st.lastErr)
+	}
+	st.calls++
+	return ""
+}
+
+// end records the outcome of a call to the agent.
+func (h *Health) end(name string, latency time.Duration, callErr error, timedOut bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	st, ok := h.status[name]
+	if !ok {
+		return
+	}
+	st.lastLatency = latency
+	if callErr == nil {
+		if st.breaker.state != breakerClosed {
+			log.Printf("Remote agent %s recovered, closing its circuit", name)
+		}
+		st.breaker.success()
+		return
+	}
+	st.failures++
+	if timedOut {
+		st.timeouts++
+	}
+	st.lastErr = callErr.Error()
+	wasOpen := st.breaker.state == breakerOpen
+	st.breaker.failure(h.breaker, time.Now())
+	if !wasOpen && st.breaker.state == breakerOpen {
+		log.Printf("Remote agent %s failed %d times, opening its circuit for %s: %v", name, st.breaker.failures, h.breaker.cooldown, callErr)
+	}
+}
+
+// wrap makes the remote agent answer with an explanation when it is down,
+// and bounds and records its calls.
+func (h *Health) wrap(remote agent.Agent) (agent.Agent, error) {
+	name := remote.Name()
+	return agent.New(agent.Config{
+		Name:        name,
+		Description: remote.Description(),
+		BeforeAgentCallbacks: []agent.BeforeAgentCallback{
+			func(agent.CallbackContext) (*genai.Content, error) {
+				if reason := h.begin(name); reason != "" {
+					return genai.NewContentFromText(offlineMessage(name, reason), genai.RoleModel), nil
+				}
+				return nil, nil
+			},
+		},
+		Run: func(ic agent.InvocationContext) iter.Seq2[*session.Event, error] {
+			return func(yield func(*session.Event, error) bool) {
+				ctx, cancel := context.WithTimeout(ic, h.breaker.callTimeout)
+				defer cancel()
+				start := time.Now()
+				var callErr error
+				timedOut := false
+				defer func() { h.end(name, time.Since(start), callErr, timedOut) }()
+
+				for event, err := range remote.Run(deadlineContext{InvocationContext: ic, ctx: ctx}) {
+					// The remote agent reports failing to reach the server as
+					// an event with an error message and no content.
+					if event != nil && event.ErrorMessage != "" && event.Content == nil {
+						reason := event.ErrorMessage
+						if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+							timedOut = true
+							reason = fmt.Sprintf("no answer within %s", h.breaker.callTimeout)
+						}
+						callErr = errors.New(reason)
+						event.Content = genai.NewContentFromText(offlineMessage(name, reason), genai.RoleModel)
+						event.ErrorMessage = ""
+					}
+					if !yield(event, err) {
+						return
+					}
+				}
+			}
+		},
+	})
+}
+
+func offlineMessage(name, reason string) string {
+	return fmt.Sprintf("Sorry, %s is currently unavailable, so I can't help with that right now (%s). Please try again later.", name, reason)
+}
+
+// InstructOffline is a BeforeModelCallback telling the model which remote
+// agents are offline.
+func (h *Health) InstructOffline(ctx agent.CallbackContext, req *model.LLMRequest) (*model.LLMResponse, error) {
+	var offline strings.Builder
+	for _, s := range h.agents() {
+		if h.unavailable(s.Name) == "" {
+			continue
+		}
+		desc := s.Description
+		if desc == "" {
+			desc = "remote agent"
+		}
+		fmt.Fprintf(&offline, "\n- %s (%s)", s.Name, desc)
+	}
+	if offline.Len() == 0 {
+		return nil, nil
+	}
+	inst := "These agents are currently offline, do not transfer to them. If the user asks for something only they can do, tell the user that this capability is temporarily unavailable:" + offline.String()
+	if req.Config == nil {
+		req.Config = &genai.GenerateContentConfig{}
+	}
+	if req.Config.SystemInstruction == nil {
+		req.Config.SystemInstruction = genai.NewContentFromText(inst, genai.RoleUser)
+	} else {
+		req.Config.SystemInstruction.Parts = append(req.Config.SystemInstruction.Parts, genai.NewPartFromText(inst))
+	}
+	return nil, nil
+}
@@ -0,0 +1,149 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package remote
+
+import (
+	"context"
+	"iter"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	a2acore "github.com/a2aproject/a2a-go/a2a"
+	"github.com/a2aproject/a2a-go/a2asrv"
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/runner"
+	"google.golang.org/adk/session"
+	"google.golang.org/genai"
+
+	"a2a-common-go/a2aserver"
+)
+
+func TestRemoteHealth(t *testing.T) {
+	mux := http.NewServeMux()
+	srv := httptest.NewServer(mux)
+	mux.Handle(a2asrv.WellKnownAgentCardPath, a2asrv.NewStaticAgentCardHandler(&a2acore.AgentCard{
+		Name: "check_prime_agent",
+		URL:  srv.URL + a2aserver.InvokePath,
+	}))
+	cardFile := filepath.Join(t.TempDir(), "prime.json")
+	card := `{"name": "check_prime_agent", "url": "` + srv.URL + a2aserver.InvokePath + `"}`
+	if err := os.WriteFile(cardFile, []byte(card), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	h := NewHealth([]AgentSpec{
+		{Name: "prime_agent", Description: "Checks primes.", URL: srv.URL},
+		{Name: "offline_prime_agent", CardFile: cardFile},
+	}, breakerConfig{callTimeout: time.Second, failures: 1, cooldown: time.Minute})
+	ctx := context.Background()
+	h.checkAll(ctx)
+	for _, name := range []string{"prime_agent", "offline_prime_agent"} {
+		if reason := h.unavailable(name); reason != "" {
+			t.Errorf("%s unavailable: %s", name, reason)
+		}
+	}
+	req := &model.LLMRequest{}
+	if _, err := h.InstructOffline(nil, req); err != nil || req.Config != nil {
+		t.Errorf("instructOffline() with all agents available changed the request: %+v, %v", req.Config, err)
+	}
+
+	srv.Close()
+	h.checkAll(ctx)
+	for _, name := range []string{"prime_agent", "offline_prime_agent"} {
+		if h.unavailable(name) == "" {
+			t.Errorf("%s available after the server stopped", name)
+		}
+	}
+	if _, err := h.InstructOffline(nil, req); err != nil {
+		t.Fatalf("instructOffline() error = %v", err)
+	}
+	inst := req.Config.SystemInstruction.Parts[0].Text
+	for _, want := range []string{"- prime_agent (Checks primes.)", "- offline_prime_agent (remote agent)"} {
+		if !strings.Contains(inst, want) {
+			t.Errorf("instruction %q does not contain %q", inst, want)
+		}
+	}
+}
+
+func TestRemoteHealthWrap(t *testing.T) {
+	h := NewHealth([]AgentSpec{{Name: "prime_agent", URL: "http://localhost:1"}}, breakerConfig{callTimeout: time.Second, failures: 1, cooldown: time.Minute})
+	calls := 0
+	remote, err := agent.New(agent.Config{
+		Name: "prime_agent",
+		Run: func(ic agent.InvocationContext) iter.Seq2[*session.Event, error] {
+			return func(yield func(*session.Event, error) bool) {
+				calls++
+				event := session.NewEvent(ic.InvocationID())
+				event.ErrorMessage = "connection refused"
+				yield(event, nil)
+			}
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	wrapped, err := h.wrap(remote)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	sessions := session.InMemoryService()
+	if _, err := sessions.Create(ctx, &session.CreateRequest{AppName: "test", UserID: "user", SessionID: "s"}); err != nil {
+		t.Fatal(err)
+	}
+	r, err := runner.New(runner.Config{AppName: "test", Agent: wrapped, SessionService: sessions})
+	if err != nil {
+		t.Fatal(err)
+	}
+	run := func() string {
+		var text strings.Builder
+		for event, err := range r.Run(ctx, "user", "s", genai.NewContentFromText("Is 7 prime?", genai.RoleUser), agent.RunConfig{}) {
+			if err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+			if event.ErrorMessage != "" {
+				t.Errorf("event error = %q, want a message", event.ErrorMessage)
+			}
+			if event.Content != nil {
+				for _, p := range event.Content.Parts {
+					text.WriteString(p.Text)
+				}
+			}
+		}
+		return text.String()
+	}
+
+	want := "prime_agent is currently unavailable"
+	if got := run(); !strings.Contains(got, want) || !strings.Contains(got, "connection refused") {
+		t.Errorf("first run = %q, want it to contain %q", got, want)
+	}
+	if h.unavailable("prime_agent") == "" {
+		t.Error("prime_agent available after a failed call")
+	}
+	// Once the agent is known to be down, it is not called at all.
+	if got := run(); !strings.Contains(got, want) {
+		t.Errorf("second run = %q, want it to contain %q", got, want)
+	}
+	if calls != 1 {
+		t.Errorf("remote agent called %d times, want 1", calls)
+	}
+}
@@ -0,0 +1,221 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package remote
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"iter"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"sync"
+
+	a2acore "github.com/a2aproject/a2a-go/a2a"
+	"github.com/a2aproject/a2a-go/a2aclient"
+)
+
+// --- Remote Agent Push Notifications ---
+//
+// With --remote_push or REMOTE_PUSH, remote agents whose card declares push
+// notifications are called without holding a connection open during their
+// task. The message is sent non-blocking, with a webhook on this server and a
+// token for the call; the remote agent answers at once with its task, and the
+// invocation of the root agent waits until the webhook receives the task in
+// a final state, then resumes with it. The wait is bounded by the call
+// timeout of the remote agent, see remotebreaker.go.
+//
+// The webhook is served at /a2a/push under the public URL of the server, see
+// package a2aserver, which the remote agents must be able to reach and allow,
+// see --push_hosts. It is authenticated by the token of each call, not by the
+// authentication of A2A callers, but with mTLS, see a2aserver.Config, the
+// remote agents would need a client certificate to reach it.
+
+const (
+	remotePushEnv = "REMOTE_PUSH"
+
+	// PushPath is where push notifications are received.
+	PushPath = "/a2a/push"
+
+	// maxPushSize bounds the size of the tasks received.
+	maxPushSize = 16 << 20
+)
+
+// remotePush receives the push notifications of remote agents, nil if they
+// are not used. It is set by UsePush at startup.
+var remotePush *PushReceiver
+
+// PushFlag registers --remote_push on fs.
+func PushFlag(fs *flag.FlagSet) (*bool, error) {
+	enabled := false
+	if v := os.Getenv(remotePushEnv); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s %q: must be true or false", remotePushEnv, v)
+		}
+		enabled = b
+	}
+	return fs.Bool("remote_push", enabled, "Wait for the tasks of remote agents supporting push notifications on a webhook rather than an open connection. Defaults to $"+remotePushEnv+"."), nil
+}
+
+// UsePush calls remote agents with push notifications to the receiver.
+// Call it before creating the remote agents.
+func UsePush(r *PushReceiver) {
+	remotePush = r
+}
+
+// PushReceiver is the webhook resuming the calls waiting for their task.
+type PushReceiver struct {
+	URL string // of the webhook, for remote agents
+
+	mu      sync.Mutex
+	waiting map[string]chan *a2acore.Task // by token
+}
+
+// NewPushReceiver returns the receiver of the server at publicURL.
+func NewPushReceiver(publicURL string) (*PushReceiver, error) {
+	u, err := url.JoinPath(publicURL, PushPath)
+	if err != nil {
+		return nil, err
+	}
+	return &PushReceiver{URL: u, waiting: make(map[string]chan *a2acore.Task)}, nil
+}
+
+// wait returns a new token and the channel receiving the latest task pushed
+// with it, until done is called.
+func (r *PushReceiver) wait() (token string, pushed <-chan *a2acore.Task, done func()) {
+	token = rand.Text()
+	ch := make(chan *a2acore.Task, 1)
+	r.mu.Lock()
+	r.waiting[token] = ch
+	r.mu.Unlock()
+	return token, ch, func() {
+		r.mu.Lock()
+		delete(r.waiting, token)
+		r.mu.Unlock()
+	}
+}
+
+func (r *PushReceiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	token := req.Header.Get("X-A2A-Notification-Token")
+	r.mu.Lock()
+	ch, ok := r.waiting[token]
+	r.mu.Unlock()
+	if token == "" || !ok {
+		// The call may have timed out since.
+		log.Printf("Ignored push notification from %s: unknown token", req.RemoteAddr)
+		http.Error(w, "unknown token", http.StatusNotFound)
+		return
+	}
+	var task a2acore.Task
+	if err := json.NewDecoder(http.MaxBytesReader(w, req.Body, maxPushSize)).Decode(&task); err != nil {
+		http.Error(w, fmt.Sprintf("invalid task: %v", err), http.StatusBadRequest)
+		return
+	}
+	// Only the latest state matters: replace the one not yet read, if any.
+	r.mu.Lock()
+	select {
+	case <-ch:
+	default:
+	}
+	ch <- &task
+	r.mu.Unlock()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// withRemotePush makes the clients of the factory of the remote agent wait
+// for the tasks of agents supporting push notifications on the receiver, if
+// remote push is used.
+func withRemotePush(agent string, f *a2aclient.Factory) *a2aclient.Factory {
+	if remotePush == nil {
+		return f
+	}
+	r := remotePush
+	return a2aclient.WithAdditionalOptions(f, a2aclient.WithTransport(a2acore.TransportProtocolJSONRPC, a2aclient.TransportFactoryFn(func(ctx context.Context, u string, card *a2acore.AgentCard) (a2aclient.Transport, error) {
+		t := newTaskTransport(agent, u, card)
+		if card == nil || !card.Capabilities.PushNotifications {
+			return t, nil
+		}
+		return &pushTransport{taskTransport: t, receiver: r}, nil
+	})))
+}
+
+// pushTransport sends messages non-blocking and waits for their task on the
+// receiver.
+type pushTransport struct {
+	*taskTransport
+	receiver *PushReceiver
+}
+
+func (t *pushTransport) SendMessage(ctx context.Context, message *a2acore.MessageSendParams) (a2acore.SendMessageResult, error) {
+	token, pushed, done := t.receiver.wait()
+	defer done()
+
+	params := *message
+	config := a2acore.MessageSendConfig{}
+	if params.Config != nil {
+		config = *params.Config
+	}
+	blocking := false
+	config.Blocking = &blocking
+	config.PushConfig = &a2acore.PushConfig{URL: t.receiver.URL, Token: token}
+	params.Config = &config
+
+	result, err := t.Transport.SendMessage(ctx, &params)
+	if err != nil {
+		return nil, err
+	}
+	task, ok := result.(*a2acore.Task)
+	if !ok {
+		return result, nil
+	}
+	defer t.track(ctx, task.ID)()
+	for !settled(task.Status.State) {
+		select {
+		case task = <-pushed:
+		case <-ctx.Done():
+			t.abandon(ctx, task.ID)
+			return nil, fmt.Errorf("no push notification completing task %s: %w", task.ID, context.Cause(ctx))
+		}
+	}
+	return task, nil
+}
+
+// SendStreamingMessage does not stream: the task is sent once settled.
+func (t *pushTransport) SendStreamingMessage(ctx context.Context, message *a2acore.MessageSendParams) iter.Seq2[a2acore.Event, error] {
+	return func(yield func(a2acore.Event, error) bool) {
+		result, err := t.SendMessage(ctx, message)
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+		switch v := result.(type) {
+		case *a2acore.Task:
+			yield(v, nil)
+		case *a2acore.Message:
+			yield(v, nil)
+		}
+	}
+}
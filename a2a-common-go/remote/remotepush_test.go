@@ -0,0 +1,273 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package remote
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"iter"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	a2acore "github.com/a2aproject/a2a-go/a2a"
+	"github.com/a2aproject/a2a-go/a2asrv"
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/runner"
+	"google.golang.org/adk/session"
+	"google.golang.org/genai"
+
+	"a2a-common-go/a2aserver"
+)
+
+// pushHarness is a slow factoring agent served over A2A with push
+// notifications, and a master receiving them.
+type pushHarness struct {
+	agentURL string
+	receiver *PushReceiver
+	started  chan struct{} // closed when the agent starts working
+	release  chan struct{} // close to let the agent answer
+	inFlight atomic.Int32  // A2A requests being served
+
+	mu     sync.Mutex
+	pushed []a2acore.TaskState // states pushed to the master
+}
+
+func newPushHarness(t *testing.T, hosts a2aserver.PushHosts) *pushHarness {
+	t.Helper()
+	h := &pushHarness{started: make(chan struct{}), release: make(chan struct{})}
+	factor, err := agent.New(agent.Config{
+		Name:        "factor_agent",
+		Description: "Factors huge numbers.",
+		Run: func(ic agent.InvocationContext) iter.Seq2[*session.Event, error] {
+			return func(yield func(*session.Event, error) bool) {
+				close(h.started)
+				select {
+				case <-h.release:
+				case <-ic.Done():
+					return
+				}
+				event := session.NewEvent(ic.InvocationID())
+				event.Author = ic.Agent().Name()
+				event.Content = genai.NewContentFromText("2^67 - 1 = 193707721 × 761838257287.", genai.RoleModel)
+				yield(event, nil)
+			}
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mux := http.NewServeMux()
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	h.agentURL = srv.URL
+	card, err := a2aserver.NewAgentCard(factor, srv.URL+a2aserver.InvokePath, a2aserver.WithPushNotifications(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	executor := a2aserver.NewLiveExecutor(func() agent.Agent { return factor }, session.InMemoryService(), agent.StreamingModeNone)
+	invoke := a2asrv.NewJSONRPCHandler(a2asrv.NewHandler(executor, hosts.Options()...))
+	mux.Handle(a2asrv.WellKnownAgentCardPath, a2asrv.NewStaticAgentCardHandler(card))
+	mux.Handle(a2aserver.InvokePath, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h.inFlight.Add(1)
+		defer h.inFlight.Add(-1)
+		invoke.ServeHTTP(w, r)
+	}))
+
+	master := http.NewServeMux()
+	masterSrv := httptest.NewServer(master)
+	t.Cleanup(masterSrv.Close)
+	if h.receiver, err = NewPushReceiver(masterSrv.URL); err != nil {
+		t.Fatal(err)
+	}
+	master.Handle(PushPath, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var task a2acore.Task
+		if json.Unmarshal(body, &task) == nil {
+			h.mu.Lock()
+			h.pushed = append(h.pushed, task.Status.State)
+			h.mu.Unlock()
+		}
+		r.Body = io.NopCloser(strings.NewReader(string(body)))
+		h.receiver.ServeHTTP(w, r)
+	}))
+	UsePush(h.receiver)
+	t.Cleanup(func() { UsePush(nil) })
+	return h
+}
+
+// run asks the remote agent through a runner, returning its text.
+func (h *pushHarness) run(ctx context.Context, sessions session.Service) (string, error) {
+	remote, err := NewAgent(AgentSpec{Name: "factor_agent", URL: h.agentURL})
+	if err != nil {
+		return "", err
+	}
+	if _, err := sessions.Create(ctx, &session.CreateRequest{AppName: "test", UserID: "user", SessionID: "s"}); err != nil {
+		return "", err
+	}
+	r, err := runner.New(runner.Config{AppName: "test", Agent: remote, SessionService: sessions})
+	if err != nil {
+		return "", err
+	}
+	var text strings.Builder
+	for event, err := range r.Run(ctx, "user", "s", genai.NewContentFromText("Factor 2^67 - 1.", genai.RoleUser), agent.RunConfig{}) {
+		if err != nil {
+			return "", err
+		}
+		if event.ErrorMessage != "" {
+			return "", errors.New(event.ErrorMessage)
+		}
+		if event.Content != nil && !event.Partial {
+			for _, p := range event.Content.Parts {
+				text.WriteString(p.Text)
+			}
+		}
+	}
+	return text.String(), nil
+}
+
+func TestRemotePush(t *testing.T) {
+	h := newPushHarness(t, "127.0.0.1")
+	ctx := context.Background()
+	sessions := session.InMemoryService()
+	type result struct {
+		text string
+		err  error
+	}
+	done := make(chan result, 1)
+	go func() {
+		text, err := h.run(ctx, sessions)
+		done <- result{text, err}
+	}()
+
+	select {
+	case <-h.started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the agent did not start")
+	}
+	// The message was answered at once: no connection waits for the task.
+	deadline := time.Now().Add(5 * time.Second)
+	for h.inFlight.Load() > 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if n := h.inFlight.Load(); n > 0 {
+		t.Errorf("%d A2A requests in flight while the task runs, want none", n)
+	}
+	select {
+	case r := <-done:
+		t.Fatalf("run ended before the task, with %q, %v", r.text, r.err)
+	default:
+	}
+
+	close(h.release)
+	var r result
+	select {
+	case r = <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the push notification did not resume the run")
+	}
+	if r.err != nil || !strings.Contains(r.text, "193707721") {
+		t.Fatalf("run = %q, %v, want the factors", r.text, r.err)
+	}
+
+	// Only the changes of state are pushed.
+	h.mu.Lock()
+	pushed := h.pushed
+	h.mu.Unlock()
+	want := []a2acore.TaskState{a2acore.TaskStateSubmitted, a2acore.TaskStateWorking, a2acore.TaskStateCompleted}
+	if len(pushed) != len(want) {
+		t.Fatalf("pushed states = %v, want %v", pushed, want)
+	}
+	for i := range want {
+		if pushed[i] != want[i] {
+			t.Fatalf("pushed states = %v, want %v", pushed, want)
+		}
+	}
+
+	// The answer is kept in the session.
+	s, err := sessions.Get(ctx, &session.GetRequest{AppName: "test", UserID: "user", SessionID: "s"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	kept := false
+	for event := range s.Session.Events().All() {
+		if event.Author == "factor_agent" && event.Content != nil && strings.Contains(event.Content.Parts[0].Text, "193707721") {
+			kept = true
+		}
+	}
+	if !kept {
+		t.Error("session lacks the answer")
+	}
+}
+
+func TestRemotePushHostNotAllowed(t *testing.T) {
+	h := newPushHarness(t, "master.example.com")
+	close(h.release)
+	_, err := h.run(context.Background(), session.InMemoryService())
+	if err == nil || !strings.Contains(err.Error(), "not allowed") {
+		t.Errorf("run error = %v, want the webhook refused", err)
+	}
+}
+
+func TestPushReceiver(t *testing.T) {
+	r, err := NewPushReceiver("http://localhost:8092")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.URL != "http://localhost:8092/a2a/push" {
+		t.Errorf("url = %q", r.URL)
+	}
+	token, pushed, done := r.wait()
+	defer done()
+
+	post := func(token, body string) int {
+		req := httptest.NewRequest(http.MethodPost, PushPath, strings.NewReader(body))
+		if token != "" {
+			req.Header.Set("X-A2A-Notification-Token", token)
+		}
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		return rec.Code
+	}
+	if code := post("", `{}`); code != http.StatusNotFound {
+		t.Errorf("POST without token = %d, want 404", code)
+	}
+	if code := post("guess", `{}`); code != http.StatusNotFound {
+		t.Errorf("POST with an unknown token = %d, want 404", code)
+	}
+	if code := post(token, `not json`); code != http.StatusBadRequest {
+		t.Errorf("POST of a broken task = %d, want 400", code)
+	}
+	// The latest state replaces the one not yet read.
+	for _, state := range []string{"working", "completed"} {
+		if code := post(token, `{"kind": "task", "id": "t1", "contextId": "c1", "status": {"state": "`+state+`"}}`); code != http.StatusNoContent {
+			t.Errorf("POST of a %s task = %d, want 204", state, code)
+		}
+	}
+	if task := <-pushed; task.Status.State != a2acore.TaskStateCompleted {
+		t.Errorf("pushed state = %s, want completed", task.Status.State)
+	}
+	done()
+	if code := post(token, `{}`); code != http.StatusNotFound {
+		t.Errorf("POST after the call ended = %d, want 404", code)
+	}
+}
@@ -0,0 +1,77 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package remote
+
+import (
+	"iter"
+	"strings"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/server/adka2a"
+	"google.golang.org/adk/session"
+	"google.golang.org/genai"
+)
+
+// --- Remote Agent Streaming ---
+//
+// Remote agents whose card declares streaming answer over message/stream, in
+// artifact updates which remoteagent turns into partial events. Clients can
+// show their text as it arrives, but the runner keeps no partial event in the
+// session: the answer would be missing from the conversation, and with it the
+// A2A context the next call to the agent continues. So, like models do when
+// streaming, the streamed text is also sent whole in a final event.
+
+// mergeRemoteStream makes the remote agent follow the text it streams with a
+// final event holding all of it.
+func mergeRemoteStream(remote agent.Agent) (agent.Agent, error) {
+	return agent.New(agent.Config{
+		Name:        remote.Name(),
+		Description: remote.Description(),
+		Run: func(ic agent.InvocationContext) iter.Seq2[*session.Event, error] {
+			return func(yield func(*session.Event, error) bool) {
+				var text strings.Builder
+				var last *session.Event // the last partial event
+				flush := func() bool {
+					if text.Len() == 0 {
+						return true
+					}
+					event := adka2a.NewRemoteAgentEvent(ic)
+					event.Content = genai.NewContentFromText(text.String(), genai.RoleModel)
+					event.CustomMetadata = adka2a.ToCustomMetadata(adka2a.GetA2ATaskInfo(last))
+					text.Reset()
+					return yield(event, nil)
+				}
+				for event, err := range remote.Run(ic) {
+					if err == nil && event != nil && event.Partial {
+						if event.Content != nil {
+							for _, p := range event.Content.Parts {
+								if !p.Thought {
+									text.WriteString(p.Text)
+								}
+							}
+						}
+						last = event
+					} else if !flush() {
+						return
+					}
+					if !yield(event, err) {
+						return
+					}
+				}
+				flush()
+			}
+		},
+	})
+}
@@ -0,0 +1,171 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package remote
+
+import (
+	"context"
+	"iter"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/a2aproject/a2a-go/a2asrv"
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/agent/llmagent"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/runner"
+	"google.golang.org/adk/server/adka2a"
+	"google.golang.org/adk/session"
+	"google.golang.org/genai"
+
+	"a2a-common-go/a2aserver"
+)
+
+// chunkedModel answers with chunks, then whole, when streaming, like Gemini.
+// Each chunk after the first waits for next, so that tests can check that
+// the previous one was received first.
+type chunkedModel struct {
+	chunks []string
+	next   chan struct{}
+}
+
+func (m *chunkedModel) Name() string { return "chunked" }
+
+func (m *chunkedModel) GenerateContent(ctx context.Context, req *model.LLMRequest, stream bool) iter.Seq2[*model.LLMResponse, error] {
+	return func(yield func(*model.LLMResponse, error) bool) {
+		whole := &model.LLMResponse{Content: genai.NewContentFromText(strings.Join(m.chunks, ""), genai.RoleModel), TurnComplete: true}
+		if !stream {
+			yield(whole, nil)
+			return
+		}
+		for i, c := range m.chunks {
+			if i > 0 {
+				select {
+				case <-m.next:
+				case <-ctx.Done():
+					return
+				}
+			}
+			if !yield(&model.LLMResponse{Content: genai.NewContentFromText(c, genai.RoleModel), Partial: true}, nil) {
+				return
+			}
+		}
+		yield(whole, nil)
+	}
+}
+
+// newStreamingPrimeServer serves a prime agent on the model over A2A like
+// a2aserver.Serve, in the streaming mode.
+func newStreamingPrimeServer(t *testing.T, m model.LLM, mode agent.StreamingMode) *httptest.Server {
+	t.Helper()
+	prime, err := llmagent.New(llmagent.Config{Name: "check_prime_agent", Description: "Checks primes.", Model: m})
+	if err != nil {
+		t.Fatal(err)
+	}
+	mux := http.NewServeMux()
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	card, err := a2aserver.NewAgentCard(prime, srv.URL+a2aserver.InvokePath, a2aserver.WithStreaming(mode == agent.StreamingModeSSE))
+	if err != nil {
+		t.Fatal(err)
+	}
+	executor := a2aserver.NewLiveExecutor(func() agent.Agent { return prime }, session.InMemoryService(), mode)
+	mux.Handle(a2asrv.WellKnownAgentCardPath, a2asrv.NewStaticAgentCardHandler(card))
+	mux.Handle(a2aserver.InvokePath, a2asrv.NewJSONRPCHandler(a2asrv.NewHandler(executor)))
+	return srv
+}
+
+func TestRemoteStreaming(t *testing.T) {
+	chunks := []string{"7 ", "is ", "prime."}
+	for _, mode := range []agent.StreamingMode{agent.StreamingModeSSE, agent.StreamingModeNone} {
+		t.Run(string(mode), func(t *testing.T) {
+			m := &chunkedModel{chunks: chunks, next: make(chan struct{})}
+			srv := newStreamingPrimeServer(t, m, mode)
+			remote, err := NewAgent(AgentSpec{Name: "prime_agent", URL: srv.URL})
+			if err != nil {
+				t.Fatal(err)
+			}
+			ctx := context.Background()
+			sessions := session.InMemoryService()
+			if _, err := sessions.Create(ctx, &session.CreateRequest{AppName: "test", UserID: "user", SessionID: "s"}); err != nil {
+				t.Fatal(err)
+			}
+			r, err := runner.New(runner.Config{AppName: "test", Agent: remote, SessionService: sessions})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var partial []string
+			var whole []string
+			for event, err := range r.Run(ctx, "user", "s", genai.NewContentFromText("Is 7 prime?", genai.RoleUser), agent.RunConfig{StreamingMode: mode}) {
+				if err != nil {
+					t.Fatalf("run error = %v", err)
+				}
+				if event.ErrorMessage != "" {
+					t.Fatalf("run error event: %s", event.ErrorMessage)
+				}
+				if event.Content == nil {
+					continue
+				}
+				for _, p := range event.Content.Parts {
+					if p.Text == "" {
+						continue
+					}
+					if !event.Partial {
+						whole = append(whole, p.Text)
+						continue
+					}
+					partial = append(partial, p.Text)
+					// The model sends the next chunk only once this one has
+					// arrived, so the test hangs unless they are streamed.
+					if len(partial) < len(chunks) {
+						m.next <- struct{}{}
+					}
+				}
+			}
+
+			wantPartial := chunks
+			if mode == agent.StreamingModeNone {
+				wantPartial = nil
+			}
+			if strings.Join(partial, "|") != strings.Join(wantPartial, "|") {
+				t.Errorf("partial text = %q, want %q", partial, wantPartial)
+			}
+			if len(whole) != 1 || whole[0] != "7 is prime." {
+				t.Errorf("whole text = %q, want the answer once", whole)
+			}
+
+			// The answer is kept in the session, with the A2A context to
+			// continue.
+			s, err := sessions.Get(ctx, &session.GetRequest{AppName: "test", UserID: "user", SessionID: "s"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var kept *session.Event
+			for event := range s.Session.Events().All() {
+				if event.Author == "prime_agent" && event.Content != nil {
+					kept = event
+				}
+			}
+			if kept == nil || kept.Content.Parts[0].Text != "7 is prime." {
+				t.Fatalf("session lacks the answer, last event of prime_agent = %+v", kept)
+			}
+			if _, contextID := adka2a.GetA2ATaskInfo(kept); contextID == "" {
+				t.Error("answer kept without its A2A context")
+			}
+		})
+	}
+}
@@ -0,0 +1,289 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package remote
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"log"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	a2acore "github.com/a2aproject/a2a-go/a2a"
+	"github.com/a2aproject/a2a-go/a2aclient"
+)
+
+// --- Remote Agent Tasks ---
+//
+// Each call to a remote agent runs as an A2A task, which is tracked from the
+// moment the agent reports its ID until it settles, so that:
+//
+//   - it can be canceled with tasks/cancel while in flight, e.g. with /cancel
+//     in the console of a2a-client-go, which stops the run of the remote agent
+//     and its tools;
+//   - when the invocation waiting for it is canceled or times out, e.g.
+//     because the task of this server was canceled, the remote task is
+//     canceled too rather than left running;
+//   - when the stream of a task breaks before it settles, the call follows it
+//     again with tasks/resubscribe, or failing that waits for it with
+//     tasks/get.
+//
+// Agents which do not stream are called with blocking message/send, which
+// only reports the task once settled. Such calls are sent non-blocking
+// instead, and the task is then polled with tasks/get.
+
+const (
+	// remoteTaskPollInterval is how often the tasks of agents which do not
+	// stream are polled.
+	remoteTaskPollInterval = time.Second
+
+	// CancelTimeout bounds the cancellation of abandoned tasks.
+	CancelTimeout = 10 * time.Second
+
+	// maxResubscribes bounds how many times a call follows its task again.
+	maxResubscribes = 3
+)
+
+// Tasks are the tasks of remote agents in flight.
+var Tasks = &TaskTracker{tasks: make(map[a2acore.TaskID]*Task)}
+
+// Task is a task of a remote agent in flight.
+type Task struct {
+	Agent   string
+	ID      a2acore.TaskID
+	Started time.Time
+	cancel  func(context.Context) (*a2acore.Task, error)
+}
+
+// TaskTracker records the tasks of remote agents while calls wait for them,
+// so that they can be listed and cancelled, e.g. by the console's /cancel
+// command. It is safe for concurrent use.
+type TaskTracker struct {
+	mu    sync.Mutex
+	tasks map[a2acore.TaskID]*Task
+}
+
+// add tracks the task until remove is called.
+func (r *TaskTracker) add(task *Task) (remove func()) {
+	r.mu.Lock()
+	r.tasks[task.ID] = task
+	r.mu.Unlock()
+	return func() {
+		r.mu.Lock()
+		delete(r.tasks, task.ID)
+		r.mu.Unlock()
+	}
+}
+
+// InFlight returns the tasks in flight, oldest first.
+func (r *TaskTracker) InFlight() []*Task {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var tasks []*Task
+	for _, t := range r.tasks {
+		tasks = append(tasks, t)
+	}
+	slices.SortFunc(tasks, func(a, b *Task) int { return a.Started.Compare(b.Started) })
+	return tasks
+}
+
+// Cancel cancels the task in flight with the given ID.
+func (r *TaskTracker) Cancel(ctx context.Context, id a2acore.TaskID) (*a2acore.Task, error) {
+	r.mu.Lock()
+	task, ok := r.tasks[id]
+	r.mu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("no remote task %s in flight", id)
+	}
+	return task.cancel(ctx)
+}
+
+// remoteTaskTransports returns the factory of the transports of the calls to
+// the remote agent.
+func remoteTaskTransports(agent string) a2aclient.TransportFactory {
+	return a2aclient.TransportFactoryFn(func(ctx context.Context, u string, card *a2acore.AgentCard) (a2aclient.Transport, error) {
+		return newTaskTransport(agent, u, card), nil
+	})
+}
+
+// taskTransport is a JSON-RPC transport tracking the tasks of its calls.
+type taskTransport struct {
+	a2aclient.Transport
+	agent     string
+	streaming bool // whether the agent supports tasks/resubscribe
+}
+
+func newTaskTransport(agent, u string, card *a2acore.AgentCard) *taskTransport {
+	return &taskTransport{
+		// Calls are bounded by the call timeout of the agent, see
+		// remotebreaker.go, not by the HTTP client.
+		Transport: a2aclient.NewJSONRPCTransport(u, &http.Client{Transport: remoteTransport}),
+		agent:     agent,
+		streaming: card != nil && card.Capabilities.Streaming,
+	}
+}
+
+// track tracks the task until untrack is called. ctx is the context of the
+// call, whose credentials cancellations are sent with.
+func (t *taskTransport) track(ctx context.Context, id a2acore.TaskID) (untrack func()) {
+	ctx = context.WithoutCancel(ctx)
+	return Tasks.add(&Task{
+		Agent:   t.agent,
+		ID:      id,
+		Started: time.Now(),
+		cancel: func(cancelCtx context.Context) (*a2acore.Task, error) {
+			// Keep the values of the call, such as its credentials.
+			ctx, cancel := context.WithCancel(ctx)
+			defer cancel()
+			stop := context.AfterFunc(cancelCtx, cancel)
+			defer stop()
+			return t.Transport.CancelTask(ctx, &a2acore.TaskIDParams{ID: id})
+		},
+	})
+}
+
+// abandon cancels the task, whose call is canceled or timed out.
+func (t *taskTransport) abandon(ctx context.Context, id a2acore.TaskID) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), CancelTimeout)
+	defer cancel()
+	if _, err := t.Transport.CancelTask(ctx, &a2acore.TaskIDParams{ID: id}); err != nil {
+		log.Printf("Failed to cancel abandoned task %s of %s: %v", id, t.agent, err)
+		return
+	}
+	log.Printf("Canceled abandoned task %s of %s", id, t.agent)
+}
+
+// SendMessage sends blocking messages non-blocking, then waits for their task
+// with tasks/get.
+func (t *taskTransport) SendMessage(ctx context.Context, message *a2acore.MessageSendParams) (a2acore.SendMessageResult, error) {
+	if message.Config != nil && message.Config.Blocking != nil && !*message.Config.Blocking {
+		return t.Transport.SendMessage(ctx, message)
+	}
+	params := *message
+	config := a2acore.MessageSendConfig{}
+	if params.Config != nil {
+		config = *params.Config
+	}
+	blocking := false
+	config.Blocking = &blocking
+	params.Config = &config
+
+	result, err := t.Transport.SendMessage(ctx, &params)
+	if err != nil {
+		return nil, err
+	}
+	task, ok := result.(*a2acore.Task)
+	if !ok {
+		return result, nil
+	}
+	defer t.track(ctx, task.ID)()
+	return t.await(ctx, task)
+}
+
+// await polls the task until it settles. If ctx is done first, the task is
+// canceled.
+func (t *taskTransport) await(ctx context.Context, task *a2acore.Task) (*a2acore.Task, error) {
+	for !settled(task.Status.State) {
+		select {
+		case <-time.After(remoteTaskPollInterval):
+		case <-ctx.Done():
+			t.abandon(ctx, task.ID)
+			return nil, context.Cause(ctx)
+		}
+		latest, err := t.Transport.GetTask(ctx, &a2acore.TaskQueryParams{ID: task.ID})
+		if err != nil {
+			if ctx.Err() != nil {
+				continue
+			}
+			return nil, fmt.Errorf("failed to get task %s: %w", task.ID, err)
+		}
+		task = latest
+	}
+	return task, nil
+}
+
+// SendStreamingMessage follows the task again if its stream breaks before it
+// settles.
+func (t *taskTransport) SendStreamingMessage(ctx context.Context, message *a2acore.MessageSendParams) iter.Seq2[a2acore.Event, error] {
+	return func(yield func(a2acore.Event, error) bool) {
+		var id a2acore.TaskID
+		untrack := func() {}
+		defer func() { untrack() }()
+
+		// forward yields the events of the stream and reports whether the
+		// call is over, because the task settled or the consumer stopped, or
+		// else the error the stream broke with, if any.
+		forward := func(events iter.Seq2[a2acore.Event, error]) (over bool, err error) {
+			for event, err := range events {
+				if err != nil {
+					return false, err
+				}
+				if id == "" {
+					if id = event.TaskInfo().TaskID; id != "" {
+						untrack = t.track(ctx, id)
+					}
+				}
+				if !yield(event, nil) || settles(event) {
+					return true, nil
+				}
+			}
+			return false, nil
+		}
+
+		over, err := forward(t.Transport.SendStreamingMessage(ctx, message))
+		for i := 0; !over && id != "" && ctx.Err() == nil && t.streaming && i < maxResubscribes; i++ {
+			log.Printf("Stream of task %s of %s broke (%v), resubscribing", id, t.agent, err)
+			over, err = forward(t.Transport.ResubscribeToTask(ctx, &a2acore.TaskIDParams{ID: id}))
+		}
+		switch {
+		case over:
+		case id != "" && ctx.Err() != nil:
+			t.abandon(ctx, id)
+			yield(nil, context.Cause(ctx))
+		case id != "":
+			// The task may have settled since the stream broke, in which
+			// case it can no longer be followed: get it.
+			task, err := t.await(ctx, &a2acore.Task{ID: id, Status: a2acore.TaskStatus{State: a2acore.TaskStateWorking}})
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			yield(task, nil)
+		case err != nil:
+			yield(nil, err)
+		}
+	}
+}
+
+// settles reports whether the event is the last of its call.
+func settles(event a2acore.Event) bool {
+	switch v := event.(type) {
+	case *a2acore.Message:
+		return true
+	case *a2acore.Task:
+		return settled(v.Status.State)
+	case *a2acore.TaskStatusUpdateEvent:
+		return v.Final || settled(v.Status.State)
+	}
+	return false
+}
+
+// settled reports whether the task waits for nothing more from the agent.
+func settled(state a2acore.TaskState) bool {
+	return state.Terminal() || state == a2acore.TaskStateInputRequired || state == a2acore.TaskStateAuthRequired
+}
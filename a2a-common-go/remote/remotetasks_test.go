@@ -0,0 +1,259 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package remote
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"iter"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	a2acore "github.com/a2aproject/a2a-go/a2a"
+	"github.com/a2aproject/a2a-go/a2aclient"
+	"github.com/a2aproject/a2a-go/a2asrv"
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/runner"
+	"google.golang.org/adk/session"
+	"google.golang.org/genai"
+
+	"a2a-common-go/a2aserver"
+)
+
+// slowServer is an agent served over A2A which works until released or
+// canceled.
+type slowServer struct {
+	url      string
+	started  chan struct{} // closed when the agent starts working
+	release  chan struct{} // close to let the agent answer
+	canceled chan error    // receives the cause of the cancellation of the agent
+}
+
+// newSlowServer serves the slow agent, its A2A requests going through wrap,
+// if not nil.
+func newSlowServer(t *testing.T, streaming bool, wrap func(http.Handler) http.Handler) *slowServer {
+	t.Helper()
+	s := &slowServer{started: make(chan struct{}), release: make(chan struct{}), canceled: make(chan error, 1)}
+	slow, err := agent.New(agent.Config{
+		Name:        "slow_agent",
+		Description: "Takes its time.",
+		Run: func(ic agent.InvocationContext) iter.Seq2[*session.Event, error] {
+			return func(yield func(*session.Event, error) bool) {
+				close(s.started)
+				select {
+				case <-s.release:
+				case <-ic.Done():
+					s.canceled <- context.Cause(ic)
+					return
+				}
+				event := session.NewEvent(ic.InvocationID())
+				event.Author = ic.Agent().Name()
+				event.Content = genai.NewContentFromText("Done at last.", genai.RoleModel)
+				yield(event, nil)
+			}
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	mux := http.NewServeMux()
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	s.url = srv.URL
+	card, err := a2aserver.NewAgentCard(slow, srv.URL+a2aserver.InvokePath, a2aserver.WithStreaming(streaming))
+	if err != nil {
+		t.Fatal(err)
+	}
+	executor := a2aserver.NewLiveExecutor(func() agent.Agent { return slow }, session.InMemoryService(), agent.StreamingModeNone)
+	var invoke http.Handler = a2asrv.NewJSONRPCHandler(a2asrv.NewHandler(executor))
+	if wrap != nil {
+		invoke = wrap(invoke)
+	}
+	mux.Handle(a2asrv.WellKnownAgentCardPath, a2asrv.NewStaticAgentCardHandler(card))
+	mux.Handle(a2aserver.InvokePath, invoke)
+	return s
+}
+
+// call asks the slow agent through a runner until ctx is done, sending the
+// text of its answer, or the error of the run, on the returned channel.
+func (s *slowServer) call(t *testing.T, ctx context.Context) <-chan string {
+	t.Helper()
+	remote, err := NewAgent(AgentSpec{Name: "slow_agent", URL: s.url})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sessions := session.InMemoryService()
+	if _, err := sessions.Create(ctx, &session.CreateRequest{AppName: "test", UserID: "user", SessionID: "s"}); err != nil {
+		t.Fatal(err)
+	}
+	r, err := runner.New(runner.Config{AppName: "test", Agent: remote, SessionService: sessions})
+	if err != nil {
+		t.Fatal(err)
+	}
+	answer := make(chan string, 1)
+	go func() {
+		var text strings.Builder
+		for event, err := range r.Run(ctx, "user", "s", genai.NewContentFromText("Take your time.", genai.RoleUser), agent.RunConfig{}) {
+			if err != nil {
+				text.WriteString("error: " + err.Error())
+				break
+			}
+			if event.Content != nil && !event.Partial {
+				for _, p := range event.Content.Parts {
+					text.WriteString(p.Text)
+				}
+			}
+		}
+		answer <- text.String()
+	}()
+	return answer
+}
+
+// inFlight waits for the task of the slow agent to be in flight.
+func inFlight(t *testing.T) *Task {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		for _, task := range Tasks.InFlight() {
+			if task.Agent == "slow_agent" {
+				return task
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("no task of slow_agent in flight")
+	return nil
+}
+
+func wait[T any](t *testing.T, ch <-chan T, what string) T {
+	t.Helper()
+	select {
+	case v := <-ch:
+		return v
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timed out waiting for %s", what)
+	}
+	var zero T
+	return zero
+}
+
+func TestRemoteTaskCancel(t *testing.T) {
+	for _, streaming := range []bool{true, false} {
+		t.Run(map[bool]string{true: "streaming", false: "polling"}[streaming], func(t *testing.T) {
+			s := newSlowServer(t, streaming, nil)
+			answer := s.call(t, context.Background())
+			wait(t, s.started, "the agent to start")
+			task := inFlight(t)
+
+			canceled, err := Tasks.Cancel(context.Background(), task.ID)
+			if err != nil {
+				t.Fatalf("cancel() error = %v", err)
+			}
+			if canceled.Status.State != a2acore.TaskStateCanceled {
+				t.Errorf("canceled task state = %s, want %s", canceled.Status.State, a2acore.TaskStateCanceled)
+			}
+			// The run of the remote agent, and so its tools, are stopped.
+			if cause := wait(t, s.canceled, "the agent to be canceled"); !errors.Is(cause, a2aserver.ErrTaskCanceled) {
+				t.Errorf("agent canceled by %v, want %v", cause, a2aserver.ErrTaskCanceled)
+			}
+			if text := wait(t, answer, "the call to end"); strings.Contains(text, "Done") {
+				t.Errorf("canceled call answered %q", text)
+			}
+			if tasks := Tasks.InFlight(); len(tasks) != 0 {
+				t.Errorf("tasks in flight after the call = %d, want 0", len(tasks))
+			}
+
+			got, err := a2aclient.NewJSONRPCTransport(s.url+a2aserver.InvokePath, nil).GetTask(context.Background(), &a2acore.TaskQueryParams{ID: task.ID})
+			if err != nil {
+				t.Fatalf("tasks/get error = %v", err)
+			}
+			if got.Status.State != a2acore.TaskStateCanceled {
+				t.Errorf("tasks/get state = %s, want %s", got.Status.State, a2acore.TaskStateCanceled)
+			}
+		})
+	}
+}
+
+func TestRemoteTaskAbandoned(t *testing.T) {
+	s := newSlowServer(t, false, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	answer := s.call(t, ctx)
+	wait(t, s.started, "the agent to start")
+	task := inFlight(t)
+	cancel()
+
+	if cause := wait(t, s.canceled, "the agent to be canceled"); !errors.Is(cause, a2aserver.ErrTaskCanceled) {
+		t.Errorf("agent canceled by %v, want %v", cause, a2aserver.ErrTaskCanceled)
+	}
+	wait(t, answer, "the call to end")
+	got, err := a2aclient.NewJSONRPCTransport(s.url+a2aserver.InvokePath, nil).GetTask(context.Background(), &a2acore.TaskQueryParams{ID: task.ID})
+	if err != nil {
+		t.Fatalf("tasks/get error = %v", err)
+	}
+	if got.Status.State != a2acore.TaskStateCanceled {
+		t.Errorf("tasks/get state = %s, want %s", got.Status.State, a2acore.TaskStateCanceled)
+	}
+}
+
+// TestRemoteTaskResubscribe breaks the stream of the call once the task is
+// known: the call follows the task again and gets the answer.
+func TestRemoteTaskResubscribe(t *testing.T) {
+	var resubscribes atomic.Int32
+	var broken atomic.Bool
+	s := newSlowServer(t, true, func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			r.Body = io.NopCloser(bytes.NewReader(body))
+			switch {
+			case bytes.Contains(body, []byte(`"tasks/resubscribe"`)):
+				resubscribes.Add(1)
+			case bytes.Contains(body, []byte(`"message/stream"`)) && broken.CompareAndSwap(false, true):
+				ctx, cancel := context.WithCancel(r.Context())
+				defer cancel()
+				w = &breakingWriter{ResponseWriter: w, cancel: cancel}
+				r = r.WithContext(ctx)
+			}
+			next.ServeHTTP(w, r)
+		})
+	})
+	answer := s.call(t, context.Background())
+	wait(t, s.started, "the agent to start")
+	inFlight(t)
+	close(s.release)
+
+	if text := wait(t, answer, "the answer"); text != "Done at last." {
+		t.Errorf("answer = %q, want %q", text, "Done at last.")
+	}
+	if !broken.Load() || resubscribes.Load() == 0 {
+		t.Errorf("stream broken = %v, resubscribes = %d, want the call to resubscribe", broken.Load(), resubscribes.Load())
+	}
+}
+
+// breakingWriter ends the response after its first event.
+type breakingWriter struct {
+	http.ResponseWriter
+	cancel func()
+}
+
+func (w *breakingWriter) Flush() {
+	w.ResponseWriter.(http.Flusher).Flush()
+	w.cancel()
+}
@@ -0,0 +1,134 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package remote
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/a2aproject/a2a-go/a2aclient/agentcard"
+
+	"a2a-common-go/tlsreload"
+)
+
+// --- Remote Agent TLS ---
+//
+// Remote agents served with TLS by a private CA, possibly requiring a client
+// certificate (mTLS), are reached with:
+//
+//	--remote_tls_ca    REMOTE_TLS_CA    PEM CA bundle verifying the remote agents,
+//	                                    instead of the system roots
+//	--remote_tls_cert  REMOTE_TLS_CERT  PEM client certificate chain to present
+//	--remote_tls_key   REMOTE_TLS_KEY   PEM private key of the client certificate
+//
+// They apply to all the traffic to remote agents: calls, agent cards, health
+// probes and the agent registry. The files are reloaded when they change, see
+// package tlsreload.
+
+const (
+	remoteTLSCAEnv   = "REMOTE_TLS_CA"
+	remoteTLSCertEnv = "REMOTE_TLS_CERT"
+	remoteTLSKeyEnv  = "REMOTE_TLS_KEY"
+
+	// remoteCardTimeout is that of the default agent card resolver.
+	remoteCardTimeout = 30 * time.Second
+)
+
+// remoteTransport carries the traffic to remote agents, nil for
+// http.DefaultTransport. It is set by UseTransport at startup.
+var remoteTransport http.RoundTripper
+
+// TLSConfig is where the TLS files of the remote agent client are.
+type TLSConfig struct {
+	CAFile   string
+	CertFile string
+	KeyFile  string
+}
+
+// Validate reports an error if only one of the client certificate and its
+// key is set. It does not read the files.
+func (c TLSConfig) Validate() error {
+	if (c.CertFile == "") != (c.KeyFile == "") {
+		return errors.New("--remote_tls_cert and --remote_tls_key must be set together")
+	}
+	return nil
+}
+
+// Transport returns the transport to remote agents, following changes to the
+// files, or nil if TLS is not configured.
+func (c TLSConfig) Transport() (http.RoundTripper, error) {
+	if c.CAFile == "" && c.CertFile == "" {
+		return nil, nil
+	}
+	var paths []string
+	for _, p := range []string{c.CAFile, c.CertFile, c.KeyFile} {
+		if p != "" {
+			paths = append(paths, p)
+		}
+	}
+	current, err := tlsreload.New(c.load, paths...)
+	if err != nil {
+		return nil, err
+	}
+	// Let calls in flight finish on the connections made with the old files.
+	current.Changed = func(old *http.Transport) { old.CloseIdleConnections() }
+	return &reloadingTransport{current: current}, nil
+}
+
+// load builds a transport from the files.
+func (c TLSConfig) load() (*http.Transport, error) {
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12}
+	if c.CAFile != "" {
+		pool, err := tlsreload.LoadCertPool(c.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		cfg.RootCAs = pool
+	}
+	if c.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load remote TLS client certificate: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.TLSClientConfig = cfg
+	return t, nil
+}
+
+// reloadingTransport sends requests with the transport built from the
+// current files.
+type reloadingTransport struct {
+	current *tlsreload.Reloading[*http.Transport]
+}
+
+func (t *reloadingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return t.current.Get().RoundTrip(req)
+}
+
+// UseTransport sends the traffic to remote agents through t, including
+// the agent cards remoteagent fetches with the default resolver. Call it
+// before creating the remote agents, their health checks and registry.
+func UseTransport(t http.RoundTripper) {
+	if t == nil {
+		return
+	}
+	remoteTransport = t
+	agentcard.DefaultResolver = agentcard.NewResolver(&http.Client{Timeout: remoteCardTimeout, Transport: t})
+}
@@ -0,0 +1,260 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package remote
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	a2acore "github.com/a2aproject/a2a-go/a2a"
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/model"
+	"google.golang.org/genai"
+)
+
+// --- Skill Routing ---
+//
+// The delegation part of the root agent's instruction is generated from its
+// sub-agents: the skills in the agent cards of the remote agents, as last read
+// by the health probes, and the routing skills of the local agents.
+//
+// With --skill_router (SKILL_ROUTER), requests are also routed without asking
+// the model when they match a single agent: first by being one of the examples
+// of a skill, then by containing tags of the skills of exactly one agent.
+// Requests matching several agents, e.g. "roll a die and check if it is
+// prime", are left to the model. Each routing decision is logged with the rule
+// that fired.
+
+const skillRouterEnv = "SKILL_ROUTER"
+
+// RouteSkill is what an agent can do, as in an A2A agent card.
+type RouteSkill struct {
+	Name        string
+	Description string
+	Tags        []string
+	Examples    []string
+}
+
+// RouteTarget is an agent requests can be routed to.
+type RouteTarget struct {
+	Name        string
+	Description string
+	Skills      []RouteSkill
+}
+
+// SkillRouter routes requests to the sub-agents of the root agent.
+type SkillRouter struct {
+	local    []RouteTarget
+	health   *Health
+	preRoute bool
+}
+
+// NewSkillRouter returns a router for the local sub-agents and the remote
+// agents health reports as available. With preRoute, Route transfers a new
+// request straight to the one agent whose skill examples or tags it matches,
+// without calling the model; otherwise, or when several agents match, the
+// model decides.
+func NewSkillRouter(local []RouteTarget, health *Health, preRoute bool) *SkillRouter {
+	return &SkillRouter{local: local, health: health, preRoute: preRoute}
+}
+
+// SkillRouterFlag registers --skill_router on fs.
+func SkillRouterFlag(fs *flag.FlagSet) (*bool, error) {
+	enabled := false
+	if v := os.Getenv(skillRouterEnv); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s %q: must be true or false", skillRouterEnv, v)
+		}
+		enabled = b
+	}
+	return fs.Bool("skill_router", enabled, "Route requests matching the skill examples or tags of a single agent without asking the model. Defaults to $"+skillRouterEnv+"."), nil
+}
+
+// targets returns the local agents and the available remote agents.
+func (r *SkillRouter) targets() []RouteTarget {
+	targets := append([]RouteTarget(nil), r.local...)
+	for _, s := range r.health.agents() {
+		if r.health.unavailable(s.Name) != "" {
+			continue
+		}
+		t := RouteTarget{Name: s.Name, Description: s.Description}
+		if card := r.health.card(s.Name); card != nil {
+			if t.Description == "" {
+				t.Description = card.Description
+			}
+			for _, sk := range card.Skills {
+				t.Skills = append(t.Skills, RouteSkill{Name: sk.Name, Description: sk.Description, Tags: sk.Tags, Examples: sk.Examples})
+			}
+		}
+		targets = append(targets, t)
+	}
+	return targets
+}
+
+// Delegation describes the agents to delegate to, for the instruction.
+func (r *SkillRouter) Delegation() string {
+	var b strings.Builder
+	b.WriteString("Delegate each request to the agent whose skills fit it best:\n")
+	for _, t := range r.targets() {
+		fmt.Fprintf(&b, "- %s", t.Name)
+		if t.Description != "" {
+			fmt.Fprintf(&b, ": %s", t.Description)
+		}
+		b.WriteString("\n")
+		for _, sk := range t.Skills {
+			fmt.Fprintf(&b, "  - %s", sk.Name)
+			if sk.Description != "" {
+				fmt.Fprintf(&b, ": %s", sk.Description)
+			}
+			if len(sk.Examples) > 0 {
+				fmt.Fprintf(&b, " (e.g. %q)", sk.Examples[0])
+			}
+			b.WriteString("\n")
+		}
+	}
+	b.WriteString("If a request needs several agents, delegate to them one after the other, passing each the results of the previous ones.\n")
+	return b.String()
+}
+
+// CardSkills returns the skills of the local agents and the available
+// remote agents, for the agent card of the root agent. Skill IDs are
+// prefixed with the name of their agent to keep them unique.
+func (r *SkillRouter) CardSkills() []a2acore.AgentSkill {
+	var skills []a2acore.AgentSkill
+	for _, t := range r.targets() {
+		for _, sk := range t.Skills {
+			skill := a2acore.AgentSkill{
+				ID:          t.Name + "." + sk.Name,
+				Name:        sk.Name,
+				Description: sk.Description,
+				Tags:        sk.Tags,
+				Examples:    sk.Examples,
+			}
+			// Cards of remote agents may leave out fields the card of the
+			// root agent requires.
+			if strings.TrimSpace(skill.Description) == "" {
+				skill.Description = fmt.Sprintf("%s of %s.", sk.Name, t.Name)
+			}
+			if len(skill.Tags) == 0 {
+				skill.Tags = []string{t.Name}
+			}
+			skills = append(skills, skill)
+		}
+	}
+	return skills
+}
+
+var nonWordChars = regexp.MustCompile(`[^\pL\pN]+`)
+
+// normalize lowercases text and reduces it to words separated by spaces.
+func normalize(text string) string {
+	return strings.TrimSpace(nonWordChars.ReplaceAllString(strings.ToLower(text), " "))
+}
+
+// match returns the agent the request should go to and the rule that
+// fired, or "" if no single agent matches.
+func (r *SkillRouter) match(request string) (target, rule string) {
+	text := normalize(request)
+	if text == "" {
+		return "", ""
+	}
+	targets := r.targets()
+	for _, t := range targets {
+		for _, sk := range t.Skills {
+			for _, ex := range sk.Examples {
+				if normalize(ex) == text {
+					return t.Name, fmt.Sprintf("example %q of skill %s", ex, sk.Name)
+				}
+			}
+		}
+	}
+
+	padded := " " + text + " "
+	var matched []string
+	for _, t := range targets {
+		tagRule := ""
+		for _, sk := range t.Skills {
+			for _, tag := range sk.Tags {
+				if tag := normalize(tag); tag != "" && strings.Contains(padded, " "+tag+" ") {
+					tagRule = fmt.Sprintf("tag %q of skill %s", tag, sk.Name)
+					break
+				}
+			}
+			if tagRule != "" {
+				break
+			}
+		}
+		if tagRule != "" {
+			matched = append(matched, t.Name)
+			target, rule = t.Name, tagRule
+		}
+	}
+	if len(matched) > 1 {
+		log.Printf("Skill router: request matches %s, leaving it to the model", strings.Join(matched, ", "))
+		return "", ""
+	}
+	return target, rule
+}
+
+// Route is a BeforeModelCallback transferring new requests matching a
+// single agent to it without calling the model.
+func (r *SkillRouter) Route(ctx agent.CallbackContext, req *model.LLMRequest) (*model.LLMResponse, error) {
+	if !r.preRoute || len(req.Contents) == 0 {
+		return nil, nil
+	}
+	// Only route the user's request, not the model's next step after a
+	// tool call or a transfer back.
+	last := req.Contents[len(req.Contents)-1]
+	request := contentText(ctx.UserContent())
+	if last.Role != genai.RoleUser || request == "" || contentText(last) != request {
+		return nil, nil
+	}
+	target, rule := r.match(request)
+	if target == "" {
+		return nil, nil
+	}
+	log.Printf("Skill router: %s matched, transferring to %s", rule, target)
+	return &model.LLMResponse{
+		Content: &genai.Content{
+			Role: genai.RoleModel,
+			Parts: []*genai.Part{{FunctionCall: &genai.FunctionCall{
+				Name: "transfer_to_agent",
+				Args: map[string]any{"agent_name": target},
+			}}},
+		},
+	}, nil
+}
+
+// contentText returns the text of the content, or "" if it has function
+// calls or responses.
+func contentText(c *genai.Content) string {
+	if c == nil {
+		return ""
+	}
+	var text strings.Builder
+	for _, p := range c.Parts {
+		if p.FunctionCall != nil || p.FunctionResponse != nil {
+			return ""
+		}
+		text.WriteString(p.Text)
+	}
+	return text.String()
+}
@@ -0,0 +1,182 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package remote
+
+import (
+	"context"
+	"errors"
+	"iter"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/agent/llmagent"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/runner"
+	"google.golang.org/adk/session"
+	"google.golang.org/genai"
+)
+
+// newTestRouter returns a router for roll_agent and a prime_agent whose card
+// declares a skill.
+func newTestRouter(t *testing.T) *SkillRouter {
+	t.Helper()
+	card := filepath.Join(t.TempDir(), "prime.json")
+	content := `{"name": "check_prime_agent", "description": "Checks primes.", "url": "http://127.0.0.1:1/a2a/invoke",
+		"skills": [{"id": "check_prime", "name": "check_prime", "description": "Checks whether numbers are prime.",
+			"tags": ["prime", "primality"], "examples": ["Is 7 prime?"]}]}`
+	if err := os.WriteFile(card, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	health := NewHealth([]AgentSpec{{Name: "prime_agent", CardFile: card}}, breakerConfig{callTimeout: time.Second, failures: 1, cooldown: time.Minute})
+	// The card is read by probes, which fail here as nothing listens.
+	c, err := LoadAgentCard(card)
+	if err != nil {
+		t.Fatal(err)
+	}
+	health.set("prime_agent", c, nil)
+	local := []RouteTarget{{Name: "roll_agent", Description: "Rolls dice.", Skills: []RouteSkill{{
+		Name:        "roll_dice",
+		Description: "Rolls dice of any size, including dice notation like 3d6+2.",
+		Tags:        []string{"roll", "dice", "die"},
+		Examples:    []string{"Roll a 6-sided die.", "Roll 3d6+2."},
+	}}}}
+	return NewSkillRouter(local, health, true)
+}
+
+func TestSkillRouterDelegation(t *testing.T) {
+	got := newTestRouter(t).Delegation()
+	for _, want := range []string{
+		"- roll_agent: Rolls dice.\n  - roll_dice: Rolls dice of any size",
+		"- prime_agent: Checks primes.\n  - check_prime: Checks whether numbers are prime. (e.g. \"Is 7 prime?\")",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("delegation() = %q, want it to contain %q", got, want)
+		}
+	}
+}
+
+func TestSkillRouterMatch(t *testing.T) {
+	r := newTestRouter(t)
+	tests := []struct {
+		request    string
+		wantTarget string
+		wantRule   string
+	}{
+		{request: "is 7 PRIME", wantTarget: "prime_agent", wantRule: `example "Is 7 prime?" of skill check_prime`},
+		{request: "Roll a 6-sided die.", wantTarget: "roll_agent", wantRule: `example "Roll a 6-sided die." of skill roll_dice`},
+		{request: "Check the primality of 91, please", wantTarget: "prime_agent", wantRule: `tag "primality" of skill check_prime`},
+		{request: "Roll two dice", wantTarget: "roll_agent", wantRule: `tag "roll" of skill roll_dice`},
+		{request: "Roll a die and check if it is prime"},
+		{request: "Is 7 a primeval number?"},
+		{request: "What's the weather?"},
+	}
+	for _, tt := range tests {
+		target, rule := r.match(tt.request)
+		if target != tt.wantTarget || rule != tt.wantRule {
+			t.Errorf("match(%q) = %q, %q, want %q, %q", tt.request, target, rule, tt.wantTarget, tt.wantRule)
+		}
+	}
+}
+
+// unusedModel fails the test if the model is called.
+type unusedModel struct{ t *testing.T }
+
+func (m unusedModel) Name() string { return "unused" }
+
+func (m unusedModel) GenerateContent(ctx context.Context, req *model.LLMRequest, stream bool) iter.Seq2[*model.LLMResponse, error] {
+	return func(yield func(*model.LLMResponse, error) bool) {
+		m.t.Error("model called for a pre-routed request")
+		yield(&model.LLMResponse{Content: genai.NewContentFromText("model answer", genai.RoleModel)}, nil)
+	}
+}
+
+func TestSkillRouterRoute(t *testing.T) {
+	r := newTestRouter(t)
+	prime, err := agent.New(agent.Config{
+		Name: "prime_agent",
+		Run: func(ic agent.InvocationContext) iter.Seq2[*session.Event, error] {
+			return func(yield func(*session.Event, error) bool) {
+				event := session.NewEvent(ic.InvocationID())
+				event.Content = genai.NewContentFromText("7 is prime.", genai.RoleModel)
+				yield(event, nil)
+			}
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	root, err := llmagent.New(llmagent.Config{
+		Name:                 "root_agent",
+		Model:                unusedModel{t},
+		Instruction:          "Delegate requests to the agents below.",
+		SubAgents:            []agent.Agent{prime},
+		BeforeModelCallbacks: []llmagent.BeforeModelCallback{r.Route},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	sessions := session.InMemoryService()
+	if _, err := sessions.Create(ctx, &session.CreateRequest{AppName: "test", UserID: "user", SessionID: "s"}); err != nil {
+		t.Fatal(err)
+	}
+	run, err := runner.New(runner.Config{AppName: "test", Agent: root, SessionService: sessions})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var authors, text []string
+	for event, err := range run.Run(ctx, "user", "s", genai.NewContentFromText("Is 7 prime?", genai.RoleUser), agent.RunConfig{}) {
+		if err != nil {
+			t.Fatalf("Run() error = %v", err)
+		}
+		authors = append(authors, event.Author)
+		if event.Content != nil {
+			for _, p := range event.Content.Parts {
+				if p.Text != "" {
+					text = append(text, p.Text)
+				}
+			}
+		}
+	}
+	if got := strings.Join(text, " "); got != "7 is prime." {
+		t.Errorf("answer = %q (events by %v), want the prime agent's", got, authors)
+	}
+}
+
+func TestSkillRouterCardSkills(t *testing.T) {
+	r := newTestRouter(t)
+	var got []string
+	for _, sk := range r.CardSkills() {
+		got = append(got, sk.ID+": "+sk.Description+" "+strings.Join(sk.Tags, ","))
+	}
+	want := []string{
+		"roll_agent.roll_dice: Rolls dice of any size, including dice notation like 3d6+2. roll,dice,die",
+		"prime_agent.check_prime: Checks whether numbers are prime. prime,primality",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("CardSkills() = %q, want %q", got, want)
+	}
+
+	// The skills of unavailable agents are left out.
+	r.health.set("prime_agent", nil, errors.New("connection refused"))
+	if skills := r.CardSkills(); len(skills) != 1 || skills[0].ID != "roll_agent.roll_dice" {
+		t.Errorf("CardSkills() with prime_agent down = %+v, want roll_dice only", skills)
+	}
+}
@@ -0,0 +1,293 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package shell provides the run_command tool, running allowlisted commands
+// in a sandbox.
+package shell
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/adk/tool"
+	"google.golang.org/adk/tool/functiontool"
+
+	"a2a-common-go/toolkit"
+)
+
+// --- Sandboxed Command Tool ---
+//
+// The run_command tool lets an agent run diagnostic commands, but only the
+// ones listed in SHELL_ALLOWED_COMMANDS. Commands run without a shell, so
+// arguments are never interpreted, in a working directory inside
+// SHELL_WORKDIR, with a minimal environment and, on Linux where user
+// namespaces are available, in a network namespace of their own without any
+// interfaces. Each run is killed, with everything it started, after
+// SHELL_TIMEOUT, and its stdout and stderr are cut to SHELL_MAX_OUTPUT bytes.
+//
+// The jail only applies to the working directory: an allowed command can
+// still read any file the agent process can, so only allow commands that
+// are safe to run with arbitrary arguments.
+
+const (
+	// shellAllowedCommandsEnv lists the commands the tool may run, by name or
+	// absolute path, e.g. "uptime,df,/opt/ops/healthcheck". The tool is only
+	// available when it is set.
+	shellAllowedCommandsEnv = "SHELL_ALLOWED_COMMANDS"
+	// shellWorkDirEnv sets the directory commands are jailed to. Defaults to a
+	// new temporary directory.
+	shellWorkDirEnv = "SHELL_WORKDIR"
+	// shellTimeoutEnv sets the time a command may run, e.g. "10s".
+	shellTimeoutEnv = "SHELL_TIMEOUT"
+	// shellMaxOutputEnv caps the bytes kept of stdout and of stderr.
+	shellMaxOutputEnv = "SHELL_MAX_OUTPUT"
+
+	defaultShellTimeout   = 10 * time.Second
+	defaultShellMaxOutput = 64 * 1024
+
+	// shellPath is the PATH of commands, for the programs they start.
+	shellPath = "/usr/local/bin:/usr/bin:/bin"
+)
+
+// CommandRunner runs allowlisted commands in a sandbox.
+type CommandRunner struct {
+	commands  map[string]string // allowed command name to executable path
+	Dir       string            // the jail, with symlinks resolved
+	timeout   time.Duration
+	maxOutput int
+
+	// noNetNS is set once creating a network namespace failed, so later runs
+	// do not try again.
+	noNetNS atomic.Bool
+}
+
+// CommandRunnerFromEnv creates a runner configured by SHELL_ALLOWED_COMMANDS,
+// SHELL_WORKDIR, SHELL_TIMEOUT and SHELL_MAX_OUTPUT. It returns nil if no
+// command is allowed.
+func CommandRunnerFromEnv() (*CommandRunner, error) {
+	allowed := os.Getenv(shellAllowedCommandsEnv)
+	if allowed == "" {
+		return nil, nil
+	}
+
+	timeout := defaultShellTimeout
+	if v := os.Getenv(shellTimeoutEnv); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("invalid %s %q: must be a positive duration", shellTimeoutEnv, v)
+		}
+		timeout = d
+	}
+
+	maxOutput := defaultShellMaxOutput
+	if v := os.Getenv(shellMaxOutputEnv); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return nil, fmt.Errorf("invalid %s %q: must be a positive integer", shellMaxOutputEnv, v)
+		}
+		maxOutput = n
+	}
+
+	dir := os.Getenv(shellWorkDirEnv)
+	if dir == "" {
+		var err error
+		if dir, err = os.MkdirTemp("", "run-command-"); err != nil {
+			return nil, fmt.Errorf("failed to create command directory: %w", err)
+		}
+	}
+	return newCommandRunner(strings.Split(allowed, ","), dir, timeout, maxOutput)
+}
+
+// newCommandRunner creates a runner for the allowed commands, jailed to dir.
+// Commands given by name are looked up in PATH once, here.
+func newCommandRunner(allowed []string, dir string, timeout time.Duration, maxOutput int) (*CommandRunner, error) {
+	jail, err := filepath.Abs(dir)
+	if err == nil {
+		jail, err = filepath.EvalSymlinks(jail)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid command directory %q: %w", dir, err)
+	}
+	if info, err := os.Stat(jail); err != nil || !info.IsDir() {
+		return nil, fmt.Errorf("invalid command directory %q: not a directory", dir)
+	}
+
+	commands := make(map[string]string)
+	for _, name := range allowed {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if strings.ContainsRune(name, os.PathSeparator) && !filepath.IsAbs(name) {
+			return nil, fmt.Errorf("invalid allowed command %q: use a name or an absolute path", name)
+		}
+		path, err := exec.LookPath(name)
+		if err != nil {
+			return nil, fmt.Errorf("allowed command %q not found: %w", name, err)
+		}
+		commands[name] = path
+	}
+	if len(commands) == 0 {
+		return nil, errors.New("no allowed commands")
+	}
+	return &CommandRunner{commands: commands, Dir: jail, timeout: timeout, maxOutput: maxOutput}, nil
+}
+
+type runCommandArgs struct {
+	Command string   `json:"command" jsonschema:"The command to run, one of the allowed commands." validate:"min=1"`
+	Args    []string `json:"args,omitempty" jsonschema:"The arguments of the command. They are passed as is, without a shell." validate:"max=64"`
+	Dir     string   `json:"dir,omitempty" jsonschema:"The working directory, relative to the sandbox directory."`
+}
+
+type runCommandResult struct {
+	ExitCode        int    `json:"exit_code"`
+	Stdout          string `json:"stdout"`
+	Stderr          string `json:"stderr"`
+	StdoutTruncated bool   `json:"stdout_truncated,omitempty"`
+	StderrTruncated bool   `json:"stderr_truncated,omitempty"`
+	TimedOut        bool   `json:"timed_out,omitempty"`
+	NetworkIsolated bool   `json:"network_isolated"`
+}
+
+// NewTool creates the run_command tool.
+func (r *CommandRunner) NewTool() (tool.Tool, error) {
+	names := make([]string, 0, len(r.commands))
+	for name := range r.commands {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return toolkit.NewValidatedTool(functiontool.Config{
+		Name: "run_command",
+		Description: fmt.Sprintf("Run a diagnostic command in a sandbox and return its exit code, stdout and stderr. "+
+			"Allowed commands: %s. Commands run without a shell and time out after %s.", strings.Join(names, ", "), r.timeout),
+	}, r.run, toolkit.WithApproval(toolkit.ApprovalRequired("run_command")))
+}
+
+// run is the handler of the run_command tool.
+func (r *CommandRunner) run(tc tool.Context, args runCommandArgs) (runCommandResult, error) {
+	path, ok := r.commands[args.Command]
+	if !ok {
+		return runCommandResult{}, fmt.Errorf("command %q is not allowed", args.Command)
+	}
+	dir, err := r.workDir(args.Dir)
+	if err != nil {
+		return runCommandResult{}, err
+	}
+
+	ctx, cancel := context.WithTimeout(tc, r.timeout)
+	defer cancel()
+
+	isolate := shellCanIsolateNetwork && !r.noNetNS.Load()
+	stdout := &cappedBuffer{max: r.maxOutput}
+	stderr := &cappedBuffer{max: r.maxOutput}
+	cmd := r.command(ctx, path, args.Args, dir, isolate, stdout, stderr)
+	err = cmd.Start()
+	if err != nil && isolate && shellIsolationUnavailable(err) {
+		if r.noNetNS.CompareAndSwap(false, true) {
+			log.Printf("Network namespaces are not available, running commands without network isolation: %v", err)
+		}
+		isolate = false
+		cmd = r.command(ctx, path, args.Args, dir, isolate, stdout, stderr)
+		err = cmd.Start()
+	}
+	if err != nil {
+		return runCommandResult{}, fmt.Errorf("failed to start %q: %w", args.Command, err)
+	}
+	err = cmd.Wait()
+
+	result := runCommandResult{
+		ExitCode:        cmd.ProcessState.ExitCode(),
+		Stdout:          stdout.String(),
+		Stderr:          stderr.String(),
+		StdoutTruncated: stdout.truncated,
+		StderrTruncated: stderr.truncated,
+		TimedOut:        errors.Is(ctx.Err(), context.DeadlineExceeded),
+		NetworkIsolated: isolate,
+	}
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) && !result.TimedOut {
+		return runCommandResult{}, fmt.Errorf("failed to run %q: %w", args.Command, err)
+	}
+	return result, nil
+}
+
+// command prepares a run of the executable at path.
+func (r *CommandRunner) command(ctx context.Context, path string, args []string, dir string, isolate bool, stdout, stderr *cappedBuffer) *exec.Cmd {
+	stdout.reset()
+	stderr.reset()
+	cmd := exec.CommandContext(ctx, path, args...)
+	cmd.Dir = dir
+	cmd.Env = []string{"PATH=" + shellPath, "HOME=" + r.Dir, "TMPDIR=" + r.Dir, "LANG=C.UTF-8"}
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	cmd.SysProcAttr = shellSysProcAttr(isolate)
+	// Kill everything the command started, not just the command, and do
+	// not wait forever for children holding on to the output pipes.
+	cmd.Cancel = func() error { return shellKill(cmd.Process) }
+	cmd.WaitDelay = time.Second
+	return cmd
+}
+
+// workDir resolves a directory relative to the jail, making sure it does not
+// lead out of it, also through symlinks.
+func (r *CommandRunner) workDir(rel string) (string, error) {
+	dir := filepath.Join(r.Dir, filepath.Clean(string(os.PathSeparator)+rel))
+	real, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return "", fmt.Errorf("invalid directory %q: %w", rel, err)
+	}
+	if p, err := filepath.Rel(r.Dir, real); err != nil || p == ".." || strings.HasPrefix(p, ".."+string(os.PathSeparator)) {
+		return "", fmt.Errorf("directory %q is outside of the sandbox", rel)
+	}
+	if info, err := os.Stat(real); err != nil || !info.IsDir() {
+		return "", fmt.Errorf("invalid directory %q: not a directory", rel)
+	}
+	return real, nil
+}
+
+// cappedBuffer keeps the first max bytes written to it and drops the rest.
+type cappedBuffer struct {
+	max       int
+	buf       []byte
+	truncated bool
+}
+
+func (b *cappedBuffer) Write(p []byte) (int, error) {
+	if room := b.max - len(b.buf); len(p) > room {
+		b.buf = append(b.buf, p[:room]...)
+		b.truncated = true
+	} else {
+		b.buf = append(b.buf, p...)
+	}
+	return len(p), nil
+}
+
+func (b *cappedBuffer) String() string {
+	return string(b.buf)
+}
+
+func (b *cappedBuffer) reset() {
+	b.buf = b.buf[:0]
+	b.truncated = false
+}
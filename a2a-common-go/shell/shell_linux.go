@@ -0,0 +1,58 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build linux
+
+package shell
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// shellCanIsolateNetwork reports whether commands can get a network
+// namespace of their own.
+const shellCanIsolateNetwork = true
+
+// shellSysProcAttr puts the command in a process group of its own, so it can
+// be killed with its children, and, if isolate is set, in new user and
+// network namespaces. The user namespace maps the current user to itself and
+// lets unprivileged processes create the network namespace, which only has a
+// loopback interface that is down.
+func shellSysProcAttr(isolate bool) *syscall.SysProcAttr {
+	attr := &syscall.SysProcAttr{
+		Setpgid:   true,
+		Pdeathsig: syscall.SIGKILL,
+	}
+	if isolate {
+		attr.Cloneflags = syscall.CLONE_NEWUSER | syscall.CLONE_NEWNET
+		attr.UidMappings = []syscall.SysProcIDMap{{ContainerID: os.Getuid(), HostID: os.Getuid(), Size: 1}}
+		attr.GidMappings = []syscall.SysProcIDMap{{ContainerID: os.Getgid(), HostID: os.Getgid(), Size: 1}}
+	}
+	return attr
+}
+
+// shellKill kills the process group of the command.
+func shellKill(p *os.Process) error {
+	return syscall.Kill(-p.Pid, syscall.SIGKILL)
+}
+
+// shellIsolationUnavailable reports whether starting a command failed
+// because namespaces cannot be created, e.g. when user namespaces are
+// disabled or inside a restricted container.
+func shellIsolationUnavailable(err error) bool {
+	return errors.Is(err, syscall.EPERM) || errors.Is(err, syscall.EINVAL) ||
+		errors.Is(err, syscall.ENOSPC) || errors.Is(err, syscall.EUSERS)
+}
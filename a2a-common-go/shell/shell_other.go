@@ -0,0 +1,42 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !linux
+
+package shell
+
+import (
+	"os"
+	"syscall"
+)
+
+// shellCanIsolateNetwork reports whether commands can get a network
+// namespace of their own. Only Linux has them.
+const shellCanIsolateNetwork = false
+
+// shellSysProcAttr returns no special attributes: commands run with the
+// network of the agent.
+func shellSysProcAttr(isolate bool) *syscall.SysProcAttr {
+	return nil
+}
+
+// shellKill kills the command. Its children may survive it.
+func shellKill(p *os.Process) error {
+	return p.Kill()
+}
+
+// shellIsolationUnavailable is never called without namespace support.
+func shellIsolationUnavailable(err error) bool {
+	return false
+}
@@ -0,0 +1,154 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package shell
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"a2a-common-go/internal/testutil"
+)
+
+func TestCommandRunner(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("needs a POSIX shell")
+	}
+	jail := t.TempDir()
+	if err := os.Mkdir(filepath.Join(jail, "sub"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(os.TempDir(), filepath.Join(jail, "out")); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("SECRET_TOKEN", "hunter2")
+
+	r, err := newCommandRunner([]string{"sh", "pwd"}, jail, time.Second, 64)
+	if err != nil {
+		t.Fatalf("newCommandRunner() error = %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	tc := testutil.ToolContext(ctx)
+
+	tests := []struct {
+		name    string
+		args    runCommandArgs
+		check   func(t *testing.T, got runCommandResult)
+		wantErr string
+	}{
+		{
+			name: "exit code and output",
+			args: runCommandArgs{Command: "sh", Args: []string{"-c", "echo out; echo err >&2; exit 3"}},
+			check: func(t *testing.T, got runCommandResult) {
+				if got.ExitCode != 3 || got.Stdout != "out\n" || got.Stderr != "err\n" || got.TimedOut {
+					t.Errorf("got %+v, want exit 3 with out and err", got)
+				}
+			},
+		},
+		{
+			name: "working directory",
+			args: runCommandArgs{Command: "pwd", Dir: "../sub"},
+			check: func(t *testing.T, got runCommandResult) {
+				if want := filepath.Join(r.Dir, "sub") + "\n"; got.Stdout != want {
+					t.Errorf("stdout = %q, want %q", got.Stdout, want)
+				}
+			},
+		},
+		{
+			name: "scrubbed environment",
+			args: runCommandArgs{Command: "sh", Args: []string{"-c", "echo \"[$SECRET_TOKEN]\""}},
+			check: func(t *testing.T, got runCommandResult) {
+				if got.Stdout != "[]\n" {
+					t.Errorf("stdout = %q, want the variable unset", got.Stdout)
+				}
+			},
+		},
+		{
+			name: "output cap",
+			args: runCommandArgs{Command: "sh", Args: []string{"-c", "printf '%0100d' 0"}},
+			check: func(t *testing.T, got runCommandResult) {
+				if got.Stdout != strings.Repeat("0", 64) || !got.StdoutTruncated {
+					t.Errorf("got %+v, want 64 bytes of truncated stdout", got)
+				}
+			},
+		},
+		{
+			name: "timeout",
+			args: runCommandArgs{Command: "sh", Args: []string{"-c", "sleep 30 & wait"}},
+			check: func(t *testing.T, got runCommandResult) {
+				if !got.TimedOut || got.ExitCode == 0 {
+					t.Errorf("got %+v, want a timed out run", got)
+				}
+			},
+		},
+		{
+			name:    "command not allowed",
+			args:    runCommandArgs{Command: "rm", Args: []string{"-rf", "/"}},
+			wantErr: "not allowed",
+		},
+		{
+			name:    "symlink out of the jail",
+			args:    runCommandArgs{Command: "pwd", Dir: "out"},
+			wantErr: "outside of the sandbox",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start := time.Now()
+			got, err := r.run(tc, tt.args)
+			if time.Since(start) > 5*time.Second {
+				t.Errorf("run() took %s, want the timeout to kill the command", time.Since(start))
+			}
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("run() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("run() error = %v", err)
+			}
+			tt.check(t, got)
+		})
+	}
+}
+
+func TestCommandRunnerNetworkIsolation(t *testing.T) {
+	if !shellCanIsolateNetwork {
+		t.Skip("network namespaces are not supported")
+	}
+	r, err := newCommandRunner([]string{"cat"}, t.TempDir(), 5*time.Second, 4096)
+	if err != nil {
+		t.Fatalf("newCommandRunner() error = %v", err)
+	}
+	got, err := r.run(testutil.ToolContext(context.Background()), runCommandArgs{Command: "cat", Args: []string{"/proc/net/dev"}})
+	if err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+	if !got.NetworkIsolated {
+		t.Skip("network namespaces are not available here")
+	}
+	// A fresh network namespace only has the loopback interface.
+	for _, line := range strings.Split(got.Stdout, "\n")[2:] {
+		if name, _, ok := strings.Cut(strings.TrimSpace(line), ":"); ok && name != "lo" {
+			t.Errorf("interface %q is visible in the sandbox", name)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package tlsreload rebuilds values from certificate files when the files
+// change.
+package tlsreload
+
+import (
+	"crypto/x509"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// --- Certificate Reloading ---
+//
+// Certificates are rotated by replacing their files, e.g. by cert-manager or
+// a sidecar. Whatever is built from them is rebuilt when the files change,
+// which is checked at most every Interval. If the new files cannot
+// be loaded, e.g. because only the certificate has been replaced yet, the
+// previous value stays in use until they can.
+
+// Interval is how often the files are checked, a variable for tests.
+var Interval = 5 * time.Second
+
+// fileStamp identifies a version of a file.
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// Reloading is a value built from files, rebuilt when they change.
+type Reloading[T any] struct {
+	paths    []string
+	load     func() (T, error)
+	interval time.Duration
+	Changed  func(old T) // called with the replaced value
+
+	mu      sync.Mutex
+	value   T
+	stamps  []fileStamp
+	checked time.Time
+}
+
+// New loads the value, failing if the files cannot be loaded now.
+func New[T any](load func() (T, error), paths ...string) (*Reloading[T], error) {
+	r := &Reloading[T]{paths: paths, load: load, interval: Interval}
+	stamps, err := r.stat()
+	if err != nil {
+		return nil, err
+	}
+	if r.value, err = load(); err != nil {
+		return nil, err
+	}
+	r.stamps, r.checked = stamps, time.Now()
+	return r, nil
+}
+
+// Get returns the value, reloading it first if the files have changed.
+func (r *Reloading[T]) Get() T {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if time.Since(r.checked) < r.interval {
+		return r.value
+	}
+	r.checked = time.Now()
+	stamps, err := r.stat()
+	if err != nil || equalStamps(stamps, r.stamps) {
+		return r.value
+	}
+	value, err := r.load()
+	if err != nil {
+		log.Printf("Failed to reload %s, keeping the previous version: %v", strings.Join(r.paths, ", "), err)
+		return r.value
+	}
+	log.Printf("Reloaded %s", strings.Join(r.paths, ", "))
+	old := r.value
+	r.value, r.stamps = value, stamps
+	if r.Changed != nil {
+		r.Changed(old)
+	}
+	return r.value
+}
+
+func (r *Reloading[T]) stat() ([]fileStamp, error) {
+	var stamps []fileStamp
+	for _, p := range r.paths {
+		info, err := os.Stat(p)
+		if err != nil {
+			return nil, err
+		}
+		stamps = append(stamps, fileStamp{modTime: info.ModTime(), size: info.Size()})
+	}
+	return stamps, nil
+}
+
+func equalStamps(a, b []fileStamp) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !a[i].modTime.Equal(b[i].modTime) || a[i].size != b[i].size {
+			return false
+		}
+	}
+	return true
+}
+
+// LoadCertPool reads a bundle of PEM CA certificates.
+func LoadCertPool(path string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA bundle: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("invalid CA bundle %s: no PEM certificates", path)
+	}
+	return pool, nil
+}
@@ -0,0 +1,201 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package toolkit
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/session"
+	"google.golang.org/adk/tool"
+	"google.golang.org/genai"
+)
+
+// --- Tool Call Approval ---
+//
+// A tool created with WithApproval(true) does not run when the model first
+// calls it. Instead it records the call in session state and answers with a
+// pending_approval response. Because such tools are long running, the model
+// event carries the call ID in LongRunningToolIDs, which is how the Web UI,
+// the REST API and the console client learn that a decision is needed.
+//
+// The client resumes by sending a user message holding a FunctionResponse
+// for that call ID with {"approved": true} or {"approved": false} and an
+// optional "reason". ResumeApprovedCalls, installed as a BeforeModelCallback
+// of the owning agent, then records the decision and, for approved calls,
+// re-issues the original call so the tool finally runs with the recorded
+// arguments. Denied calls are left for the model to report.
+
+const (
+	// toolsRequiringApprovalEnv lists, comma separated, the tools that must
+	// be approved by the user before they run.
+	toolsRequiringApprovalEnv = "TOOLS_REQUIRING_APPROVAL"
+
+	approvalKeyPrefix = "approval:"
+	// approvedCallPrefix prefixes the IDs of re-issued, approved calls.
+	approvedCallPrefix = "approved-"
+
+	approvalStatusPending  = "pending"
+	approvalStatusApproved = "approved"
+	approvalStatusDenied   = "denied"
+	approvalStatusExecuted = "executed"
+
+	// PendingApprovalStatus is the "status" of a pending_approval response.
+	PendingApprovalStatus = "pending_approval"
+
+	approvalToolNote = "NOTE: Every call to this tool must be approved by the user. " +
+		"If it returns a pending_approval status, tell the user that the call is waiting for their approval and do not call it again."
+)
+
+// ApprovalRequired reports whether the tool is listed in
+// TOOLS_REQUIRING_APPROVAL.
+func ApprovalRequired(toolName string) bool {
+	return slices.Contains(strings.Split(os.Getenv(toolsRequiringApprovalEnv), ","), toolName)
+}
+
+// approvalRecord tracks a gated call in session state under
+// approvalKeyPrefix + call ID.
+type approvalRecord struct {
+	Tool   string         `json:"tool"`
+	Args   map[string]any `json:"args"`
+	Status string         `json:"status"`
+	Reason string         `json:"reason,omitempty"`
+	// ApprovedCall is the ID of the call the user approved; it is only set
+	// on the record of the re-issued call.
+	ApprovedCall string `json:"approved_call,omitempty"`
+}
+
+func loadApproval(state session.State, callID string) (*approvalRecord, error) {
+	v, err := state.Get(approvalKeyPrefix + callID)
+	if errors.Is(err, session.ErrStateKeyNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read approval of call %q: %w", callID, err)
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read approval of call %q: %w", callID, err)
+	}
+	var rec approvalRecord
+	if err := json.Unmarshal(data, &rec); err != nil {
+		return nil, fmt.Errorf("failed to read approval of call %q: %w", callID, err)
+	}
+	return &rec, nil
+}
+
+func saveApproval(state session.State, callID string, rec *approvalRecord) error {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("failed to record approval of call %q: %w", callID, err)
+	}
+	var value map[string]any
+	if err := json.Unmarshal(data, &value); err != nil {
+		return fmt.Errorf("failed to record approval of call %q: %w", callID, err)
+	}
+	if err := state.Set(approvalKeyPrefix+callID, value); err != nil {
+		return fmt.Errorf("failed to record approval of call %q: %w", callID, err)
+	}
+	return nil
+}
+
+// requestApproval records the call as pending and stops the agent so the
+// client can ask the user.
+func requestApproval(tc tool.Context, toolName string, args map[string]any) map[string]any {
+	rec := &approvalRecord{Tool: toolName, Args: args, Status: approvalStatusPending}
+	if err := saveApproval(tc.State(), tc.FunctionCallID(), rec); err != nil {
+		return Error(CodeFailed, err.Error())
+	}
+	tc.Actions().SkipSummarization = true
+	return map[string]any{
+		"status":  PendingApprovalStatus,
+		"message": fmt.Sprintf("Calling %s requires the user's approval.", toolName),
+	}
+}
+
+// consumeApproval reports whether the current call is a re-issued call the
+// user approved, marking it executed so it cannot run twice.
+func consumeApproval(tc tool.Context) (bool, error) {
+	rec, err := loadApproval(tc.State(), tc.FunctionCallID())
+	if err != nil || rec == nil || rec.Status != approvalStatusApproved {
+		return false, err
+	}
+	rec.Status = approvalStatusExecuted
+	return true, saveApproval(tc.State(), tc.FunctionCallID(), rec)
+}
+
+// ResumeApprovedCalls is a BeforeModelCallback that applies the approval
+// decisions found in the user's message. If any call was approved, the model
+// call is skipped and the approved calls are issued in its place.
+func ResumeApprovedCalls(ctx agent.CallbackContext, req *model.LLMRequest) (*model.LLMResponse, error) {
+	content := ctx.UserContent()
+	if content == nil {
+		return nil, nil
+	}
+
+	var calls []*genai.Part
+	for _, part := range content.Parts {
+		fr := part.FunctionResponse
+		if fr == nil {
+			continue
+		}
+		rec, err := loadApproval(ctx.State(), fr.ID)
+		if err != nil {
+			return nil, err
+		}
+		if rec == nil || rec.Status != approvalStatusPending {
+			continue
+		}
+
+		approved, _ := fr.Response["approved"].(bool)
+		rec.Reason, _ = fr.Response["reason"].(string)
+		rec.Status = approvalStatusDenied
+		if approved {
+			rec.Status = approvalStatusApproved
+		}
+		if err := saveApproval(ctx.State(), fr.ID, rec); err != nil {
+			return nil, err
+		}
+		if !approved {
+			continue
+		}
+
+		id := approvedCallPrefix + fr.ID
+		if err := saveApproval(ctx.State(), id, &approvalRecord{
+			Tool:         rec.Tool,
+			Args:         rec.Args,
+			Status:       approvalStatusApproved,
+			ApprovedCall: fr.ID,
+		}); err != nil {
+			return nil, err
+		}
+		calls = append(calls, &genai.Part{
+			FunctionCall: &genai.FunctionCall{ID: id, Name: rec.Tool, Args: rec.Args},
+		})
+	}
+
+	if len(calls) == 0 {
+		return nil, nil
+	}
+	return &model.LLMResponse{
+		Content: &genai.Content{Role: genai.RoleModel, Parts: calls},
+	}, nil
+}
@@ -0,0 +1,206 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package toolkit
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/session"
+	"google.golang.org/adk/tool"
+	"google.golang.org/genai"
+
+	"a2a-common-go/internal/testutil"
+)
+
+// callContext is a tool context for the call with the given ID.
+type callContext struct {
+	tool.Context
+	id string
+}
+
+func (c callContext) FunctionCallID() string { return c.id }
+
+// userContext is a callback context whose user message is content.
+type userContext struct {
+	agent.CallbackContext
+	state   session.State
+	content *genai.Content
+}
+
+func (c userContext) State() session.State        { return c.state }
+func (c userContext) UserContent() *genai.Content { return c.content }
+
+// decide returns the user message deciding on the call with the ID.
+func decide(callID string, response map[string]any) *genai.Content {
+	return genai.NewContentFromParts([]*genai.Part{
+		{FunctionResponse: &genai.FunctionResponse{ID: callID, Name: "roll", Response: response}},
+	}, genai.RoleUser)
+}
+
+func approvalStatus(t *testing.T, state session.State, callID string) *approvalRecord {
+	t.Helper()
+	rec, err := loadApproval(state, callID)
+	if err != nil {
+		t.Fatalf("loadApproval(%q) error = %v", callID, err)
+	}
+	if rec == nil {
+		t.Fatalf("no approval recorded for call %q", callID)
+	}
+	return rec
+}
+
+// newApprovalTool returns a roll tool that needs approval and counts its
+// runs.
+func newApprovalTool(t *testing.T) (FunctionTool, *int) {
+	t.Helper()
+	runs := new(int)
+	return newRollTool(t, func(tc tool.Context, args rollArgs) (int, error) {
+		*runs++
+		return args.Sides, nil
+	}, WithApproval(true)), runs
+}
+
+func TestApprovalPending(t *testing.T) {
+	rt, runs := newApprovalTool(t)
+	tc := testutil.ToolContext(context.Background())
+	args := map[string]any{"sides": 6.0}
+
+	for range 2 {
+		got, err := rt.Run(tc, args)
+		if err != nil {
+			t.Fatalf("Run() error = %v", err)
+		}
+		if got["status"] != PendingApprovalStatus {
+			t.Errorf("Run() = %v, want status %q", got, PendingApprovalStatus)
+		}
+	}
+	if *runs != 0 {
+		t.Errorf("tool ran %d times before being approved", *runs)
+	}
+	if !tc.Actions().SkipSummarization {
+		t.Error("pending call does not stop the agent")
+	}
+	want := &approvalRecord{Tool: "roll", Args: args, Status: approvalStatusPending}
+	if got := approvalStatus(t, tc.State(), "call-1"); !reflect.DeepEqual(got, want) {
+		t.Errorf("approval = %+v, want %+v", got, want)
+	}
+
+	// Messages without a decision on a pending call change nothing.
+	for _, content := range []*genai.Content{
+		nil,
+		genai.NewContentFromText("go ahead", genai.RoleUser),
+		decide("call-2", map[string]any{"approved": true}),
+	} {
+		resp, err := ResumeApprovedCalls(userContext{state: tc.State(), content: content}, nil)
+		if err != nil || resp != nil {
+			t.Errorf("ResumeApprovedCalls(%v) = %v, %v, want nothing", content, resp, err)
+		}
+	}
+	if got := approvalStatus(t, tc.State(), "call-1").Status; got != approvalStatusPending {
+		t.Errorf("approval status = %q, want %q", got, approvalStatusPending)
+	}
+}
+
+func TestApprovalApprove(t *testing.T) {
+	rt, runs := newApprovalTool(t)
+	tc := testutil.ToolContext(context.Background())
+	args := map[string]any{"sides": 20.0}
+	if _, err := rt.Run(tc, args); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := userContext{state: tc.State(), content: decide("call-1", map[string]any{"approved": true})}
+	resp, err := ResumeApprovedCalls(ctx, nil)
+	if err != nil {
+		t.Fatalf("ResumeApprovedCalls() error = %v", err)
+	}
+	want := []*genai.Part{{FunctionCall: &genai.FunctionCall{ID: "approved-call-1", Name: "roll", Args: args}}}
+	if resp == nil || !reflect.DeepEqual(resp.Content.Parts, want) {
+		t.Fatalf("ResumeApprovedCalls() = %+v, want the approved call", resp)
+	}
+	if got := approvalStatus(t, tc.State(), "call-1").Status; got != approvalStatusApproved {
+		t.Errorf("approval status = %q, want %q", got, approvalStatusApproved)
+	}
+	// The decision applies once.
+	if resp, err := ResumeApprovedCalls(ctx, nil); err != nil || resp != nil {
+		t.Errorf("ResumeApprovedCalls() again = %v, %v, want nothing", resp, err)
+	}
+
+	approved := callContext{Context: tc, id: "approved-call-1"}
+	got, err := rt.Run(approved, args)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if got["result"] != 20.0 || *runs != 1 {
+		t.Errorf("Run() of the approved call = %v after %d runs, want 20 after 1", got, *runs)
+	}
+	rec := approvalStatus(t, tc.State(), "approved-call-1")
+	if rec.Status != approvalStatusExecuted || rec.ApprovedCall != "call-1" {
+		t.Errorf("approval of the approved call = %+v, want executed", rec)
+	}
+
+	// The approved call cannot run twice.
+	if got, err := rt.Run(approved, args); err != nil || got["status"] != PendingApprovalStatus || *runs != 1 {
+		t.Errorf("Run() of the executed call = %v, %v after %d runs, want it pending again", got, err, *runs)
+	}
+}
+
+func TestApprovalDeny(t *testing.T) {
+	tests := []struct {
+		name       string
+		response   map[string]any
+		wantReason string
+	}{
+		{name: "denied", response: map[string]any{"approved": false, "reason": "not now"}, wantReason: "not now"},
+		{name: "no answer", response: map[string]any{}},
+		{name: "malformed answer", response: map[string]any{"approved": "yes"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rt, runs := newApprovalTool(t)
+			tc := testutil.ToolContext(context.Background())
+			if _, err := rt.Run(tc, map[string]any{"sides": 6}); err != nil {
+				t.Fatal(err)
+			}
+
+			ctx := userContext{state: tc.State(), content: decide("call-1", tt.response)}
+			if resp, err := ResumeApprovedCalls(ctx, nil); err != nil || resp != nil {
+				t.Errorf("ResumeApprovedCalls() = %v, %v, want no calls", resp, err)
+			}
+			rec := approvalStatus(t, tc.State(), "call-1")
+			if rec.Status != approvalStatusDenied || rec.Reason != tt.wantReason {
+				t.Errorf("approval = %+v, want denied with reason %q", rec, tt.wantReason)
+			}
+			if rec, err := loadApproval(tc.State(), "approved-call-1"); err != nil || rec != nil {
+				t.Errorf("denied call re-issued: %+v, %v", rec, err)
+			}
+			if *runs != 0 {
+				t.Errorf("denied tool ran %d times", *runs)
+			}
+		})
+	}
+}
+
+func TestApprovalRequired(t *testing.T) {
+	t.Setenv(toolsRequiringApprovalEnv, "roll_dice,shell")
+	for name, want := range map[string]bool{"roll_dice": true, "shell": true, "roll": false, "": false} {
+		if got := ApprovalRequired(name); got != want {
+			t.Errorf("ApprovalRequired(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,205 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package toolkit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/tool"
+)
+
+// --- Tool Timeouts and Concurrency ---
+
+const (
+	// toolTimeoutEnv sets the default time a tool call may take, e.g. "30s".
+	toolTimeoutEnv = "TOOL_TIMEOUT"
+	// toolTimeoutsEnv overrides the timeout of single tools, e.g.
+	// "prime_checking=5s,roll_dice=2s".
+	toolTimeoutsEnv = "TOOL_TIMEOUTS"
+	// toolMaxConcurrencyEnv caps the number of tool calls running at once
+	// across all sessions served by the process.
+	toolMaxConcurrencyEnv = "TOOL_MAX_CONCURRENCY"
+
+	defaultToolTimeout        = 30 * time.Second
+	defaultToolMaxConcurrency = 8
+)
+
+// Limiter bounds how long tool calls may take and how many of them run
+// at the same time.
+type Limiter struct {
+	timeout  time.Duration
+	timeouts map[string]time.Duration // per-tool overrides
+	slots    chan struct{}
+}
+
+// newToolLimiter creates a limiter allowing maxConcurrent calls at once, each
+// limited to timeout unless overridden in timeouts.
+func newToolLimiter(timeout time.Duration, timeouts map[string]time.Duration, maxConcurrent int) *Limiter {
+	return &Limiter{
+		timeout:  timeout,
+		timeouts: timeouts,
+		slots:    make(chan struct{}, maxConcurrent),
+	}
+}
+
+// LimiterFromEnv creates a limiter configured by TOOL_TIMEOUT,
+// TOOL_TIMEOUTS and TOOL_MAX_CONCURRENCY.
+func LimiterFromEnv() (*Limiter, error) {
+	timeout := defaultToolTimeout
+	if v := os.Getenv(toolTimeoutEnv); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("invalid %s %q: must be a positive duration", toolTimeoutEnv, v)
+		}
+		timeout = d
+	}
+
+	timeouts := make(map[string]time.Duration)
+	if v := os.Getenv(toolTimeoutsEnv); v != "" {
+		for _, entry := range strings.Split(v, ",") {
+			name, value, ok := strings.Cut(strings.TrimSpace(entry), "=")
+			d, err := time.ParseDuration(value)
+			if !ok || name == "" || err != nil || d <= 0 {
+				return nil, fmt.Errorf("invalid %s entry %q: want name=duration", toolTimeoutsEnv, entry)
+			}
+			timeouts[name] = d
+		}
+	}
+
+	maxConcurrent := defaultToolMaxConcurrency
+	if v := os.Getenv(toolMaxConcurrencyEnv); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return nil, fmt.Errorf("invalid %s %q: must be a positive integer", toolMaxConcurrencyEnv, v)
+		}
+		maxConcurrent = n
+	}
+	return newToolLimiter(timeout, timeouts, maxConcurrent), nil
+}
+
+// Limit wraps t so its calls are subject to the limiter.
+func (l *Limiter) Limit(t tool.Tool) (tool.Tool, error) {
+	ft, ok := t.(FunctionTool)
+	if !ok {
+		return nil, fmt.Errorf("tool %q is not a function tool and cannot be limited", t.Name())
+	}
+	timeout := l.timeout
+	if d, ok := l.timeouts[t.Name()]; ok {
+		timeout = d
+	}
+	return &limitedTool{FunctionTool: ft, limiter: l, timeout: timeout}, nil
+}
+
+// LimitToolset wraps ts so the calls of its tools are subject to the
+// limiter.
+func (l *Limiter) LimitToolset(ts tool.Toolset) tool.Toolset {
+	return &limitedToolset{Toolset: ts, limiter: l}
+}
+
+// limitedToolset limits each tool of the toolset it wraps.
+type limitedToolset struct {
+	tool.Toolset
+	limiter *Limiter
+}
+
+// Tools implements tool.Toolset.
+func (s *limitedToolset) Tools(ctx agent.ReadonlyContext) ([]tool.Tool, error) {
+	tools, err := s.Toolset.Tools(ctx)
+	if err != nil {
+		return nil, err
+	}
+	limited := make([]tool.Tool, len(tools))
+	for i, t := range tools {
+		if limited[i], err = s.limiter.Limit(t); err != nil {
+			return nil, err
+		}
+	}
+	return limited, nil
+}
+
+// limitedTool runs a tool under the timeout and concurrency limits of its
+// limiter. When a call times out, or the invocation is cancelled, a
+// function-error response is returned to the model right away. The call
+// itself keeps its concurrency slot until the tool returns, so stuck tools
+// cannot pile up beyond the limit.
+type limitedTool struct {
+	FunctionTool
+	limiter *Limiter
+	timeout time.Duration
+}
+
+// ProcessRequest registers the wrapper, rather than the wrapped tool, so the
+// flow runs calls through the limiter.
+func (t *limitedTool) ProcessRequest(ctx tool.Context, req *model.LLMRequest) error {
+	return Register(ctx, req, t.FunctionTool, t)
+}
+
+// Run runs the wrapped tool with a deadline derived from the invocation.
+func (t *limitedTool) Run(tc tool.Context, args any) (map[string]any, error) {
+	ctx, cancel := context.WithTimeout(tc, t.timeout)
+	defer cancel()
+
+	select {
+	case t.limiter.slots <- struct{}{}:
+	case <-ctx.Done():
+		return t.timeoutError(ctx, "while waiting for a free tool slot"), nil
+	}
+
+	type outcome struct {
+		result map[string]any
+		err    error
+	}
+	done := make(chan outcome, 1)
+	dtc := newDetachedToolContext(ctx, tc)
+	go func() {
+		defer func() { <-t.limiter.slots }()
+		defer func() {
+			if r := recover(); r != nil {
+				log.Printf("Tool %q panicked: %v\n%s", t.Name(), r, debug.Stack())
+				done <- outcome{result: Error(CodePanicked, fmt.Sprintf("tool %q failed unexpectedly: %v", t.Name(), r))}
+			}
+		}()
+		result, err := t.FunctionTool.Run(dtc, args)
+		done <- outcome{result, err}
+	}()
+
+	select {
+	case o := <-done:
+		dtc.commit(tc.Actions())
+		return o.result, o.err
+	case <-ctx.Done():
+		dtc.abandon()
+		return t.timeoutError(ctx, "while running"), nil
+	}
+}
+
+func (t *limitedTool) timeoutError(ctx context.Context, during string) map[string]any {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		log.Printf("Tool %q timed out after %s %s", t.Name(), t.timeout, during)
+		return Error(CodeTimeout, fmt.Sprintf("tool %q did not finish within %s", t.Name(), t.timeout))
+	}
+	log.Printf("Tool %q was cancelled %s: %v", t.Name(), during, ctx.Err())
+	return Error(CodeTimeout, fmt.Sprintf("tool %q was cancelled: %v", t.Name(), ctx.Err()))
+}
@@ -0,0 +1,183 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package toolkit
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"google.golang.org/adk/tool"
+	"google.golang.org/adk/tool/functiontool"
+
+	"a2a-common-go/internal/testutil"
+)
+
+// newLimitedSleepTool returns the sleep tool of s, named name, limited by l.
+func newLimitedSleepTool(t *testing.T, l *Limiter, name string, s *sleepTool) tool.Tool {
+	t.Helper()
+	ft, err := NewValidatedTool(functiontool.Config{Name: name, Description: name}, s.sleep)
+	if err != nil {
+		t.Fatal(err)
+	}
+	limited, err := l.Limit(ft)
+	if err != nil {
+		t.Fatalf("Limit() error = %v", err)
+	}
+	return limited
+}
+
+func runTool(t *testing.T, tl tool.Tool, tc tool.Context, args map[string]any) map[string]any {
+	t.Helper()
+	result, err := tl.(FunctionTool).Run(tc, args)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	return result
+}
+
+func TestLimiterTimeout(t *testing.T) {
+	l := newToolLimiter(time.Hour, map[string]time.Duration{"quick": 20 * time.Millisecond}, 4)
+	s := &sleepTool{}
+	quick := newLimitedSleepTool(t, l, "quick", s)
+	slow := newLimitedSleepTool(t, l, "slow", s)
+
+	tc := testutil.ToolContext(t.Context())
+	if got, want := runTool(t, quick, tc, map[string]any{"ms": 1, "key": "done"}), map[string]any{"result": 1.0}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Run() = %v, want %v", got, want)
+	}
+	if got := tc.Actions().StateDelta["done"]; got != "call-1" {
+		t.Errorf("state delta = %v, want the change of the call", tc.Actions().StateDelta)
+	}
+
+	// The per-tool timeout applies to quick only.
+	want := Error(CodeTimeout, `tool "quick" did not finish within 20ms`)
+	if got := runTool(t, quick, tc, map[string]any{"ms": 5000, "key": "late"}); !reflect.DeepEqual(got, want) {
+		t.Errorf("Run() = %v, want %v", got, want)
+	}
+	if _, ok := tc.Actions().StateDelta["late"]; ok {
+		t.Error("state delta has the change of a timed out call")
+	}
+	if got := runTool(t, slow, tc, map[string]any{"ms": 50}); got["result"] != 50.0 {
+		t.Errorf("Run() = %v, want 50", got)
+	}
+
+	// Cancelling the invocation ends the call too.
+	ctx, cancel := context.WithCancel(t.Context())
+	time.AfterFunc(10*time.Millisecond, cancel)
+	got := runTool(t, slow, testutil.ToolContext(ctx), map[string]any{"ms": 5000})
+	if errorCode(got) != CodeTimeout {
+		t.Errorf("Run() of a cancelled call = %v, want a %s error", got, CodeTimeout)
+	}
+}
+
+func TestLimiterConcurrency(t *testing.T) {
+	l := newToolLimiter(time.Hour, nil, 2)
+	s := &sleepTool{}
+	limited := newLimitedSleepTool(t, l, "sleep", s)
+
+	var wg sync.WaitGroup
+	for range 6 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			runTool(t, limited, testutil.ToolContext(t.Context()), map[string]any{"ms": 20})
+		}()
+	}
+	wg.Wait()
+	if s.maxRunning != 2 {
+		t.Errorf("max running calls = %d, want 2", s.maxRunning)
+	}
+}
+
+func TestLimiterSlotKeptUntilReturn(t *testing.T) {
+	l := newToolLimiter(20*time.Millisecond, nil, 1)
+	release := make(chan struct{})
+	stuck, err := NewValidatedTool(functiontool.Config{Name: "stuck", Description: "stuck"}, func(tool.Context, sleepArgs) (int, error) {
+		<-release
+		return 0, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	limited, err := l.Limit(stuck)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tc := testutil.ToolContext(t.Context())
+	if got := runTool(t, limited, tc, map[string]any{"ms": 0}); errorCode(got) != CodeTimeout {
+		t.Fatalf("Run() = %v, want a %s error", got, CodeTimeout)
+	}
+	// The stuck call still holds the only slot.
+	want := Error(CodeTimeout, `tool "stuck" did not finish within 20ms`)
+	if got := runTool(t, limited, tc, map[string]any{"ms": 0}); !reflect.DeepEqual(got, want) {
+		t.Errorf("Run() while the slot is taken = %v, want %v", got, want)
+	}
+	close(release)
+	deadline := time.Now().Add(5 * time.Second)
+	for len(l.slots) > 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if len(l.slots) > 0 {
+		t.Error("slot not freed after the stuck calls returned")
+	}
+}
+
+func TestLimiterFromEnv(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		want    *Limiter
+		wantErr bool
+	}{
+		{
+			name: "defaults",
+			want: newToolLimiter(defaultToolTimeout, map[string]time.Duration{}, defaultToolMaxConcurrency),
+		},
+		{
+			name: "configured",
+			env:  map[string]string{toolTimeoutEnv: "5s", toolTimeoutsEnv: "prime_checking=1m, roll_dice=2s", toolMaxConcurrencyEnv: "3"},
+			want: newToolLimiter(5*time.Second, map[string]time.Duration{"prime_checking": time.Minute, "roll_dice": 2 * time.Second}, 3),
+		},
+		{name: "invalid timeout", env: map[string]string{toolTimeoutEnv: "soon"}, wantErr: true},
+		{name: "negative timeout", env: map[string]string{toolTimeoutEnv: "-1s"}, wantErr: true},
+		{name: "timeout without name", env: map[string]string{toolTimeoutsEnv: "=1s"}, wantErr: true},
+		{name: "timeout without duration", env: map[string]string{toolTimeoutsEnv: "roll_dice"}, wantErr: true},
+		{name: "zero concurrency", env: map[string]string{toolMaxConcurrencyEnv: "0"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, k := range []string{toolTimeoutEnv, toolTimeoutsEnv, toolMaxConcurrencyEnv} {
+				t.Setenv(k, tt.env[k])
+			}
+			got, err := LimiterFromEnv()
+			if tt.wantErr {
+				if err == nil {
+					t.Error("LimiterFromEnv() succeeded")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LimiterFromEnv() error = %v", err)
+			}
+			if got.timeout != tt.want.timeout || !reflect.DeepEqual(got.timeouts, tt.want.timeouts) || cap(got.slots) != cap(tt.want.slots) {
+				t.Errorf("LimiterFromEnv() = %v, %v, %d, want %v, %v, %d", got.timeout, got.timeouts, cap(got.slots), tt.want.timeout, tt.want.timeouts, cap(tt.want.slots))
+			}
+		})
+	}
+}
@@ -0,0 +1,281 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package toolkit
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"runtime/debug"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/artifact"
+	"google.golang.org/adk/tool"
+	"google.golang.org/adk/tool/functiontool"
+	"google.golang.org/genai"
+)
+
+// --- Parallel Function Calls ---
+//
+// The llmagent flow runs the function calls of a model response one after
+// another. To run independent calls at the same time, ParallelCalls provides
+// the run_in_parallel tool: the model passes it a list of calls of the other
+// tools, and it runs them on a bounded pool of workers and returns their
+// responses in the order of the calls. A failing call only affects its own
+// response. The flow itself is unchanged: calls the model makes directly,
+// e.g. three roll_die calls in one response, still run one after another.
+//
+// run_in_parallel is an ordinary tool, so its call goes through the flow
+// like any other, and each call it runs goes through the wrappers of the
+// tool called, e.g. the limiter. Only tools added with concurrent set to
+// true can be called this way. Long running tools, such as the ones needing
+// approval, never are, since their calls must reach the client.
+
+const (
+	// toolParallelismEnv sets how many calls of one run_in_parallel call
+	// may run at the same time.
+	toolParallelismEnv = "TOOL_PARALLELISM"
+
+	defaultToolParallelism = 4
+
+	parallelToolName = "run_in_parallel"
+)
+
+// ParallelCalls runs calls of the tools added to it concurrently. Each agent
+// using it needs its own instance, since tools are looked up by name.
+type ParallelCalls struct {
+	workers int
+
+	mu    sync.Mutex
+	tools map[string]parallelEntry
+}
+
+type parallelEntry struct {
+	tool       FunctionTool
+	concurrent bool
+}
+
+func newParallelCalls(workers int) *ParallelCalls {
+	return &ParallelCalls{
+		workers: workers,
+		tools:   make(map[string]parallelEntry),
+	}
+}
+
+// ParallelCallsFromEnv creates a ParallelCalls configured by
+// TOOL_PARALLELISM.
+func ParallelCallsFromEnv() (*ParallelCalls, error) {
+	workers := defaultToolParallelism
+	if v := os.Getenv(toolParallelismEnv); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return nil, fmt.Errorf("invalid %s %q: must be a positive integer", toolParallelismEnv, v)
+		}
+		workers = n
+	}
+	return newParallelCalls(workers), nil
+}
+
+// Add registers t, as it is given to the agent. Tools added with concurrent
+// set to false are known to run_in_parallel but refused by it, so the model
+// learns to call them on their own.
+func (p *ParallelCalls) Add(t tool.Tool, concurrent bool) error {
+	ft, ok := t.(FunctionTool)
+	if !ok {
+		return fmt.Errorf("tool %q is not a function tool and cannot run in parallel", t.Name())
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if _, ok := p.tools[t.Name()]; ok {
+		return fmt.Errorf("duplicate tool: %q", t.Name())
+	}
+	p.tools[t.Name()] = parallelEntry{tool: ft, concurrent: concurrent && !t.IsLongRunning()}
+	return nil
+}
+
+type parallelToolArgs struct {
+	Calls []parallelCall `json:"calls" jsonschema:"The calls to run at the same time." validate:"min=1"`
+}
+
+type parallelCall struct {
+	Tool string         `json:"tool" jsonschema:"The name of the tool to call."`
+	Args map[string]any `json:"args,omitempty" jsonschema:"The arguments of the call."`
+}
+
+type parallelToolResults struct {
+	Results []parallelResult `json:"results"`
+}
+
+type parallelResult struct {
+	Tool     string         `json:"tool"`
+	Response map[string]any `json:"response"`
+}
+
+// Tool returns the run_in_parallel tool, which calls the tools added so far.
+// It is not limited itself: the calls it runs are, by their own tools.
+func (p *ParallelCalls) Tool() (FunctionTool, error) {
+	p.mu.Lock()
+	var names []string
+	for name, e := range p.tools {
+		if e.concurrent {
+			names = append(names, name)
+		}
+	}
+	p.mu.Unlock()
+	slices.Sort(names)
+
+	return NewValidatedTool(functiontool.Config{
+		Name: parallelToolName,
+		Description: "Runs independent calls of the other tools at the same time and returns their responses in the order of the calls. " +
+			"Use it instead of calling the tools one after another when no call needs the result of another. " +
+			"The tools that can run in parallel are: " + strings.Join(names, ", ") + ".",
+	}, p.run)
+}
+
+// run starts the calls in order as workers become free, up to p.workers at
+// a time. The calls get detached tool contexts, which are merged into tc in
+// the order of the calls once all of them are done. If tc is cancelled
+// first, the calls not done yet are given up on and answered with an error.
+func (p *ParallelCalls) run(tc tool.Context, args parallelToolArgs) (parallelToolResults, error) {
+	results := make([]parallelResult, len(args.Calls))
+	contexts := make([]*detachedToolContext, len(args.Calls))
+	done := make([]chan struct{}, len(args.Calls))
+	workers := make(chan struct{}, p.workers)
+	artifacts := &sync.Mutex{}
+	for i, call := range args.Calls {
+		results[i].Tool = call.Tool
+	}
+
+	cancelled := func(i int) parallelResult {
+		return parallelResult{
+			Tool:     results[i].Tool,
+			Response: Error(CodeTimeout, fmt.Sprintf("tool %q was cancelled: %v", results[i].Tool, tc.Err())),
+		}
+	}
+
+start:
+	for i, call := range args.Calls {
+		p.mu.Lock()
+		e, ok := p.tools[call.Tool]
+		p.mu.Unlock()
+		switch {
+		case !ok:
+			results[i].Response = Error(CodeInvalidArguments, fmt.Sprintf("unknown tool %q", call.Tool))
+			continue
+		case !e.concurrent:
+			results[i].Response = Error(CodeInvalidArguments, fmt.Sprintf("tool %q cannot run in parallel, call it on its own", call.Tool))
+			continue
+		}
+
+		select {
+		case workers <- struct{}{}:
+		case <-tc.Done():
+			break start
+		}
+		sub := &parallelCallContext{Context: tc, callID: fmt.Sprintf("%s/%d", tc.FunctionCallID(), i), artifacts: artifacts}
+		dtc := newDetachedToolContext(tc, sub)
+		contexts[i] = dtc
+		done[i] = make(chan struct{})
+		go func() {
+			defer close(done[i])
+			defer func() { <-workers }()
+			defer func() {
+				if r := recover(); r != nil {
+					log.Printf("Tool %q panicked: %v\n%s", call.Tool, r, debug.Stack())
+					results[i].Response = Error(CodePanicked, fmt.Sprintf("tool %q failed unexpectedly: %v", call.Tool, r))
+				}
+			}()
+			args := call.Args
+			if args == nil {
+				args = map[string]any{}
+			}
+			response, err := e.tool.Run(dtc, args)
+			if err != nil {
+				response = Error(CodeFailed, err.Error())
+			}
+			results[i].Response = response
+		}()
+	}
+
+	out := make([]parallelResult, len(results))
+	for i := range results {
+		switch {
+		case done[i] == nil && results[i].Response == nil:
+			// Not started before tc was cancelled.
+			out[i] = cancelled(i)
+			continue
+		case done[i] == nil:
+			out[i] = results[i]
+			continue
+		}
+		finished := false
+		select {
+		case <-done[i]:
+			finished = true
+		case <-tc.Done():
+			// Calls that finished in time keep their results.
+			select {
+			case <-done[i]:
+				finished = true
+			default:
+			}
+		}
+		if finished {
+			contexts[i].commit(tc.Actions())
+			out[i] = results[i]
+			continue
+		}
+		// The call is abandoned: its goroutine may still write its result,
+		// which is not read anymore.
+		contexts[i].abandon()
+		out[i] = cancelled(i)
+	}
+	return parallelToolResults{Results: out}, nil
+}
+
+// parallelCallContext is the tool context of one call run by
+// run_in_parallel. Its calls share the artifacts of the run_in_parallel
+// call, so saving them is serialized.
+type parallelCallContext struct {
+	tool.Context
+	callID    string
+	artifacts *sync.Mutex
+}
+
+// FunctionCallID implements tool.Context.
+func (c *parallelCallContext) FunctionCallID() string {
+	return c.callID
+}
+
+// Artifacts implements agent.CallbackContext.
+func (c *parallelCallContext) Artifacts() agent.Artifacts {
+	return lockedArtifacts{Artifacts: c.Context.Artifacts(), mu: c.artifacts}
+}
+
+type lockedArtifacts struct {
+	agent.Artifacts
+	mu *sync.Mutex
+}
+
+func (a lockedArtifacts) Save(ctx context.Context, name string, data *genai.Part) (*artifact.SaveResponse, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.Artifacts.Save(ctx, name, data)
+}
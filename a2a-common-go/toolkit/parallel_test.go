@@ -0,0 +1,270 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package toolkit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"google.golang.org/adk/tool"
+	"google.golang.org/adk/tool/functiontool"
+
+	"a2a-common-go/internal/testutil"
+)
+
+type sleepArgs struct {
+	MS  int    `json:"ms"`
+	Key string `json:"key,omitempty"`
+}
+
+// sleepTool sleeps, saves the call's ID under its key if one is given, and
+// records how many of its calls ran at the same time.
+type sleepTool struct {
+	mu         sync.Mutex
+	running    int
+	maxRunning int
+}
+
+func (s *sleepTool) sleep(tc tool.Context, args sleepArgs) (int, error) {
+	s.mu.Lock()
+	s.running++
+	s.maxRunning = max(s.maxRunning, s.running)
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		s.running--
+		s.mu.Unlock()
+	}()
+
+	select {
+	case <-time.After(time.Duration(args.MS) * time.Millisecond):
+	case <-tc.Done():
+		return 0, tc.Err()
+	}
+	if args.Key != "" {
+		if err := tc.State().Set(args.Key, tc.FunctionCallID()); err != nil {
+			return 0, err
+		}
+	}
+	return args.MS, nil
+}
+
+func newParallelTestCalls(t *testing.T, workers int) (*ParallelCalls, *sleepTool) {
+	t.Helper()
+	s := &sleepTool{}
+	p := newParallelCalls(workers)
+	add := func(name string, handler toolFunc[sleepArgs, int], concurrent bool) {
+		ft, err := NewValidatedTool(functiontool.Config{Name: name, Description: name}, handler)
+		if err != nil {
+			t.Fatalf("NewValidatedTool(%q) error = %v", name, err)
+		}
+		if err := p.Add(ft, concurrent); err != nil {
+			t.Fatalf("Add(%q) error = %v", name, err)
+		}
+	}
+	add("sleep", s.sleep, true)
+	add("sleep_alone", s.sleep, false)
+	add("fail", func(tool.Context, sleepArgs) (int, error) { return 0, errors.New("no luck") }, true)
+	add("panic", func(tool.Context, sleepArgs) (int, error) { panic("boom") }, true)
+	return p, s
+}
+
+// runParallel calls the run_in_parallel tool and returns its results.
+func runParallel(t *testing.T, p *ParallelCalls, tc tool.Context, calls ...map[string]any) []parallelResult {
+	t.Helper()
+	pt, err := p.Tool()
+	if err != nil {
+		t.Fatalf("Tool() error = %v", err)
+	}
+	out, err := pt.Run(tc, map[string]any{"calls": calls})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	data, err := json.Marshal(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var res parallelToolResults
+	if err := json.Unmarshal(data, &res); err != nil || res.Results == nil {
+		t.Fatalf("Run() = %v, want results", out)
+	}
+	return res.Results
+}
+
+// errorCode returns the code of an error result.
+func errorCode(response map[string]any) any {
+	e, _ := response["error"].(map[string]any)
+	return e["code"]
+}
+
+func sleepCall(tool string, ms int) map[string]any {
+	return map[string]any{"tool": tool, "args": map[string]any{"ms": ms}}
+}
+
+func TestParallelCallsOrder(t *testing.T) {
+	p, s := newParallelTestCalls(t, 3)
+	tc := testutil.ToolContext(t.Context())
+
+	// Later calls finish first, but the results keep the order of the calls.
+	var calls []map[string]any
+	for ms := 60; ms > 0; ms -= 10 {
+		calls = append(calls, sleepCall("sleep", ms))
+	}
+	start := time.Now()
+	results := runParallel(t, p, tc, calls...)
+	elapsed := time.Since(start)
+
+	for i, r := range results {
+		want := map[string]any{"result": float64(60 - 10*i)}
+		if r.Tool != "sleep" || !reflect.DeepEqual(r.Response, want) {
+			t.Errorf("result %d = %s %v, want sleep %v", i, r.Tool, r.Response, want)
+		}
+	}
+	if s.maxRunning != 3 {
+		t.Errorf("max running calls = %d, want 3", s.maxRunning)
+	}
+	// Run one after another, the calls would take 210ms.
+	if elapsed >= 210*time.Millisecond {
+		t.Errorf("calls took %v, want them to overlap", elapsed)
+	}
+}
+
+func TestParallelCallsErrors(t *testing.T) {
+	p, _ := newParallelTestCalls(t, 2)
+	tc := testutil.ToolContext(t.Context())
+
+	results := runParallel(t, p, tc,
+		sleepCall("sleep", 1),
+		sleepCall("sleep_alone", 1),
+		sleepCall("unknown", 1),
+		sleepCall("fail", 1),
+		sleepCall("panic", 1),
+		map[string]any{"tool": "sleep", "args": map[string]any{"ms": "one"}},
+	)
+
+	want := []map[string]any{
+		{"result": 1.0},
+		Error(CodeInvalidArguments, `tool "sleep_alone" cannot run in parallel, call it on its own`),
+		Error(CodeInvalidArguments, `unknown tool "unknown"`),
+		Error(CodeFailed, "no luck"),
+		Error(CodePanicked, `tool "panic" failed unexpectedly: boom`),
+	}
+	for i, w := range want {
+		if !reflect.DeepEqual(results[i].Response, w) {
+			t.Errorf("result %d = %v, want %v", i, results[i].Response, w)
+		}
+	}
+	if code := errorCode(results[5].Response); code != CodeInvalidArguments {
+		t.Errorf("result 5 = %v, want an %s error", results[5].Response, CodeInvalidArguments)
+	}
+}
+
+func TestParallelCallsState(t *testing.T) {
+	p, _ := newParallelTestCalls(t, 4)
+	tc := testutil.ToolContext(t.Context())
+
+	// The first call finishes last, yet the second call's change wins since
+	// the changes are merged in the order of the calls.
+	runParallel(t, p, tc,
+		map[string]any{"tool": "sleep", "args": map[string]any{"ms": 30, "key": "last"}},
+		map[string]any{"tool": "sleep", "args": map[string]any{"ms": 1, "key": "last"}},
+		map[string]any{"tool": "sleep", "args": map[string]any{"ms": 1, "key": "third"}},
+	)
+
+	want := map[string]any{"last": "call-1/1", "third": "call-1/2"}
+	if got := tc.Actions().StateDelta; !reflect.DeepEqual(got, want) {
+		t.Errorf("state delta = %v, want %v", got, want)
+	}
+}
+
+func TestParallelCallsCancel(t *testing.T) {
+	p, _ := newParallelTestCalls(t, 1)
+	// block ignores the cancellation, so its call has to be abandoned.
+	release := make(chan struct{})
+	defer close(release)
+	block, err := NewValidatedTool(functiontool.Config{Name: "block", Description: "block"}, func(tc tool.Context, args sleepArgs) (int, error) {
+		<-release
+		return 0, tc.State().Set("late", true)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Add(block, true); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(t.Context(), 30*time.Millisecond)
+	defer cancel()
+	tc := testutil.ToolContext(ctx)
+
+	results := runParallel(t, p, tc,
+		sleepCall("sleep", 1),
+		sleepCall("block", 0),
+		sleepCall("sleep", 1),
+	)
+
+	if want := map[string]any{"result": 1.0}; !reflect.DeepEqual(results[0].Response, want) {
+		t.Errorf("result 0 = %v, want %v", results[0].Response, want)
+	}
+	want := []string{"block", "sleep"}
+	for i, r := range results[1:] {
+		if r.Tool != want[i] || errorCode(r.Response) != CodeTimeout {
+			t.Errorf("result %d = %s %v, want a %s error of %s", i+1, r.Tool, r.Response, CodeTimeout, want[i])
+		}
+	}
+	if _, ok := tc.Actions().StateDelta["late"]; ok {
+		t.Error("state delta has the change of a cancelled call")
+	}
+}
+
+func TestParallelCallsAdd(t *testing.T) {
+	p, _ := newParallelTestCalls(t, 1)
+
+	ft, err := NewValidatedTool(functiontool.Config{Name: "sleep", Description: "sleep"}, (&sleepTool{}).sleep)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Add(ft, true); err == nil {
+		t.Error("Add() of a duplicate tool succeeded")
+	}
+
+	long, err := NewValidatedTool(functiontool.Config{Name: "approve", Description: "approve"}, (&sleepTool{}).sleep, WithApproval(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Add(long, true); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	results := runParallel(t, p, testutil.ToolContext(t.Context()), sleepCall("approve", 1))
+	if want := Error(CodeInvalidArguments, `tool "approve" cannot run in parallel, call it on its own`); !reflect.DeepEqual(results[0].Response, want) {
+		t.Errorf("result = %v, want %v", results[0].Response, want)
+	}
+}
+
+func TestParallelCallsFromEnv(t *testing.T) {
+	t.Setenv(toolParallelismEnv, "0")
+	if _, err := ParallelCallsFromEnv(); err == nil {
+		t.Error("ParallelCallsFromEnv() with 0 workers succeeded")
+	}
+	t.Setenv(toolParallelismEnv, "2")
+	p, err := ParallelCallsFromEnv()
+	if err != nil || p.workers != 2 {
+		t.Errorf("ParallelCallsFromEnv() = %v, %v, want 2 workers", p, err)
+	}
+}
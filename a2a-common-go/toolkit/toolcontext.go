@@ -0,0 +1,197 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package toolkit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+	"maps"
+	"sync"
+	"time"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/artifact"
+	"google.golang.org/adk/session"
+	"google.golang.org/adk/tool"
+	"google.golang.org/genai"
+)
+
+// --- Detached Tool Context ---
+
+// errToolCallDetached is returned by a detached tool context once its call
+// has been given up on, e.g. after a timeout.
+var errToolCallDetached = errors.New("tool call is no longer awaited")
+
+// detachedToolContext lets a tool run on its own goroutine without touching
+// the event actions of the call it serves. State changes and actions are
+// collected privately and only merged by commit, so a call that is given up
+// on can keep running without racing the flow.
+type detachedToolContext struct {
+	tool.Context // parent, only used for read-only accessors
+	ctx          context.Context
+
+	mu       sync.Mutex
+	detached bool
+	base     map[string]any // parent state delta when the call started
+	actions  session.EventActions
+}
+
+// newDetachedToolContext creates a context for a call of parent whose
+// cancellation and deadline come from ctx.
+func newDetachedToolContext(ctx context.Context, parent tool.Context) *detachedToolContext {
+	return &detachedToolContext{
+		Context: parent,
+		ctx:     ctx,
+		base:    maps.Clone(parent.Actions().StateDelta),
+		actions: session.EventActions{StateDelta: make(map[string]any)},
+	}
+}
+
+// commit detaches the context and merges the state changes and actions of
+// the call into dst, the actions of the call's function response.
+func (c *detachedToolContext) commit(dst *session.EventActions) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.detached = true
+
+	if dst.StateDelta == nil {
+		dst.StateDelta = make(map[string]any)
+	}
+	maps.Copy(dst.StateDelta, c.actions.StateDelta)
+	if len(c.actions.ArtifactDelta) > 0 {
+		if dst.ArtifactDelta == nil {
+			dst.ArtifactDelta = make(map[string]int64)
+		}
+		maps.Copy(dst.ArtifactDelta, c.actions.ArtifactDelta)
+	}
+	dst.SkipSummarization = dst.SkipSummarization || c.actions.SkipSummarization
+	dst.Escalate = dst.Escalate || c.actions.Escalate
+	if c.actions.TransferToAgent != "" {
+		dst.TransferToAgent = c.actions.TransferToAgent
+	}
+}
+
+// abandon detaches the context, dropping whatever the call did to it.
+func (c *detachedToolContext) abandon() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.detached = true
+}
+
+// Deadline implements context.Context.
+func (c *detachedToolContext) Deadline() (time.Time, bool) { return c.ctx.Deadline() }
+
+// Done implements context.Context.
+func (c *detachedToolContext) Done() <-chan struct{} { return c.ctx.Done() }
+
+// Err implements context.Context.
+func (c *detachedToolContext) Err() error { return c.ctx.Err() }
+
+// Value implements context.Context.
+func (c *detachedToolContext) Value(key any) any { return c.ctx.Value(key) }
+
+// Actions implements tool.Context.
+func (c *detachedToolContext) Actions() *session.EventActions {
+	return &c.actions
+}
+
+// State implements agent.CallbackContext.
+func (c *detachedToolContext) State() session.State {
+	return detachedState{c}
+}
+
+// Artifacts implements agent.CallbackContext.
+func (c *detachedToolContext) Artifacts() agent.Artifacts {
+	return detachedArtifacts{Artifacts: c.Context.Artifacts(), c: c}
+}
+
+// detachedState reads through the private delta, the parent's delta and the
+// session state, in that order, and writes to the private delta only.
+type detachedState struct {
+	c *detachedToolContext
+}
+
+func (s detachedState) Get(key string) (any, error) {
+	s.c.mu.Lock()
+	defer s.c.mu.Unlock()
+	if v, ok := s.c.actions.StateDelta[key]; ok {
+		return v, nil
+	}
+	if v, ok := s.c.base[key]; ok {
+		return v, nil
+	}
+	return s.c.Context.ReadonlyState().Get(key)
+}
+
+func (s detachedState) Set(key string, value any) error {
+	s.c.mu.Lock()
+	defer s.c.mu.Unlock()
+	s.c.actions.StateDelta[key] = value
+	return nil
+}
+
+func (s detachedState) All() iter.Seq2[string, any] {
+	return func(yield func(string, any) bool) {
+		s.c.mu.Lock()
+		merged := maps.Clone(s.c.base)
+		if merged == nil {
+			merged = make(map[string]any)
+		}
+		maps.Copy(merged, s.c.actions.StateDelta)
+		s.c.mu.Unlock()
+
+		for k, v := range s.c.Context.ReadonlyState().All() {
+			if _, ok := merged[k]; ok {
+				continue
+			}
+			if !yield(k, v) {
+				return
+			}
+		}
+		for k, v := range merged {
+			if !yield(k, v) {
+				return
+			}
+		}
+	}
+}
+
+// detachedArtifacts refuses to save once the call is detached, because
+// saving may record the new version in the parent's actions. Saved versions
+// are also recorded privately, so they reach the function response even when
+// the parent is not the call's own tool context.
+type detachedArtifacts struct {
+	agent.Artifacts
+	c *detachedToolContext
+}
+
+func (a detachedArtifacts) Save(ctx context.Context, name string, data *genai.Part) (*artifact.SaveResponse, error) {
+	a.c.mu.Lock()
+	defer a.c.mu.Unlock()
+	if a.c.detached {
+		return nil, fmt.Errorf("failed to save artifact %q: %w", name, errToolCallDetached)
+	}
+	resp, err := a.Artifacts.Save(ctx, name, data)
+	if err != nil {
+		return nil, err
+	}
+	if a.c.actions.ArtifactDelta == nil {
+		a.c.actions.ArtifactDelta = make(map[string]int64)
+	}
+	a.c.actions.ArtifactDelta[name] = resp.Version
+	return resp, nil
+}
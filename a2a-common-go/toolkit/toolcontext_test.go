@@ -0,0 +1,142 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package toolkit
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"reflect"
+	"testing"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/artifact"
+	"google.golang.org/adk/session"
+	"google.golang.org/adk/tool"
+	"google.golang.org/genai"
+
+	"a2a-common-go/internal/testutil"
+)
+
+// artifactsContext is a tool context whose artifacts are savedArtifacts.
+type artifactsContext struct {
+	tool.Context
+	artifacts *savedArtifacts
+}
+
+func (c artifactsContext) Artifacts() agent.Artifacts { return c.artifacts }
+
+// savedArtifacts counts the versions saved of each artifact. Only Save may
+// be used.
+type savedArtifacts struct {
+	agent.Artifacts
+	versions map[string]int64
+}
+
+func (a *savedArtifacts) Save(_ context.Context, name string, _ *genai.Part) (*artifact.SaveResponse, error) {
+	a.versions[name]++
+	return &artifact.SaveResponse{Version: a.versions[name]}, nil
+}
+
+func TestDetachedToolContextState(t *testing.T) {
+	parent := testutil.ToolContext(t.Context(), map[string]any{"a": "session", "b": "session", "c": "session"})
+	if err := parent.State().Set("b", "parent"); err != nil {
+		t.Fatal(err)
+	}
+	dtc := newDetachedToolContext(t.Context(), parent)
+	if err := dtc.State().Set("c", "call"); err != nil {
+		t.Fatal(err)
+	}
+	// Changes of the parent after the call started are not seen.
+	if err := parent.State().Set("a", "later"); err != nil {
+		t.Fatal(err)
+	}
+
+	for key, want := range map[string]string{"b": "parent", "c": "call"} {
+		if got, err := dtc.State().Get(key); err != nil || got != want {
+			t.Errorf("Get(%q) = %v, %v, want %q", key, got, err, want)
+		}
+	}
+	if got := maps.Collect(dtc.State().All()); !reflect.DeepEqual(got, map[string]any{"a": "later", "b": "parent", "c": "call"}) {
+		t.Errorf("All() = %v", got)
+	}
+	if _, err := dtc.State().Get("missing"); !errors.Is(err, session.ErrStateKeyNotExist) {
+		t.Errorf("Get() of a missing key error = %v, want %v", err, session.ErrStateKeyNotExist)
+	}
+	if _, ok := parent.Actions().StateDelta["c"]; ok {
+		t.Error("Set() of the detached context changed the parent")
+	}
+}
+
+func TestDetachedToolContextCommit(t *testing.T) {
+	parent := testutil.ToolContext(t.Context())
+	dtc := newDetachedToolContext(t.Context(), parent)
+	if err := dtc.State().Set("key", "value"); err != nil {
+		t.Fatal(err)
+	}
+	dtc.Actions().Escalate = true
+	dtc.Actions().TransferToAgent = "other"
+
+	dtc.commit(parent.Actions())
+	got := parent.Actions()
+	if got.StateDelta["key"] != "value" || !got.Escalate || got.TransferToAgent != "other" {
+		t.Errorf("actions after commit = %+v", got)
+	}
+
+	parent = testutil.ToolContext(t.Context())
+	dtc = newDetachedToolContext(t.Context(), parent)
+	dtc.abandon()
+	if err := dtc.State().Set("key", "value"); err != nil {
+		t.Fatal(err)
+	}
+	if len(parent.Actions().StateDelta) > 0 {
+		t.Errorf("state delta of an abandoned call = %v, want none", parent.Actions().StateDelta)
+	}
+}
+
+func TestDetachedToolContextArtifacts(t *testing.T) {
+	artifacts := &savedArtifacts{versions: make(map[string]int64)}
+	parent := artifactsContext{Context: testutil.ToolContext(t.Context()), artifacts: artifacts}
+	dtc := newDetachedToolContext(t.Context(), parent)
+
+	if _, err := dtc.Artifacts().Save(t.Context(), "report.txt", genai.NewPartFromText("report")); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	dtc.commit(parent.Actions())
+	if got, want := parent.Actions().ArtifactDelta, map[string]int64{"report.txt": 1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("artifact delta = %v, want %v", got, want)
+	}
+
+	if _, err := dtc.Artifacts().Save(t.Context(), "late.txt", genai.NewPartFromText("late")); !errors.Is(err, errToolCallDetached) {
+		t.Errorf("Save() after detaching error = %v, want %v", err, errToolCallDetached)
+	}
+	if _, ok := artifacts.versions["late.txt"]; ok {
+		t.Error("artifact saved after detaching")
+	}
+}
+
+func TestDetachedToolContextDeadline(t *testing.T) {
+	ctx, cancel := context.WithCancel(t.Context())
+	dtc := newDetachedToolContext(ctx, testutil.ToolContext(t.Context()))
+	cancel()
+	select {
+	case <-dtc.Done():
+	default:
+		t.Fatal("Done() not closed after the call's context was cancelled")
+	}
+	if !errors.Is(dtc.Err(), context.Canceled) {
+		t.Errorf("Err() = %v, want %v", dtc.Err(), context.Canceled)
+	}
+}
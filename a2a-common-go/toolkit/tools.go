@@ -0,0 +1,360 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package toolkit builds function tools and wraps them with approval, time
+// and concurrency limits and parallel calls.
+package toolkit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"reflect"
+	"runtime/debug"
+	"strconv"
+	"strings"
+
+	"github.com/google/jsonschema-go/jsonschema"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/tool"
+	"google.golang.org/adk/tool/functiontool"
+	"google.golang.org/genai"
+)
+
+// --- Validated Function Tools ---
+
+// Error codes reported to the model in function-error responses.
+const (
+	CodeInvalidArguments = "invalid_arguments"
+	CodeFailed           = "tool_failed"
+	CodePanicked         = "tool_panicked"
+	CodeTimeout          = "tool_timeout"
+)
+
+// toolFunc is a tool handler that can report a failure back to the model.
+// Errors become tool_failed responses, or tool_timeout ones when they come
+// from the cancellation of the call's context.
+type toolFunc[TArgs, TResults any] func(tool.Context, TArgs) (TResults, error)
+
+// toolOptions holds the per-tool behavior that functiontool.Config does not
+// cover.
+type toolOptions struct {
+	requireApproval bool
+}
+
+// toolOption configures a validated tool.
+type toolOption func(*toolOptions)
+
+// WithApproval makes the tool wait for the user to approve each call before
+// it runs when required is true. See approval.go.
+func WithApproval(required bool) toolOption {
+	return func(o *toolOptions) {
+		o.requireApproval = required
+	}
+}
+
+// FunctionTool is a tool the flow declares to the model and runs, like the
+// tools made by functiontool.New.
+type FunctionTool interface {
+	tool.Tool
+	Declaration() *genai.FunctionDeclaration
+	ProcessRequest(ctx tool.Context, req *model.LLMRequest) error
+	Run(ctx tool.Context, args any) (map[string]any, error)
+}
+
+// Register lets inner add itself and its declaration to the request, then
+// puts wrapper in its place, so the flow runs the calls of inner through
+// wrapper.
+func Register(ctx tool.Context, req *model.LLMRequest, inner FunctionTool, wrapper tool.Tool) error {
+	if err := inner.ProcessRequest(ctx, req); err != nil {
+		return err
+	}
+	req.Tools[inner.Name()] = wrapper
+	return nil
+}
+
+// NewValidatedTool creates a function tool from a handler with
+// functiontool.New. The input schema is inferred from TArgs, and the
+// handler only runs once the arguments match it and the constraints found
+// in the `validate` struct tags of TArgs, e.g. `validate:"min=2,max=1000"`.
+// For numbers min and max bound the value, for strings and slices they bound
+// the length.
+//
+// Invalid arguments, handler errors and handler panics are all returned to
+// the model as a function-error response, see Error, so it can correct
+// itself and retry, instead of failing the invocation or crashing the
+// process.
+func NewValidatedTool[TArgs, TResults any](cfg functiontool.Config, handler toolFunc[TArgs, TResults], opts ...toolOption) (FunctionTool, error) {
+	var o toolOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	constraints, err := parseConstraints(reflect.TypeFor[TArgs]())
+	if err != nil {
+		return nil, fmt.Errorf("invalid constraints for tool %q: %w", cfg.Name, err)
+	}
+	if cfg.InputSchema == nil {
+		if cfg.InputSchema, err = jsonschema.For[TArgs](nil); err != nil {
+			return nil, fmt.Errorf("failed to infer input schema: %w", err)
+		}
+		for _, c := range constraints {
+			c.describe(cfg.InputSchema.Properties[c.name])
+		}
+	}
+	if o.requireApproval {
+		// Tools that need approval are long running, so the pending call is
+		// surfaced to the client.
+		cfg.IsLongRunning = true
+		cfg.Description += "\n\n" + approvalToolNote
+	}
+	// The handler results are wrapped into function responses, or replaced
+	// by errors, so they are not checked against the schema of TResults.
+	cfg.OutputSchema = nil
+
+	name := cfg.Name
+	run := func(tc tool.Context, args map[string]any) (result map[string]any) {
+		defer func() {
+			if r := recover(); r != nil {
+				log.Printf("Tool %q panicked: %v\n%s", name, r, debug.Stack())
+				result = Error(CodePanicked, fmt.Sprintf("tool %q failed unexpectedly: %v", name, r))
+			}
+		}()
+
+		input, err := decodeToolArgs[TArgs](args)
+		if err != nil {
+			return Error(CodeInvalidArguments, err.Error())
+		}
+		v := reflect.ValueOf(input)
+		for _, c := range constraints {
+			if err := c.check(v.FieldByIndex(c.index)); err != nil {
+				return Error(CodeInvalidArguments, err.Error())
+			}
+		}
+
+		if o.requireApproval {
+			approved, err := consumeApproval(tc)
+			if err != nil {
+				return Error(CodeFailed, err.Error())
+			}
+			if !approved {
+				return requestApproval(tc, name, args)
+			}
+		}
+
+		output, err := handler(tc, input)
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			return Error(CodeTimeout, fmt.Sprintf("tool %q was cancelled: %v", name, err))
+		}
+		if err != nil {
+			return Error(CodeFailed, err.Error())
+		}
+		result, err = encodeToolResult(output)
+		if err != nil {
+			return Error(CodeFailed, err.Error())
+		}
+		return result
+	}
+
+	inner, err := newFunctionTool(cfg, run)
+	if err != nil {
+		return nil, err
+	}
+	ft, ok := inner.(FunctionTool)
+	if !ok {
+		return nil, fmt.Errorf("tool %q is not a function tool", name)
+	}
+	return &validatedTool{FunctionTool: ft}, nil
+}
+
+// functionToolHandler is the handler newFunctionTool passes to
+// functiontool.New.
+type functionToolHandler = functiontool.Func[map[string]any, map[string]any]
+
+// newFunctionTool creates a function tool calling run. The handlers of
+// functiontool return a result up to ADK v0.1.0 and a result and an error
+// from v0.2.0 on, so the handler is built to fit the version in use.
+func newFunctionTool(cfg functiontool.Config, run func(tool.Context, map[string]any) map[string]any) (tool.Tool, error) {
+	t := reflect.TypeFor[functionToolHandler]()
+	handler := reflect.MakeFunc(t, func(in []reflect.Value) []reflect.Value {
+		tc, _ := in[0].Interface().(tool.Context)
+		args, _ := in[1].Interface().(map[string]any)
+		out := []reflect.Value{reflect.ValueOf(run(tc, args))}
+		if t.NumOut() == 2 {
+			out = append(out, reflect.Zero(t.Out(1)))
+		}
+		return out
+	}).Interface().(functionToolHandler)
+	return functiontool.New(cfg, handler)
+}
+
+// validatedTool reports the arguments that functiontool rejects, because they
+// do not match the input schema, to the model like the other invalid
+// arguments.
+type validatedTool struct {
+	FunctionTool
+}
+
+// ProcessRequest registers the wrapper in the LLM request.
+func (t *validatedTool) ProcessRequest(ctx tool.Context, req *model.LLMRequest) error {
+	return Register(ctx, req, t.FunctionTool, t)
+}
+
+// Run runs the function tool.
+func (t *validatedTool) Run(ctx tool.Context, args any) (map[string]any, error) {
+	result, err := t.FunctionTool.Run(ctx, args)
+	if err != nil {
+		return Error(CodeInvalidArguments, err.Error()), nil
+	}
+	return result, nil
+}
+
+// Error builds a function-error response. The "error" key is what the
+// model looks at to tell a failed call apart from a regular result.
+func Error(code, message string) map[string]any {
+	return map[string]any{
+		"error": map[string]any{
+			"code":    code,
+			"message": message,
+		},
+	}
+}
+
+func decodeToolArgs[TArgs any](args any) (TArgs, error) {
+	var input TArgs
+	data, err := json.Marshal(args)
+	if err != nil {
+		return input, fmt.Errorf("failed to encode arguments: %w", err)
+	}
+	if err := json.Unmarshal(data, &input); err != nil {
+		return input, fmt.Errorf("malformed arguments: %w", err)
+	}
+	return input, nil
+}
+
+// encodeToolResult converts a handler result into a function response. Like
+// functiontool, results that are not JSON objects are wrapped under "result".
+func encodeToolResult(output any) (map[string]any, error) {
+	data, err := json.Marshal(output)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode result: %w", err)
+	}
+	var m map[string]any
+	if err := json.Unmarshal(data, &m); err == nil && m != nil {
+		return m, nil
+	}
+	return map[string]any{"result": output}, nil
+}
+
+// --- Argument Constraints ---
+
+// fieldConstraint is the parsed `validate` tag of a single argument field.
+type fieldConstraint struct {
+	name     string // JSON name of the field
+	index    []int
+	kind     reflect.Kind
+	min, max *float64
+}
+
+func parseConstraints(t reflect.Type) ([]fieldConstraint, error) {
+	if t.Kind() != reflect.Struct {
+		return nil, nil
+	}
+	var constraints []fieldConstraint
+	for _, f := range reflect.VisibleFields(t) {
+		tag, ok := f.Tag.Lookup("validate")
+		if !ok || !f.IsExported() {
+			continue
+		}
+		c := fieldConstraint{name: f.Name, index: f.Index, kind: f.Type.Kind()}
+		if name, _, _ := strings.Cut(f.Tag.Get("json"), ","); name != "" && name != "-" {
+			c.name = name
+		}
+		for _, rule := range strings.Split(tag, ",") {
+			key, value, ok := strings.Cut(strings.TrimSpace(rule), "=")
+			if !ok {
+				return nil, fmt.Errorf("field %s: malformed rule %q", f.Name, rule)
+			}
+			n, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return nil, fmt.Errorf("field %s: rule %q: %w", f.Name, rule, err)
+			}
+			switch key {
+			case "min":
+				c.min = &n
+			case "max":
+				c.max = &n
+			default:
+				return nil, fmt.Errorf("field %s: unknown rule %q", f.Name, key)
+			}
+		}
+		constraints = append(constraints, c)
+	}
+	return constraints, nil
+}
+
+// describe adds the constraint to the description of the field's schema so
+// the model sees the allowed range up front. It is not added as a schema
+// keyword, which functiontool would enforce before the handler runs.
+func (c fieldConstraint) describe(s *jsonschema.Schema) {
+	if s == nil {
+		return
+	}
+	what := "The value"
+	switch c.kind {
+	case reflect.String, reflect.Slice, reflect.Array, reflect.Map:
+		what = "The length"
+	}
+	var rule string
+	switch {
+	case c.min != nil && c.max != nil:
+		rule = fmt.Sprintf("%s must be between %v and %v.", what, *c.min, *c.max)
+	case c.min != nil:
+		rule = fmt.Sprintf("%s must be at least %v.", what, *c.min)
+	case c.max != nil:
+		rule = fmt.Sprintf("%s must be at most %v.", what, *c.max)
+	default:
+		return
+	}
+	if s.Description != "" {
+		s.Description += " "
+	}
+	s.Description += rule
+}
+
+func (c fieldConstraint) check(v reflect.Value) error {
+	var n float64
+	what := c.name
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n = float64(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n = float64(v.Uint())
+	case reflect.Float32, reflect.Float64:
+		n = v.Float()
+	case reflect.String, reflect.Slice, reflect.Array, reflect.Map:
+		n = float64(v.Len())
+		what = "length of " + c.name
+	default:
+		return nil
+	}
+	if c.min != nil && n < *c.min {
+		return fmt.Errorf("%s must be at least %v, got %v", what, *c.min, n)
+	}
+	if c.max != nil && n > *c.max {
+		return fmt.Errorf("%s must be at most %v, got %v", what, *c.max, n)
+	}
+	return nil
+}
@@ -0,0 +1,184 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package toolkit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/google/jsonschema-go/jsonschema"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/tool"
+	"google.golang.org/adk/tool/functiontool"
+
+	"a2a-common-go/internal/testutil"
+)
+
+type rollArgs struct {
+	Sides int    `json:"sides" jsonschema:"The number of sides." validate:"min=2,max=100"`
+	Label string `json:"label,omitempty" validate:"max=5"`
+}
+
+func newRollTool(t *testing.T, handler toolFunc[rollArgs, int], opts ...toolOption) FunctionTool {
+	t.Helper()
+	rt, err := NewValidatedTool(functiontool.Config{Name: "roll", Description: "Rolls a die."}, handler, opts...)
+	if err != nil {
+		t.Fatalf("NewValidatedTool() error = %v", err)
+	}
+	return rt
+}
+
+func TestValidatedToolRun(t *testing.T) {
+	rt := newRollTool(t, func(tc tool.Context, args rollArgs) (int, error) {
+		switch args.Sides {
+		case 13:
+			return 0, errors.New("unlucky")
+		case 66:
+			panic("boom")
+		case 99:
+			return 0, fmt.Errorf("gave up: %w", context.DeadlineExceeded)
+		}
+		return args.Sides, nil
+	})
+
+	tests := []struct {
+		name string
+		args any
+		want map[string]any
+	}{
+		{
+			name: "valid",
+			args: map[string]any{"sides": 6},
+			want: map[string]any{"result": 6.0},
+		},
+		{
+			name: "below min",
+			args: map[string]any{"sides": 1},
+			want: Error(CodeInvalidArguments, "sides must be at least 2, got 1"),
+		},
+		{
+			name: "above max",
+			args: map[string]any{"sides": 101},
+			want: Error(CodeInvalidArguments, "sides must be at most 100, got 101"),
+		},
+		{
+			name: "string too long",
+			args: map[string]any{"sides": 6, "label": "abcdef"},
+			want: Error(CodeInvalidArguments, "length of label must be at most 5, got 6"),
+		},
+		{
+			name: "handler error",
+			args: map[string]any{"sides": 13},
+			want: Error(CodeFailed, "unlucky"),
+		},
+		{
+			name: "handler panic",
+			args: map[string]any{"sides": 66},
+			want: Error(CodePanicked, `tool "roll" failed unexpectedly: boom`),
+		},
+		{
+			name: "handler cancelled",
+			args: map[string]any{"sides": 99},
+			want: Error(CodeTimeout, `tool "roll" was cancelled: gave up: context deadline exceeded`),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := rt.Run(testutil.ToolContext(context.Background()), tt.args)
+			if err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Run() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidatedToolSchemaErrors(t *testing.T) {
+	rt := newRollTool(t, func(tc tool.Context, args rollArgs) (int, error) {
+		t.Errorf("handler called with %+v", args)
+		return 0, nil
+	})
+
+	for _, args := range []any{
+		map[string]any{"sides": "six"},
+		map[string]any{"label": "d6"},
+		"sides=6",
+	} {
+		got, err := rt.Run(testutil.ToolContext(context.Background()), args)
+		if err != nil {
+			t.Fatalf("Run(%v) error = %v", args, err)
+		}
+		e, _ := got["error"].(map[string]any)
+		if e == nil || e["code"] != CodeInvalidArguments || e["message"] == "" {
+			t.Errorf("Run(%v) = %v, want an %s error", args, got, CodeInvalidArguments)
+		}
+	}
+}
+
+func TestValidatedToolDeclaration(t *testing.T) {
+	decl := newRollTool(t, nil).Declaration()
+	schema, ok := decl.ParametersJsonSchema.(*jsonschema.Schema)
+	if !ok {
+		t.Fatalf("ParametersJsonSchema = %T, want *jsonschema.Schema", decl.ParametersJsonSchema)
+	}
+	if got, want := schema.Properties["sides"].Description, "The number of sides. The value must be between 2 and 100."; got != want {
+		t.Errorf("sides description = %q, want %q", got, want)
+	}
+	if got, want := schema.Properties["label"].Description, "The length must be at most 5."; got != want {
+		t.Errorf("label description = %q, want %q", got, want)
+	}
+	if schema.Properties["sides"].Minimum != nil || schema.Properties["sides"].Maximum != nil {
+		t.Errorf("sides schema has bounds %v, %v, want them checked by the handler only", schema.Properties["sides"].Minimum, schema.Properties["sides"].Maximum)
+	}
+
+	approved := newRollTool(t, nil, WithApproval(true))
+	if !approved.IsLongRunning() || !strings.Contains(approved.Declaration().Description, approvalToolNote) {
+		t.Errorf("tool with approval: IsLongRunning() = %v, description %q, want long running with the approval note", approved.IsLongRunning(), approved.Declaration().Description)
+	}
+}
+
+func TestValidatedToolBadConstraints(t *testing.T) {
+	type badArgs struct {
+		N int `json:"n" validate:"min=two"`
+	}
+	_, err := NewValidatedTool(functiontool.Config{Name: "bad"}, func(tool.Context, badArgs) (int, error) { return 0, nil })
+	if err == nil {
+		t.Errorf("NewValidatedTool() error = nil, want error for a malformed validate tag")
+	}
+}
+
+func TestRegister(t *testing.T) {
+	rt := newRollTool(t, nil)
+	limited, err := newToolLimiter(defaultToolTimeout, nil, 1).Limit(rt)
+	if err != nil {
+		t.Fatalf("Limit() error = %v", err)
+	}
+	req := &model.LLMRequest{}
+	if err := limited.(FunctionTool).ProcessRequest(testutil.ToolContext(context.Background()), req); err != nil {
+		t.Fatalf("ProcessRequest() error = %v", err)
+	}
+	if req.Tools["roll"] != limited {
+		t.Errorf("req.Tools[roll] = %T, want the outermost wrapper", req.Tools["roll"])
+	}
+	if len(req.Config.Tools) != 1 || len(req.Config.Tools[0].FunctionDeclarations) != 1 || req.Config.Tools[0].FunctionDeclarations[0].Name != "roll" {
+		t.Errorf("req.Config.Tools = %v, want the declaration of roll", req.Config.Tools)
+	}
+}
@@ -14,8 +14,11 @@ import (
 	"google.golang.org/adk/cmd/launcher/full"
 	"google.golang.org/adk/model/gemini"
 	"google.golang.org/adk/tool"
+	"google.golang.org/adk/tool/agenttool"
 	"google.golang.org/adk/tool/geminitool"
 	"google.golang.org/genai"
+
+	"a2a-common-go/mcptools"
 )
 
 const (
@@ -63,14 +66,23 @@ func run(ctx context.Context) error {
 		return fmt.Errorf("failed to create model: %w", err)
 	}
 
+	mcp, err := mcptools.ConfigFromEnv()
+	if err != nil {
+		return err
+	}
+	defer mcp.Close()
+	tools, toolsets, err := newTools(model, mcp)
+	if err != nil {
+		return err
+	}
+
 	ag, err := llmagent.New(llmagent.Config{
 		Name:        agentName,
 		Model:       model,
 		Description: "Tells the current time in a specified city.",
 		Instruction: "You are a helpful assistant that tells the current time in a city.",
-		Tools: []tool.Tool{
-			geminitool.GoogleSearch{},
-		},
+		Tools:       tools,
+		Toolsets:    toolsets,
 	})
 	if err != nil {
 		return fmt.Errorf("failed to create agent: %w", err)
@@ -91,6 +103,32 @@ func run(ctx context.Context) error {
 	return nil
 }
 
+// newTools returns Google Search plus the tools of the MCP servers the agent
+// is configured to use, if any.
+func newTools(model model.LLM, mcp *mcptools.Config) ([]tool.Tool, []tool.Toolset, error) {
+	toolsets, err := mcp.ToolsetsFor(agentName)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to configure MCP servers: %w", err)
+	}
+	if len(toolsets) == 0 {
+		return []tool.Tool{geminitool.GoogleSearch{}}, nil, nil
+	}
+
+	// Gemini cannot use Google Search and function calling in the same
+	// request, so searching moves to an agent of its own.
+	searchAgent, err := llmagent.New(llmagent.Config{
+		Name:        "google_search_agent",
+		Model:       model,
+		Description: "Searches the web with Google Search.",
+		Instruction: "Answer the request using Google Search.",
+		Tools:       []tool.Tool{geminitool.GoogleSearch{}},
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create search agent: %w", err)
+	}
+	return []tool.Tool{agenttool.New(searchAgent, nil)}, toolsets, nil
+}
+
 // singleAgentLoader adapts a single agent instance to the AgentLoader interface.
 type singleAgentLoader struct {
 	agent agent.Agent
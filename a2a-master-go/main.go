@@ -16,16 +16,14 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
 	"log"
-	"math/rand"
-	"strconv"
-
+	"net/http"
+	"os"
 
 	"google.golang.org/adk/agent"
 	"google.golang.org/adk/agent/llmagent"
-	"google.golang.org/adk/agent/remoteagent"
-	adkservices "google.golang.org/adk/server/restapi/services"
 
 	"google.golang.org/adk/model/gemini"
 
@@ -33,33 +31,72 @@ import (
 	"google.golang.org/adk/tool"
 	"google.golang.org/adk/tool/functiontool"
 
-        "google.golang.org/adk/cmd/launcher/adk"
-        "google.golang.org/adk/cmd/launcher/web"
-        "google.golang.org/adk/cmd/launcher/web/a2a"
-        
-
 	"google.golang.org/genai"
+
+	"a2a-common-go/a2aserver"
+	"a2a-common-go/calc"
+	"a2a-common-go/dice"
+	"a2a-common-go/flagutil"
+	"a2a-common-go/mcptools"
+	"a2a-common-go/openapi"
+	"a2a-common-go/remote"
+	"a2a-common-go/shell"
+	"a2a-common-go/toolkit"
 )
 
 // --- Local Roll Agent ---
 
-type rollDieToolArgs struct {
-	Sides int `json:"sides" jsonschema:"The number of sides on the die."`
-}
-
-func rollDieTool(tc tool.Context, args rollDieToolArgs) int {
-	return rand.Intn(args.Sides) + 1
-}
-
-func newRollAgent(ctx context.Context) (agent.Agent, error) {
-	rollTool, err := functiontool.New(functiontool.Config{
+func newRollAgent(ctx context.Context, roller *dice.Roller, limiter *toolkit.Limiter, apiTools []tool.Tool) (agent.Agent, error) {
+	rollTool, err := toolkit.NewValidatedTool(functiontool.Config{
 		Name:        "roll_die",
 		Description: "Roll a die and return the rolled result.",
-	}, rollDieTool)
+	}, roller.RollDieTool, toolkit.WithApproval(toolkit.ApprovalRequired("roll_die")))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create roll_die tool: %w", err)
 	}
 
+	diceTool, err := toolkit.NewValidatedTool(functiontool.Config{
+		Name:        "roll_dice",
+		Description: "Roll dice written in standard dice notation and return every roll, the kept and dropped dice and the total.",
+	}, roller.RollDiceTool, toolkit.WithApproval(toolkit.ApprovalRequired("roll_dice")))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create roll_dice tool: %w", err)
+	}
+
+	calcTool, err := calc.NewTool()
+	if err != nil {
+		return nil, err
+	}
+
+	calls, err := toolkit.ParallelCallsFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	// Seeded rolls must run in order to be replayable; calculations have no
+	// side effects and API tools tell whether they do.
+	tools := []tool.Tool{rollTool, diceTool, calcTool}
+	concurrent := []bool{roller.Concurrent(), roller.Concurrent(), true}
+	for _, t := range apiTools {
+		tools = append(tools, t)
+		// Tools of another kind may have side effects, so they run alone.
+		api, ok := t.(*openapi.Tool)
+		concurrent = append(concurrent, ok && api.ReadOnly())
+	}
+	for i, t := range tools {
+		if tools[i], err = limiter.Limit(t); err != nil {
+			return nil, err
+		}
+		if err := calls.Add(tools[i], concurrent[i]); err != nil {
+			return nil, err
+		}
+	}
+	// run_in_parallel is not limited: the calls it runs are.
+	parallelTool, err := calls.Tool()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create run_in_parallel tool: %w", err)
+	}
+	tools = append(tools, parallelTool)
+
 	model, err := gemini.NewModel(ctx, "gemini-2.5-flash", &genai.ClientConfig{})
 	if err != nil {
 		return nil, fmt.Errorf("failed to create model for roll agent: %w", err)
@@ -67,34 +104,26 @@ func newRollAgent(ctx context.Context) (agent.Agent, error) {
 
 	return llmagent.New(llmagent.Config{
 		Name:        "roll_agent",
-		Description: "Handles rolling dice of different sizes.",
-		Instruction: "You are responsible for rolling dice based on the user's request. When asked to roll a die, you must call the roll_die tool with the number of sides as an integer.",
-		Model:       model,
-		Tools:       []tool.Tool{rollTool},
-	})
-}
-
-// --- Remote Prime Agent ---
-
-// --8<-- [start:new-prime-agent]
-func newPrimeAgent() (agent.Agent, error) {
-	remoteAgent, err := remoteagent.New(remoteagent.A2AConfig{
-		Name:            "prime_agent",
-		Description:     "Agent that handles checking if numbers are prime.",
-		AgentCardSource: "http://localhost:8086",
+		Description: "Handles rolling dice of different sizes, including standard dice notation like 3d6+2.",
+		Instruction: `
+      You are responsible for rolling dice based on the user's request.
+      When asked to roll a single die, you must call the roll_die tool with the number of sides as an integer.
+      When the request uses dice notation or needs several dice, modifiers, dropped or kept dice, or exploding dice
+      (for example "3d6+2", "4d6 drop lowest", "2d20 keep highest" or "3d6!"), call the roll_dice tool with the expression.
+      Report the individual rolls, any dropped dice and the total.
+      Use the calculate tool for any arithmetic on the rolls.
+      To make several independent rolls or calculations at once, call run_in_parallel with all of them.
+    `,
+		Model:                model,
+		Tools:                tools,
+		BeforeModelCallbacks: []llmagent.BeforeModelCallback{toolkit.ResumeApprovedCalls},
 	})
-	if err != nil {
-		return nil, fmt.Errorf("failed to create remote prime agent: %w", err)
-	}
-	return remoteAgent, nil
 }
 
-// --8<-- [end:new-prime-agent]
-
 // --- Root Agent ---
 
 // --8<-- [start:new-root-agent]
-func newRootAgent(ctx context.Context, rollAgent, primeAgent agent.Agent) (agent.Agent, error) {
+func newRootAgent(ctx context.Context, rollAgent agent.Agent, remoteAgents []agent.Agent, health *remote.Health, router *remote.SkillRouter, tools []tool.Tool, toolsets []tool.Toolset) (agent.Agent, error) {
 	model, err := gemini.NewModel(ctx, "gemini-2.0-flash", &genai.ClientConfig{})
 	if err != nil {
 		return nil, err
@@ -102,21 +131,31 @@ func newRootAgent(ctx context.Context, rollAgent, primeAgent agent.Agent) (agent
 	return llmagent.New(llmagent.Config{
 		Name:  "root_agent",
 		Model: model,
-		Instruction: `
-      You are a helpful assistant that can roll dice and check if numbers are prime.
-      You delegate rolling dice tasks to the roll_agent and prime checking tasks to the prime_agent.
-      Follow these steps:
-      1. If the user asks to roll a die, delegate to the roll_agent.
-      2. If the user asks to check primes, delegate to the prime_agent.
-      3. If the user asks to roll a die and then check if the result is prime, call roll_agent first, then pass the result to prime_agent.
-      Always clarify the results before proceeding.
-    `,
-		SubAgents: []agent.Agent{rollAgent, primeAgent},
-		Tools:     []tool.Tool{},
+		InstructionProvider: func(agent.ReadonlyContext) (string, error) {
+			return rootInstruction + router.Delegation(), nil
+		},
+		SubAgents:            append([]agent.Agent{rollAgent}, remoteAgents...),
+		Tools:                tools,
+		Toolsets:             toolsets,
+		BeforeModelCallbacks: []llmagent.BeforeModelCallback{toolkit.ResumeApprovedCalls, health.InstructOffline, router.Route},
 	})
 }
 
-
+// rootInstruction is followed by the delegation instructions generated by the
+// skill router.
+const rootInstruction = `You are a helpful assistant that can roll dice, check if numbers are prime and delegate other requests to the agents below.
+Always clarify the results before proceeding.
+Use the calculate tool for arithmetic, e.g. to sum rolls before checking the total, instead of computing it yourself.
+For other requests, use your tools if one of them can help.
+`
+
+// rollAgentSkills describe roll_agent to the skill router.
+var rollAgentSkills = []remote.RouteSkill{{
+	Name:        "roll_dice",
+	Description: "Rolls dice of any size, including dice notation like 3d6+2.",
+	Tags:        []string{"roll", "dice", "die"},
+	Examples:    []string{"Roll a 6-sided die.", "Roll 3d6+2."},
+}}
 
 // --8<-- [end:new-root-agent]
 
@@ -125,20 +164,136 @@ func newRootAgent(ctx context.Context, rollAgent, primeAgent agent.Agent) (agent
 func main() {
 	ctx := context.Background()
 
-	primeAgent, err := newPrimeAgent()
+	fs := flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+	serverCfg, err := a2aserver.NewConfig(fs, 8092)
+	if err != nil {
+		log.Fatalf("Invalid server configuration: %v", err)
+	}
+	remoteCfg, err := remote.NewConfig(fs)
+	if err != nil {
+		log.Fatalf("Invalid remote agents configuration: %v", err)
+	}
+	preRoute, err := remote.SkillRouterFlag(fs)
+	if err != nil {
+		log.Fatalf("Invalid skill router configuration: %v", err)
+	}
+	push, err := remote.PushFlag(fs)
+	if err != nil {
+		log.Fatalf("Invalid remote push configuration: %v", err)
+	}
+	if err := flagutil.Parse(fs, os.Args[1:]); err != nil {
+		log.Fatalf("Invalid arguments: %v", err)
+	}
+	if err := serverCfg.Validate(); err != nil {
+		log.Fatalf("Invalid server configuration: %v", err)
+	}
+	handlers := make(map[string]http.Handler)
+	if *push {
+		receiver, err := remote.NewPushReceiver(serverCfg.PublicURL)
+		if err != nil {
+			log.Fatalf("Invalid remote push configuration: %v", err)
+		}
+		remote.UsePush(receiver)
+		// Not protected: pushes are authenticated by the token of each call.
+		handlers[remote.PushPath] = receiver
+		log.Printf("Waiting for remote tasks on %s", receiver.URL)
+	}
+
+	remoteSpecs, err := remoteCfg.Agents()
+	if err != nil {
+		log.Fatalf("Invalid remote agents configuration: %v", err)
+	}
+	transport, err := remoteCfg.TLS.Transport()
 	if err != nil {
-		log.Fatalf("Failed to create prime agent: %v", err)
+		log.Fatalf("Invalid remote TLS configuration: %v", err)
 	}
+	remote.UseTransport(transport)
+	registry, err := remoteCfg.Registry(remoteSpecs)
+	if err != nil {
+		log.Fatalf("Invalid agent registry configuration: %v", err)
+	}
+	if remoteSpecs, err = registry.Load(ctx); err != nil {
+		log.Fatalf("Failed to load agent registry: %v", err)
+	}
+	health := remote.NewHealth(remoteSpecs, remoteCfg.Breaker)
+	go health.Watch(ctx, remoteCfg.HealthInterval)
+	// The status names the remote agents, so it is as private as the agent.
+	handlers["/status"] = serverCfg.Protect(health)
 
-	rollAgent, err := newRollAgent(ctx)
+	roller, err := dice.RollerFromEnv()
+	if err != nil {
+		log.Fatalf("Failed to create die roller: %v", err)
+	}
+	log.Printf("Rolling dice with %s randomness", roller.Source())
+
+	limiter, err := toolkit.LimiterFromEnv()
+	if err != nil {
+		log.Fatalf("Failed to create tool limiter: %v", err)
+	}
+
+	openAPI, err := openapi.ConfigFromEnv()
+	if err != nil {
+		log.Fatalf("Failed to load OpenAPI config: %v", err)
+	}
+	apiTools, err := openAPI.ToolsFor("roll_agent")
+	if err != nil {
+		log.Fatalf("Failed to create OpenAPI tools: %v", err)
+	}
+
+	rollAgent, err := newRollAgent(ctx, roller, limiter, apiTools)
 	if err != nil {
 		log.Fatalf("Failed to create roll agent: %v", err)
 	}
 
-	rootAgent, err := newRootAgent(ctx, rollAgent, primeAgent)
+	mcp, err := mcptools.ConfigFromEnv()
+	if err != nil {
+		log.Fatalf("Failed to load MCP config: %v", err)
+	}
+	defer mcp.Close()
+	rootToolsets, err := mcp.ToolsetsFor("root_agent")
+	if err != nil {
+		log.Fatalf("Failed to configure MCP servers: %v", err)
+	}
+	for i, ts := range rootToolsets {
+		rootToolsets[i] = limiter.LimitToolset(ts)
+	}
+	calcTool, err := calc.NewTool()
+	if err != nil {
+		log.Fatalf("Failed to create calculator: %v", err)
+	}
+	rootTools := []tool.Tool{calcTool}
+
+	commands, err := shell.CommandRunnerFromEnv()
 	if err != nil {
+		log.Fatalf("Failed to configure run_command tool: %v", err)
+	}
+	if commands != nil {
+		runTool, err := commands.NewTool()
+		if err != nil {
+			log.Fatalf("Failed to create run_command tool: %v", err)
+		}
+		log.Printf("Commands run in %s", commands.Dir)
+		rootTools = append(rootTools, runTool)
+	}
+	for i, t := range rootTools {
+		if rootTools[i], err = limiter.Limit(t); err != nil {
+			log.Fatalf("Failed to limit tool: %v", err)
+		}
+	}
+
+	router := remote.NewSkillRouter([]remote.RouteTarget{{Name: rollAgent.Name(), Description: rollAgent.Description(), Skills: rollAgentSkills}}, health, *preRoute)
+	root := remote.NewLiveRoot(health, func(remoteAgents []agent.Agent) (agent.Agent, error) {
+		return newRootAgent(ctx, rollAgent, remoteAgents, health, router, rootTools, rootToolsets)
+	})
+	if err := root.Update(ctx, remoteSpecs); err != nil {
 		log.Fatalf("Failed to create root agent: %v", err)
 	}
+	go registry.Watch(ctx, remoteCfg.RegistryInterval, remoteSpecs, func(specs []remote.AgentSpec) {
+		if err := root.Update(ctx, specs); err != nil {
+			log.Printf("Failed to update remote agents, keeping the previous ones: %v", err)
+		}
+	})
+	rootAgent := root.Current()
 
 	sessionService := session.InMemoryService()
 
@@ -151,29 +306,14 @@ func main() {
 		log.Fatalf("Failed to create session: %v", err)
 	}
 
-
-
-        port := 8092
-        launcher := web.NewLauncher(a2a.NewLauncher())
-        _, parseErr := launcher.Parse([]string{
-                "--port", strconv.Itoa(port),
-                "a2a", "--a2a_agent_url", "http://0.0.0.0:" + strconv.Itoa(port),
-        })
-        if parseErr != nil {
-                log.Fatalf("launcher.Parse() error = %v", parseErr)
-        }
-
-        // Create ADK config
-        config := &adk.Config{
-                AgentLoader:    adkservices.NewSingleAgentLoader(rootAgent),
-                SessionService: session.InMemoryService(),
-        }
-
-        log.Printf("Starting A2A prime checker server on port %d\n", port)
-        // Run launcher
-        if err := launcher.Run(context.Background(), config); err != nil {
-                log.Fatalf("launcher.Run() error = %v", err)
-        }
-
-
+	// The instruction of root_agent is generated, so its skills cannot be
+	// derived from it: they are those of roll_agent and of the remote agents
+	// available when the card is requested.
+	rootCard := []a2aserver.CardOption{
+		a2aserver.WithDescription("Rolls dice, checks whether numbers are prime and delegates to the remote agents it knows."),
+		a2aserver.WithCurrentSkills(router.CardSkills),
+	}
+	if err := a2aserver.Serve(ctx, serverCfg, root.Current, session.InMemoryService(), handlers, rootCard...); err != nil {
+		log.Fatalf("Failed to serve A2A: %v", err)
+	}
 }
@@ -16,61 +16,89 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
 	"log"
+	"os"
 	"strconv"
 	"strings"
 
+	a2acore "github.com/a2aproject/a2a-go/a2a"
+	"google.golang.org/adk/agent"
 	"google.golang.org/adk/agent/llmagent"
-	"google.golang.org/adk/cmd/launcher/adk"
-	"google.golang.org/adk/cmd/launcher/web"
-	"google.golang.org/adk/cmd/launcher/web/a2a"
 	"google.golang.org/adk/model/gemini"
-	"google.golang.org/adk/server/restapi/services"
 	"google.golang.org/adk/session"
 	"google.golang.org/adk/tool"
 	"google.golang.org/adk/tool/functiontool"
 	"google.golang.org/genai"
+
+	"a2a-common-go/a2aserver"
+	"a2a-common-go/calc"
+	"a2a-common-go/flagutil"
+	"a2a-common-go/openapi"
+	"a2a-common-go/toolkit"
 )
 
-// isPrime checks if a number is prime.
-func isPrime(n int) bool {
+// isPrime checks if a number is prime. Large numbers take a while, so it
+// gives up with the context's error once ctx is done.
+func isPrime(ctx context.Context, n int) (bool, error) {
 	if n <= 1 {
-		return false
+		return false, nil
 	}
 	for i := 2; i*i <= n; i++ {
 		if n%i == 0 {
-			return false
+			return false, nil
+		}
+		if i%(1<<16) == 0 && ctx.Err() != nil {
+			return false, ctx.Err()
 		}
 	}
-	return true
+	return true, nil
 }
 
 type checkPrimeToolArgs struct {
 	Nums []int `json:"nums" jsonschema:"A list of numbers to check for primality."`
 }
 
-func checkPrimeTool(tc tool.Context, args checkPrimeToolArgs) string {
+func checkPrimeTool(tc tool.Context, args checkPrimeToolArgs) (string, error) {
 	var primes []int
 	for _, num := range args.Nums {
-		if isPrime(num) {
+		prime, err := isPrime(tc, num)
+		if err != nil {
+			return "", err
+		}
+		if prime {
 			primes = append(primes, num)
 		}
 	}
 	if len(primes) == 0 {
-		return "No prime numbers found."
+		return "No prime numbers found.", nil
 	}
 	var primeStrings []string
 	for _, p := range primes {
 		primeStrings = append(primeStrings, strconv.Itoa(p))
 	}
-	return fmt.Sprintf("%s are prime numbers.", strings.Join(primeStrings, ", "))
+	return fmt.Sprintf("%s are prime numbers.", strings.Join(primeStrings, ", ")), nil
 }
 
 // --8<-- [start:a2a-launcher]
 func main() {
 	ctx := context.Background()
-	primeTool, err := functiontool.New(functiontool.Config{
+	fs := flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+	serverCfg, err := a2aserver.NewConfig(fs, 8086)
+	if err != nil {
+		log.Fatalf("Invalid server configuration: %v", err)
+	}
+	if err := flagutil.Parse(fs, os.Args[1:]); err != nil {
+		log.Fatalf("Invalid arguments: %v", err)
+	}
+	if err := serverCfg.Validate(); err != nil {
+		log.Fatalf("Invalid server configuration: %v", err)
+	}
+
+	// A cancelled or timed out check is reported as a tool_timeout error.
+	var primeTool tool.Tool
+	primeTool, err = toolkit.NewValidatedTool(functiontool.Config{
 		Name:        "prime_checking",
 		Description: "Check if numbers in a list are prime using efficient mathematical algorithms",
 	}, checkPrimeTool)
@@ -78,6 +106,66 @@ func main() {
 		log.Fatalf("Failed to create prime_checking tool: %v", err)
 	}
 
+	calcTool, err := calc.NewTool()
+	if err != nil {
+		log.Fatalf("Failed to create calculator: %v", err)
+	}
+
+	limiter, err := toolkit.LimiterFromEnv()
+	if err != nil {
+		log.Fatalf("Failed to create tool limiter: %v", err)
+	}
+	primeTool, err = limiter.Limit(primeTool)
+	if err != nil {
+		log.Fatalf("Failed to limit prime_checking tool: %v", err)
+	}
+
+	// Checking primes has no side effects, so several calls can run at once.
+	calls, err := toolkit.ParallelCallsFromEnv()
+	if err != nil {
+		log.Fatalf("Failed to configure parallel tool calls: %v", err)
+	}
+	if err := calls.Add(primeTool, true); err != nil {
+		log.Fatalf("Failed to add prime_checking tool: %v", err)
+	}
+
+	openAPI, err := openapi.ConfigFromEnv()
+	if err != nil {
+		log.Fatalf("Failed to load OpenAPI config: %v", err)
+	}
+	apiTools, err := openAPI.ToolsFor("check_prime_agent")
+	if err != nil {
+		log.Fatalf("Failed to create OpenAPI tools: %v", err)
+	}
+	tools := []tool.Tool{primeTool}
+	// Calculations have no side effects either.
+	limitedCalc, err := limiter.Limit(calcTool)
+	if err != nil {
+		log.Fatalf("Failed to limit calculate tool: %v", err)
+	}
+	if err := calls.Add(limitedCalc, true); err != nil {
+		log.Fatalf("Failed to add calculate tool: %v", err)
+	}
+	tools = append(tools, limitedCalc)
+	for _, t := range apiTools {
+		// Tools of another kind may have side effects, so they run alone.
+		api, ok := t.(*openapi.Tool)
+		concurrent := ok && api.ReadOnly()
+		if t, err = limiter.Limit(t); err != nil {
+			log.Fatalf("Failed to limit tool: %v", err)
+		}
+		if err := calls.Add(t, concurrent); err != nil {
+			log.Fatalf("Failed to add tool: %v", err)
+		}
+		tools = append(tools, t)
+	}
+	// run_in_parallel is not limited: the calls it runs are.
+	parallelTool, err := calls.Tool()
+	if err != nil {
+		log.Fatalf("Failed to create run_in_parallel tool: %v", err)
+	}
+	tools = append(tools, parallelTool)
+
 	model, err := gemini.NewModel(ctx, "gemini-2.5-flash", &genai.ClientConfig{})
 	if err != nil {
 		log.Fatalf("Failed to create model: %v", err)
@@ -90,36 +178,41 @@ func main() {
 			You check whether numbers are prime.
 			When checking prime numbers, call the check_prime tool with a list of integers. Be sure to pass in a list of integers. You should never pass in a string.
 			You should not rely on the previous history on prime results.
+			If a number has to be computed first, e.g. the sum of rolls, compute it with the calculate tool.
+			To make several independent calls at once, call run_in_parallel with all of them.
     `,
 		Model: model,
-		Tools: []tool.Tool{primeTool},
+		Tools: tools,
 	})
 	if err != nil {
 		log.Fatalf("Failed to create agent: %v", err)
 	}
 
-	// Create launcher. The a2a.NewLauncher() will dynamically generate the agent card.
-	port := 8086
-	launcher := web.NewLauncher(a2a.NewLauncher())
-	_, err = launcher.Parse([]string{
-		"--port", strconv.Itoa(port),
-		"a2a", "--a2a_agent_url", "http://0.0.0.0:" + strconv.Itoa(port),
-	})
-	if err != nil {
-		log.Fatalf("launcher.Parse() error = %v", err)
-	}
-
-	// Create ADK config
-	config := &adk.Config{
-		AgentLoader:    services.NewSingleAgentLoader(primeAgent),
-		SessionService: session.InMemoryService(),
+	if err := a2aserver.Serve(ctx, serverCfg, func() agent.Agent { return primeAgent }, session.InMemoryService(), nil, primeCardOptions...); err != nil {
+		log.Fatalf("Failed to serve A2A: %v", err)
 	}
+}
 
-	log.Printf("Starting A2A prime checker server on port %d\n", port)
-	// Run launcher
-	if err := launcher.Run(context.Background(), config); err != nil {
-		log.Fatalf("launcher.Run() error = %v", err)
-	}
+// primeCardOptions advertise what check_prime_agent does in its agent card,
+// for clients and routers choosing an agent by its skills.
+var primeCardOptions = []a2aserver.CardOption{
+	a2aserver.WithDescription("Checks whether numbers are prime, computing them first if needed."),
+	a2aserver.WithSkills(
+		a2acore.AgentSkill{
+			ID:          "check_prime",
+			Name:        "check_prime",
+			Description: "Checks whether numbers are prime.",
+			Tags:        []string{"prime", "primes", "primality"},
+			Examples:    []string{"Is 7 prime?", "Which of 15, 17 and 21 are prime?"},
+		},
+		a2acore.AgentSkill{
+			ID:          "calculate",
+			Name:        "calculate",
+			Description: "Computes arithmetic expressions exactly, e.g. to check whether a sum of rolls is prime.",
+			Tags:        []string{"arithmetic", "calculate"},
+			Examples:    []string{"Is 3 + 4 + 6 prime?", "Is 2^31 - 1 prime?"},
+		},
+	),
 }
 
 // --8<-- [end:a2a-launcher]
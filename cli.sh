@@ -3,5 +3,5 @@ source $HOME/adk-hello-world-go/set_env.sh
 cd hello-agent
 
 echo `pwd`
-echo go run agent.go
-go run agent.go
+echo go run .
+go run .
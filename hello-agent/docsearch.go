@@ -0,0 +1,484 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"google.golang.org/adk/tool"
+	"google.golang.org/adk/tool/functiontool"
+
+	"a2a-common-go/toolkit"
+)
+
+// --- Document Search ---
+//
+// The search_docs tool lets the agent answer from local Markdown and text
+// files, e.g. runbooks, and cite them. The files under DOCS_DIR are split
+// into passages, at headings and paragraph breaks, and ranked with BM25.
+// The index is saved to DOCS_INDEX and kept up to date incrementally in the
+// background: every DOCS_REFRESH_INTERVAL, files that were added, changed or
+// removed since the last refresh are reindexed, everything else is reused.
+// Searches never touch the files, they read the latest index.
+
+const (
+	// docsDirEnv names the directory to index. The tool is only available
+	// when it is set.
+	docsDirEnv = "DOCS_DIR"
+	// docsIndexEnv names the file the index is saved to. Defaults to a file
+	// in the user cache directory.
+	docsIndexEnv = "DOCS_INDEX"
+	// docsRefreshEnv sets how often the directory is checked for changes.
+	docsRefreshEnv = "DOCS_REFRESH_INTERVAL"
+
+	defaultDocsRefresh = 30 * time.Second
+
+	docsIndexVersion = 1
+
+	// Passages end at the first paragraph break after passageMinLines lines
+	// and are cut at passageMaxLines lines at the latest.
+	passageMinLines = 8
+	passageMaxLines = 40
+
+	defaultTopK = 5
+
+	// BM25 parameters.
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// docsExtensions are the extensions of the files that are indexed.
+var docsExtensions = map[string]bool{".md": true, ".markdown": true, ".txt": true}
+
+// docsStopWords are left out of the index; they match nearly every passage.
+var docsStopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true, "be": true,
+	"by": true, "for": true, "from": true, "how": true, "in": true, "is": true, "it": true,
+	"of": true, "on": true, "or": true, "that": true, "the": true, "this": true, "to": true,
+	"was": true, "what": true, "when": true, "with": true,
+}
+
+// docIndex is a BM25 index of the passages of the files in a directory.
+type docIndex struct {
+	dir  string // absolute
+	path string // where the index is saved
+
+	refreshMu sync.Mutex // held while refreshing, the only writer
+
+	// The fields below are replaced, never modified, by a refresh.
+	mu    sync.RWMutex
+	files map[string]*indexedFile // by slash-separated path relative to dir
+	// Corpus statistics, derived from files.
+	docFreq   map[string]int
+	passages  int
+	avgLength float64
+}
+
+type indexedFile struct {
+	ModTime  time.Time `json:"mod_time"`
+	Size     int64     `json:"size"`
+	Passages []passage `json:"passages"`
+}
+
+type passage struct {
+	StartLine int            `json:"start_line"`
+	EndLine   int            `json:"end_line"`
+	Section   string         `json:"section,omitempty"`
+	Text      string         `json:"text"`
+	Terms     map[string]int `json:"terms"`
+	Length    int            `json:"length"`
+}
+
+// savedDocIndex is the on-disk form of a docIndex.
+type savedDocIndex struct {
+	Version int                     `json:"version"`
+	Dir     string                  `json:"dir"`
+	Files   map[string]*indexedFile `json:"files"`
+}
+
+// docIndexFromEnv opens the index of DOCS_DIR, building or updating it as
+// needed, and keeps it up to date until ctx is done. It returns nil if
+// DOCS_DIR is not set.
+func docIndexFromEnv(ctx context.Context) (*docIndex, error) {
+	dir := os.Getenv(docsDirEnv)
+	if dir == "" {
+		return nil, nil
+	}
+	interval := defaultDocsRefresh
+	if v := os.Getenv(docsRefreshEnv); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("invalid %s %q: must be a positive duration", docsRefreshEnv, v)
+		}
+		interval = d
+	}
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", docsDirEnv, err)
+	}
+	path := os.Getenv(docsIndexEnv)
+	if path == "" {
+		cache, err := os.UserCacheDir()
+		if err != nil {
+			return nil, fmt.Errorf("no %s set and no cache directory: %w", docsIndexEnv, err)
+		}
+		sum := sha256.Sum256([]byte(dir))
+		path = filepath.Join(cache, "hello-agent", "docs-"+hex.EncodeToString(sum[:8])+".json")
+	}
+	idx, err := openDocIndex(dir, path)
+	if err != nil {
+		return nil, err
+	}
+	go idx.watch(ctx, interval)
+	return idx, nil
+}
+
+// openDocIndex loads the index saved at path and brings it up to date with
+// the files in dir.
+func openDocIndex(dir, path string) (*docIndex, error) {
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return nil, fmt.Errorf("invalid documents directory %q: not a directory", dir)
+	}
+	idx := &docIndex{dir: dir, path: path}
+
+	var saved savedDocIndex
+	data, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+	case err != nil:
+		return nil, fmt.Errorf("failed to read index: %w", err)
+	case json.Unmarshal(data, &saved) != nil || saved.Version != docsIndexVersion || saved.Dir != dir:
+		log.Printf("Ignoring outdated or unreadable index %s", path)
+	default:
+		idx.files = saved.Files
+	}
+
+	if _, err := idx.refresh(); err != nil {
+		return nil, err
+	}
+	log.Printf("Indexed %d passages of %d files in %s", idx.passages, len(idx.files), dir)
+	return idx, nil
+}
+
+// watch refreshes the index every interval until ctx is done.
+func (idx *docIndex) watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		changed, err := idx.refresh()
+		if err != nil {
+			log.Printf("Failed to refresh the index of %s: %v", idx.dir, err)
+			continue
+		}
+		if changed {
+			idx.mu.RLock()
+			log.Printf("Reindexed %s: %d passages of %d files", idx.dir, idx.passages, len(idx.files))
+			idx.mu.RUnlock()
+		}
+	}
+}
+
+// refresh reindexes the files that changed since the last refresh and saves
+// the index if anything did. Searches keep using the previous index until
+// the new one is ready. It reports whether anything changed.
+func (idx *docIndex) refresh() (bool, error) {
+	idx.refreshMu.Lock()
+	defer idx.refreshMu.Unlock()
+
+	files := make(map[string]*indexedFile)
+	changed := idx.docFreq == nil
+	err := filepath.WalkDir(idx.dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if strings.HasPrefix(d.Name(), ".") && path != idx.dir {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() || !docsExtensions[strings.ToLower(filepath.Ext(path))] {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(idx.dir, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+
+		if f, ok := idx.files[rel]; ok && f.ModTime.Equal(info.ModTime()) && f.Size == info.Size() {
+			files[rel] = f
+			return nil
+		}
+		passages, err := splitPassages(path)
+		if err != nil {
+			return err
+		}
+		files[rel] = &indexedFile{ModTime: info.ModTime(), Size: info.Size(), Passages: passages}
+		changed = true
+		return nil
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to index %s: %w", idx.dir, err)
+	}
+	// Every file kept is in both, so a difference in size means removals.
+	if !changed && len(files) == len(idx.files) {
+		return false, nil
+	}
+
+	docFreq := make(map[string]int)
+	passages, total := 0, 0
+	for _, f := range files {
+		for _, p := range f.Passages {
+			for term := range p.Terms {
+				docFreq[term]++
+			}
+			passages++
+			total += p.Length
+		}
+	}
+	avgLength := 0.0
+	if passages > 0 {
+		avgLength = float64(total) / float64(passages)
+	}
+
+	idx.mu.Lock()
+	idx.files, idx.docFreq, idx.passages, idx.avgLength = files, docFreq, passages, avgLength
+	idx.mu.Unlock()
+	return true, idx.save(files)
+}
+
+// save writes the index of files atomically, so a crash never leaves a
+// partial file behind.
+func (idx *docIndex) save(files map[string]*indexedFile) error {
+	data, err := json.Marshal(savedDocIndex{Version: docsIndexVersion, Dir: idx.dir, Files: files})
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(idx.path), 0o755); err != nil {
+		return fmt.Errorf("failed to save index: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(idx.path), ".docs-index-*")
+	if err != nil {
+		return fmt.Errorf("failed to save index: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to save index: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to save index: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), idx.path); err != nil {
+		return fmt.Errorf("failed to save index: %w", err)
+	}
+	return nil
+}
+
+// searchResult is a passage matching a query.
+type searchResult struct {
+	Path      string  `json:"path"`
+	StartLine int     `json:"start_line"`
+	EndLine   int     `json:"end_line"`
+	Section   string  `json:"section,omitempty"`
+	Score     float64 `json:"score"`
+	Text      string  `json:"text"`
+}
+
+// search returns the k passages ranking best for the query, best first.
+func (idx *docIndex) search(query string, k int) []searchResult {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	terms := make(map[string]bool)
+	for _, term := range tokenize(query) {
+		terms[term] = true
+	}
+	var results []searchResult
+	for rel, f := range idx.files {
+		for _, p := range f.Passages {
+			score := 0.0
+			for term := range terms {
+				tf := float64(p.Terms[term])
+				if tf == 0 {
+					continue
+				}
+				df := float64(idx.docFreq[term])
+				idf := math.Log(1 + (float64(idx.passages)-df+0.5)/(df+0.5))
+				norm := 1 - bm25B + bm25B*float64(p.Length)/idx.avgLength
+				score += idf * tf * (bm25K1 + 1) / (tf + bm25K1*norm)
+			}
+			if score > 0 {
+				results = append(results, searchResult{
+					Path:      rel,
+					StartLine: p.StartLine,
+					EndLine:   p.EndLine,
+					Section:   p.Section,
+					Score:     math.Round(score*1000) / 1000,
+					Text:      p.Text,
+				})
+			}
+		}
+	}
+	sort.Slice(results, func(i, j int) bool {
+		a, b := results[i], results[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		if a.Path != b.Path {
+			return a.Path < b.Path
+		}
+		return a.StartLine < b.StartLine
+	})
+	if len(results) > k {
+		results = results[:k]
+	}
+	return results
+}
+
+// splitPassages splits a file into passages. A Markdown heading starts a new
+// passage and becomes the section of the passages below it.
+func splitPassages(path string) ([]passage, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	markdown := strings.ToLower(filepath.Ext(path)) != ".txt"
+
+	var passages []passage
+	var lines []string
+	start, section := 0, ""
+	flush := func() {
+		// Leading blank lines are never collected; drop trailing ones.
+		for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+			lines = lines[:len(lines)-1]
+		}
+		if len(lines) > 0 {
+			text := strings.Join(lines, "\n")
+			terms := make(map[string]int)
+			length := 0
+			for _, term := range tokenize(section + "\n" + text) {
+				terms[term]++
+				length++
+			}
+			if length > 0 {
+				passages = append(passages, passage{
+					StartLine: start,
+					EndLine:   start + len(lines) - 1,
+					Section:   section,
+					Text:      text,
+					Terms:     terms,
+					Length:    length,
+				})
+			}
+		}
+		lines = nil
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	inFence := false
+	for n := 1; scanner.Scan(); n++ {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+		if markdown && strings.HasPrefix(trimmed, "```") {
+			inFence = !inFence
+		}
+		if markdown && !inFence && strings.HasPrefix(trimmed, "#") {
+			if heading := strings.TrimSpace(strings.TrimLeft(trimmed, "#")); heading != "" {
+				flush()
+				section = heading
+			}
+		}
+		if trimmed == "" {
+			if len(lines) >= passageMinLines && !inFence {
+				flush()
+			}
+			if len(lines) == 0 {
+				continue
+			}
+		}
+		if len(lines) == 0 {
+			start = n
+		}
+		lines = append(lines, line)
+		if len(lines) >= passageMaxLines {
+			flush()
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	flush()
+	return passages, nil
+}
+
+// tokenize lowercases text and splits it into words, leaving out stop words.
+func tokenize(text string) []string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	terms := words[:0]
+	for _, w := range words {
+		if !docsStopWords[w] {
+			terms = append(terms, w)
+		}
+	}
+	return terms
+}
+
+// --- Search Tool ---
+
+type searchDocsArgs struct {
+	Query string `json:"query" jsonschema:"Keywords to search for." validate:"min=1"`
+	TopK  int    `json:"top_k,omitempty" jsonschema:"The number of passages to return, 5 if not set." validate:"min=0,max=20"`
+}
+
+type searchDocsResults struct {
+	Passages []searchResult `json:"passages"`
+	Message  string         `json:"message,omitempty"`
+}
+
+// newSearchDocsTool creates the search_docs tool, searching idx.
+func newSearchDocsTool(idx *docIndex) (toolkit.FunctionTool, error) {
+	return toolkit.NewValidatedTool(functiontool.Config{
+		Name: "search_docs",
+		Description: "Search the internal documentation, such as runbooks, and return the best matching passages " +
+			"with the path and line range they come from. Cite the path and lines when using a passage.",
+	}, func(tc tool.Context, args searchDocsArgs) (searchDocsResults, error) {
+		k := args.TopK
+		if k == 0 {
+			k = defaultTopK
+		}
+		results := idx.search(args.Query, k)
+		if len(results) == 0 {
+			return searchDocsResults{Passages: []searchResult{}, Message: "No matching passages found."}, nil
+		}
+		return searchDocsResults{Passages: results}, nil
+	})
+}
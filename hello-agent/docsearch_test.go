@@ -0,0 +1,242 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"a2a-common-go/toolkit"
+)
+
+// writeDocs writes the files, by path relative to dir.
+func writeDocs(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for rel, text := range files {
+		path := filepath.Join(dir, rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(text), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func newTestDocIndex(t *testing.T, files map[string]string) *docIndex {
+	t.Helper()
+	dir := t.TempDir()
+	writeDocs(t, dir, files)
+	idx, err := openDocIndex(dir, filepath.Join(t.TempDir(), "index.json"))
+	if err != nil {
+		t.Fatalf("openDocIndex() error = %v", err)
+	}
+	return idx
+}
+
+// resultPaths returns the paths of the results, in order.
+func resultPaths(results []searchResult) []string {
+	var paths []string
+	for _, r := range results {
+		paths = append(paths, r.Path)
+	}
+	return paths
+}
+
+func TestDocIndexSearch(t *testing.T) {
+	idx := newTestDocIndex(t, map[string]string{
+		"restart.md": "# Restarting\n\nRestart the server, then restart the workers.\n",
+		"backup.md":  "# Backups\n\nBackups run nightly. Restore one with the restore script.\n",
+		"notes/oncall.txt": "The pager rotates weekly. Escalate outages to the lead, check the dashboards, " +
+			"read the runbook, restart the server if it hangs, and write a report afterwards.\n",
+		".hidden/restart.md": "restart restart restart\n",
+		"image.png":          "restart\n",
+	})
+
+	tests := []struct {
+		query string
+		k     int
+		want  []string
+	}{
+		// More occurrences in a shorter passage rank higher.
+		{query: "restart", k: 5, want: []string{"restart.md", "notes/oncall.txt"}},
+		{query: "RESTART", k: 1, want: []string{"restart.md"}},
+		// A term found in one passage only outweighs one found in two, and
+		// of those two the shorter passage ranks higher.
+		{query: "server nightly", k: 5, want: []string{"backup.md", "restart.md", "notes/oncall.txt"}},
+		{query: "restore script", k: 5, want: []string{"backup.md"}},
+		{query: "the and of", k: 5, want: nil},
+		{query: "missing", k: 5, want: nil},
+	}
+	for _, tt := range tests {
+		if got := resultPaths(idx.search(tt.query, tt.k)); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("search(%q, %d) = %v, want %v", tt.query, tt.k, got, tt.want)
+		}
+	}
+
+	results := idx.search("restore", 5)
+	if len(results) != 1 {
+		t.Fatalf("search() = %v, want 1 result", results)
+	}
+	r := results[0]
+	if r.StartLine != 1 || r.EndLine != 3 || r.Section != "Backups" || r.Score <= 0 {
+		t.Errorf("search() = %+v, want lines 1-3 of section Backups", r)
+	}
+}
+
+func TestSplitPassages(t *testing.T) {
+	var long strings.Builder
+	for range passageMaxLines + 5 {
+		long.WriteString("line\n")
+	}
+	dir := t.TempDir()
+	writeDocs(t, dir, map[string]string{
+		"doc.md":   "intro\n\n# One\ntext\n```\n# not a heading\n```\n## Two\nmore\n",
+		"long.txt": long.String(),
+	})
+
+	passages, err := splitPassages(filepath.Join(dir, "doc.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got [][3]any
+	for _, p := range passages {
+		got = append(got, [3]any{p.StartLine, p.EndLine, p.Section})
+	}
+	want := [][3]any{{1, 1, ""}, {3, 7, "One"}, {8, 9, "Two"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("splitPassages(doc.md) = %v, want %v", got, want)
+	}
+
+	passages, err = splitPassages(filepath.Join(dir, "long.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(passages) != 2 || passages[0].EndLine != passageMaxLines {
+		t.Errorf("splitPassages(long.txt) = %d passages, want a cut after %d lines", len(passages), passageMaxLines)
+	}
+}
+
+func TestDocIndexRefresh(t *testing.T) {
+	dir := t.TempDir()
+	writeDocs(t, dir, map[string]string{
+		"keep.md":   "# Keep\nunchanged runbook\n",
+		"change.md": "# Change\nold runbook\n",
+		"remove.md": "# Remove\nobsolete runbook\n",
+	})
+	path := filepath.Join(t.TempDir(), "index.json")
+	idx, err := openDocIndex(dir, path)
+	if err != nil {
+		t.Fatalf("openDocIndex() error = %v", err)
+	}
+	kept := idx.files["keep.md"]
+
+	writeDocs(t, dir, map[string]string{
+		"change.md": "# Change\nnew and longer runbook\n",
+		"add.md":    "# Add\nadded runbook\n",
+	})
+	if err := os.Remove(filepath.Join(dir, "remove.md")); err != nil {
+		t.Fatal(err)
+	}
+
+	// Searches use the index as it was until it is refreshed.
+	if got := resultPaths(idx.search("obsolete", 5)); !reflect.DeepEqual(got, []string{"remove.md"}) {
+		t.Errorf("search() before refresh = %v, want the removed file", got)
+	}
+	changed, err := idx.refresh()
+	if err != nil || !changed {
+		t.Fatalf("refresh() = %v, %v, want a change", changed, err)
+	}
+	if idx.files["keep.md"] != kept {
+		t.Error("unchanged file reindexed")
+	}
+	for query, want := range map[string][]string{"obsolete": nil, "old": nil, "longer": {"change.md"}, "added": {"add.md"}} {
+		if got := resultPaths(idx.search(query, 5)); !reflect.DeepEqual(got, want) {
+			t.Errorf("search(%q) = %v, want %v", query, got, want)
+		}
+	}
+	if idx.passages != 3 {
+		t.Errorf("passages = %d, want 3", idx.passages)
+	}
+	if changed, err := idx.refresh(); err != nil || changed {
+		t.Errorf("refresh() without changes = %v, %v, want none", changed, err)
+	}
+
+	// A reopened index reuses the saved passages of unchanged files.
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(strings.ReplaceAll(string(data), "unchanged", "cached")), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	idx, err = openDocIndex(dir, path)
+	if err != nil {
+		t.Fatalf("openDocIndex() error = %v", err)
+	}
+	if got := idx.files["keep.md"].Passages[0].Text; !strings.Contains(got, "cached") {
+		t.Errorf("passage of the reopened index = %q, want the saved one", got)
+	}
+}
+
+func TestDocIndexWatch(t *testing.T) {
+	dir := t.TempDir()
+	writeDocs(t, dir, map[string]string{"a.md": "first\n"})
+	idx, err := openDocIndex(dir, filepath.Join(t.TempDir(), "index.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go idx.watch(ctx, 10*time.Millisecond)
+
+	writeDocs(t, dir, map[string]string{"b.md": "second\n"})
+	deadline := time.Now().Add(5 * time.Second)
+	for len(idx.search("second", 5)) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("new file not indexed in the background")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestSearchDocsTool(t *testing.T) {
+	idx := newTestDocIndex(t, map[string]string{"a.md": "# Deploys\nDeploy with make deploy.\n"})
+	searchDocs, err := newSearchDocsTool(idx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		args     map[string]any
+		wantCode string
+		wantLen  int
+	}{
+		{args: map[string]any{"query": "deploy"}, wantLen: 1},
+		{args: map[string]any{"query": "deploy", "top_k": 20}, wantLen: 1},
+		{args: map[string]any{"query": "rollback"}},
+		{args: map[string]any{"query": "  "}},
+		{args: map[string]any{"query": ""}, wantCode: toolkit.CodeInvalidArguments},
+		{args: map[string]any{"query": "deploy", "top_k": 21}, wantCode: toolkit.CodeInvalidArguments},
+		{args: map[string]any{"query": "deploy", "top_k": -1}, wantCode: toolkit.CodeInvalidArguments},
+		{args: map[string]any{"query": "deploy", "top_k": "all"}, wantCode: toolkit.CodeInvalidArguments},
+		{args: map[string]any{}, wantCode: toolkit.CodeInvalidArguments},
+	}
+	for _, tt := range tests {
+		got, err := searchDocs.Run(nil, tt.args)
+		if err != nil {
+			t.Fatalf("Run(%v) error = %v", tt.args, err)
+		}
+		if tt.wantCode != "" {
+			if e, _ := got["error"].(map[string]any); e["code"] != tt.wantCode {
+				t.Errorf("Run(%v) = %v, want a %s error", tt.args, got, tt.wantCode)
+			}
+			continue
+		}
+		if n := reflect.ValueOf(got["passages"]).Len(); n != tt.wantLen {
+			t.Errorf("Run(%v) = %v, want %d passages", tt.args, got, tt.wantLen)
+		}
+	}
+}